@@ -1,7 +1,10 @@
 package agent
 
 import (
+	"context"
+	"encoding/json"
 	"fmt"
+	"time"
 
 	"github.com/anxuanzi/bua/browser"
 	"github.com/anxuanzi/bua/dom"
@@ -14,6 +17,11 @@ type BrowserToolkit struct {
 	browser    *browser.Browser
 	elementMap *dom.ElementMap
 	maxWidth   int
+
+	// extractedTables holds the full tables from the last extract_table call
+	// so done can return them without the model re-typing every row. It is
+	// cleared when a run starts, so tables never leak into another task.
+	extractedTables []dom.Table
 }
 
 // NewBrowserToolkit creates a new browser toolkit.
@@ -25,8 +33,8 @@ func NewBrowserToolkit(b *browser.Browser, maxWidth int) *BrowserToolkit {
 }
 
 // RefreshElementMap updates the cached element map.
-func (t *BrowserToolkit) RefreshElementMap() error {
-	em, err := t.browser.GetElementMap(nil)
+func (t *BrowserToolkit) RefreshElementMap(ctx context.Context) error {
+	em, err := t.browser.GetElementMap(ctx)
 	if err != nil {
 		return err
 	}
@@ -39,255 +47,377 @@ func (t *BrowserToolkit) GetElementMap() *dom.ElementMap {
 	return t.elementMap
 }
 
+// ExtractedTables returns the tables from the last extract_table call.
+func (t *BrowserToolkit) ExtractedTables() []dom.Table {
+	return t.extractedTables
+}
+
+// SetExtractedTables replaces the extracted tables, e.g. to restore them
+// when a run resumes or to clear them when a new run starts (nil).
+func (t *BrowserToolkit) SetExtractedTables(tables []dom.Table) {
+	t.extractedTables = tables
+}
+
 // ---- Tool Argument Structs (ADK format with json + jsonschema tags) ----
 
+// AgentBrain is the structured reasoning the model attaches to every tool
+// call. It is recorded on the step and fed back through the history so the
+// model can track progress across turns.
+type AgentBrain struct {
+	Thinking   string `json:"thinking,omitempty" jsonschema:"Brief reasoning about the current state and why this action"`
+	Evaluation string `json:"evaluation_previous_goal,omitempty" jsonschema:"Did the previous action achieve its goal? Success, Failed or Unknown, and why"`
+	Memory     string `json:"memory,omitempty" jsonschema:"What to remember for later steps: progress, collected data, approaches already tried"`
+	NextGoal   string `json:"next_goal,omitempty" jsonschema:"The immediate goal this action works towards"`
+}
+
+// UnmarshalJSON accepts either the reasoning object or a plain string, as
+// used by older recorded scripts, which becomes Thinking.
+func (b *AgentBrain) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err == nil {
+		*b = AgentBrain{Thinking: text}
+		return nil
+	}
+	type brain AgentBrain
+	return json.Unmarshal(data, (*brain)(b))
+}
+
+// String returns the thinking, or the next goal if no thinking was given.
+func (b AgentBrain) String() string {
+	if b.Thinking != "" {
+		return b.Thinking
+	}
+	return b.NextGoal
+}
+
 // NavigateArgs is the input for the navigate tool.
 type NavigateArgs struct {
-	URL       string `json:"url" jsonschema:"The URL to navigate to"`
-	Reasoning string `json:"reasoning,omitempty" jsonschema:"Why navigating to this URL"`
+	URL       string     `json:"url" jsonschema:"The URL to navigate to"`
+	Reasoning AgentBrain `json:"reasoning,omitempty" jsonschema:"Why navigating to this URL"`
 }
 
 // NavigateResult is the output for the navigate tool.
 type NavigateResult struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
-	URL     string `json:"url,omitempty"`
+	Success    bool     `json:"success"`
+	Message    string   `json:"message"`
+	ErrorCode  string   `json:"error_code,omitempty"`
+	URL        string   `json:"url,omitempty"` // Final URL after redirects
+	HTTPStatus int      `json:"http_status,omitempty"`
+	Redirects  []string `json:"redirects,omitempty"` // Redirect chain, starting from the requested URL
 }
 
 // ClickArgs is the input for the click tool.
 type ClickArgs struct {
-	ElementIndex int    `json:"element_index" jsonschema:"The index of the element to click"`
-	Reasoning    string `json:"reasoning,omitempty" jsonschema:"Why clicking this element"`
+	ElementIndex int        `json:"element_index" jsonschema:"The index of the element to click"`
+	Reasoning    AgentBrain `json:"reasoning,omitempty" jsonschema:"Why clicking this element"`
 }
 
 // ClickResult is the output for the click tool.
 type ClickResult struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	ErrorCode string `json:"error_code,omitempty"`
 }
 
 // TypeTextArgs is the input for the type_text tool.
 type TypeTextArgs struct {
-	ElementIndex int    `json:"element_index" jsonschema:"The index of the element to type into"`
-	Text         string `json:"text" jsonschema:"The text to type"`
-	Reasoning    string `json:"reasoning,omitempty" jsonschema:"Why typing this text"`
+	ElementIndex int        `json:"element_index" jsonschema:"The index of the element to type into"`
+	Text         string     `json:"text" jsonschema:"The text to type"`
+	Reasoning    AgentBrain `json:"reasoning,omitempty" jsonschema:"Why typing this text"`
 }
 
 // TypeTextResult is the output for the type_text tool.
 type TypeTextResult struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	ErrorCode string `json:"error_code,omitempty"`
 }
 
 // ClearAndTypeArgs is the input for the clear_and_type tool.
 type ClearAndTypeArgs struct {
-	ElementIndex int    `json:"element_index" jsonschema:"The index of the element to clear and type into"`
-	Text         string `json:"text" jsonschema:"The text to type after clearing"`
-	Reasoning    string `json:"reasoning,omitempty" jsonschema:"Why clearing and typing"`
+	ElementIndex int        `json:"element_index" jsonschema:"The index of the element to clear and type into"`
+	Text         string     `json:"text" jsonschema:"The text to type after clearing"`
+	Reasoning    AgentBrain `json:"reasoning,omitempty" jsonschema:"Why clearing and typing"`
 }
 
 // ClearAndTypeResult is the output for the clear_and_type tool.
 type ClearAndTypeResult struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	ErrorCode string `json:"error_code,omitempty"`
 }
 
 // ScrollArgs is the input for the scroll tool.
 type ScrollArgs struct {
-	Direction    string `json:"direction" jsonschema:"Scroll direction: up, down, left, right"`
-	Amount       int    `json:"amount,omitzero" jsonschema:"Number of pixels to scroll (default 300)"`
-	ElementIndex *int   `json:"element_index,omitempty" jsonschema:"Optional element index to scroll within"`
-	Reasoning    string `json:"reasoning,omitempty" jsonschema:"Why scrolling"`
+	Direction    string     `json:"direction" jsonschema:"Scroll direction: up, down, left, right"`
+	Amount       int        `json:"amount,omitzero" jsonschema:"Number of pixels to scroll (default 300)"`
+	ElementIndex *int       `json:"element_index,omitempty" jsonschema:"Optional element index to scroll within"`
+	Reasoning    AgentBrain `json:"reasoning,omitempty" jsonschema:"Why scrolling"`
 }
 
 // ScrollResult is the output for the scroll tool.
 type ScrollResult struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	ErrorCode string `json:"error_code,omitempty"`
 }
 
 // SendKeysArgs is the input for the send_keys tool.
 type SendKeysArgs struct {
-	Keys      string `json:"keys" jsonschema:"The keys to send (Enter, Escape, Tab, etc.)"`
-	Reasoning string `json:"reasoning,omitempty" jsonschema:"Why sending these keys"`
+	Keys      string     `json:"keys" jsonschema:"The keys to send (Enter, Escape, Tab, etc.)"`
+	Reasoning AgentBrain `json:"reasoning,omitempty" jsonschema:"Why sending these keys"`
 }
 
 // SendKeysResult is the output for the send_keys tool.
 type SendKeysResult struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	ErrorCode string `json:"error_code,omitempty"`
 }
 
 // GoBackArgs is the input for the go_back tool.
 type GoBackArgs struct {
-	Reasoning string `json:"reasoning,omitempty" jsonschema:"Why going back"`
+	Reasoning AgentBrain `json:"reasoning,omitempty" jsonschema:"Why going back"`
 }
 
 // GoBackResult is the output for the go_back tool.
 type GoBackResult struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	ErrorCode string `json:"error_code,omitempty"`
 }
 
 // GoForwardArgs is the input for the go_forward tool.
 type GoForwardArgs struct {
-	Reasoning string `json:"reasoning,omitempty" jsonschema:"Why going forward"`
+	Reasoning AgentBrain `json:"reasoning,omitempty" jsonschema:"Why going forward"`
 }
 
 // GoForwardResult is the output for the go_forward tool.
 type GoForwardResult struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	ErrorCode string `json:"error_code,omitempty"`
 }
 
 // HoverArgs is the input for the hover tool.
 type HoverArgs struct {
-	ElementIndex int    `json:"element_index" jsonschema:"The index of the element to hover over"`
-	Reasoning    string `json:"reasoning,omitempty" jsonschema:"Why hovering over this element"`
+	ElementIndex int        `json:"element_index" jsonschema:"The index of the element to hover over"`
+	Reasoning    AgentBrain `json:"reasoning,omitempty" jsonschema:"Why hovering over this element"`
 }
 
 // HoverResult is the output for the hover tool.
 type HoverResult struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	ErrorCode string `json:"error_code,omitempty"`
 }
 
 // DoubleClickArgs is the input for the double_click tool.
 type DoubleClickArgs struct {
-	ElementIndex int    `json:"element_index" jsonschema:"The index of the element to double-click"`
-	Reasoning    string `json:"reasoning,omitempty" jsonschema:"Why double-clicking this element"`
+	ElementIndex int        `json:"element_index" jsonschema:"The index of the element to double-click"`
+	Reasoning    AgentBrain `json:"reasoning,omitempty" jsonschema:"Why double-clicking this element"`
 }
 
 // DoubleClickResult is the output for the double_click tool.
 type DoubleClickResult struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	ErrorCode string `json:"error_code,omitempty"`
 }
 
 // FocusArgs is the input for the focus tool.
 type FocusArgs struct {
-	ElementIndex int    `json:"element_index" jsonschema:"The index of the element to focus"`
-	Reasoning    string `json:"reasoning,omitempty" jsonschema:"Why focusing this element"`
+	ElementIndex int        `json:"element_index" jsonschema:"The index of the element to focus"`
+	Reasoning    AgentBrain `json:"reasoning,omitempty" jsonschema:"Why focusing this element"`
 }
 
 // FocusResult is the output for the focus tool.
 type FocusResult struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	ErrorCode string `json:"error_code,omitempty"`
 }
 
 // ReloadArgs is the input for the reload tool.
 type ReloadArgs struct {
-	Reasoning string `json:"reasoning,omitempty" jsonschema:"Why reloading the page"`
+	Reasoning AgentBrain `json:"reasoning,omitempty" jsonschema:"Why reloading the page"`
 }
 
 // ReloadResult is the output for the reload tool.
 type ReloadResult struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	ErrorCode string `json:"error_code,omitempty"`
 }
 
 // ScrollToElementArgs is the input for the scroll_to_element tool.
 type ScrollToElementArgs struct {
-	ElementIndex int    `json:"element_index" jsonschema:"The index of the element to scroll into view"`
-	Reasoning    string `json:"reasoning,omitempty" jsonschema:"Why scrolling to this element"`
+	ElementIndex int        `json:"element_index" jsonschema:"The index of the element to scroll into view"`
+	Reasoning    AgentBrain `json:"reasoning,omitempty" jsonschema:"Why scrolling to this element"`
 }
 
 // ScrollToElementResult is the output for the scroll_to_element tool.
 type ScrollToElementResult struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	ErrorCode string `json:"error_code,omitempty"`
 }
 
 // ExtractContentArgs is the input for the extract_content tool.
 type ExtractContentArgs struct {
-	Reasoning string `json:"reasoning,omitempty" jsonschema:"Why extracting content"`
+	Query        string     `json:"query,omitempty" jsonschema:"Optional description of the information needed; only relevant sections are returned"`
+	Selector     string     `json:"selector,omitempty" jsonschema:"Optional CSS selector to limit extraction to part of the page"`
+	ElementIndex *int       `json:"element_index,omitempty" jsonschema:"Optional element index; extracts the table, form, list or section containing it"`
+	Page         int        `json:"page,omitempty" jsonschema:"Page of the content to return for long pages (default 1)"`
+	Reasoning    AgentBrain `json:"reasoning,omitempty" jsonschema:"Why extracting content"`
 }
 
 // ExtractContentResult is the output for the extract_content tool.
 type ExtractContentResult struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
-	Content string `json:"content,omitempty"`
+	Success    bool   `json:"success"`
+	Message    string `json:"message"`
+	ErrorCode  string `json:"error_code,omitempty"`
+	Content    string `json:"content,omitempty"`
+	Page       int    `json:"page,omitempty"`
+	TotalPages int    `json:"total_pages,omitempty"`
+}
+
+// ExtractTableArgs is the input for the extract_table tool.
+type ExtractTableArgs struct {
+	Selector     string     `json:"selector,omitempty" jsonschema:"Optional CSS selector for the table or a container of tables"`
+	ElementIndex *int       `json:"element_index,omitempty" jsonschema:"Optional element index; extracts the table containing it"`
+	Reasoning    AgentBrain `json:"reasoning,omitempty" jsonschema:"Why extracting this table"`
 }
 
+// ExtractTableResult is the output for the extract_table tool.
+type ExtractTableResult struct {
+	Success   bool        `json:"success"`
+	Message   string      `json:"message"`
+	ErrorCode string      `json:"error_code,omitempty"`
+	Tables    []dom.Table `json:"tables,omitempty"`
+}
+
+// extractTablePreviewRows is the number of rows per table shown to the model.
+// The full tables are kept on the toolkit for done(use_extracted_tables).
+const extractTablePreviewRows = 50
+
+// extractContentPageSize is the maximum number of characters returned per extract_content page.
+const extractContentPageSize = 10000
+
 // ScreenshotArgs is the input for the screenshot tool.
 type ScreenshotArgs struct {
-	FullPage  bool   `json:"full_page,omitempty" jsonschema:"Whether to capture the full page or just the viewport"`
-	Reasoning string `json:"reasoning,omitempty" jsonschema:"Why taking a screenshot"`
+	FullPage  bool       `json:"full_page,omitempty" jsonschema:"Whether to capture the full page or just the viewport"`
+	Reasoning AgentBrain `json:"reasoning,omitempty" jsonschema:"Why taking a screenshot"`
 }
 
 // ScreenshotResult is the output for the screenshot tool.
 type ScreenshotResult struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
-	Size    int    `json:"size,omitempty"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	ErrorCode string `json:"error_code,omitempty"`
+	Size      int    `json:"size,omitempty"`
 }
 
 // EvaluateJSArgs is the input for the evaluate_js tool.
 type EvaluateJSArgs struct {
-	Script    string `json:"script" jsonschema:"The JavaScript code to execute"`
-	Reasoning string `json:"reasoning,omitempty" jsonschema:"Why running this JavaScript"`
+	Script    string     `json:"script" jsonschema:"The JavaScript code to execute"`
+	Reasoning AgentBrain `json:"reasoning,omitempty" jsonschema:"Why running this JavaScript"`
 }
 
 // EvaluateJSResult is the output for the evaluate_js tool.
 type EvaluateJSResult struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
-	Result  string `json:"result,omitempty"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	ErrorCode string `json:"error_code,omitempty"`
+	Result    string `json:"result,omitempty"`
 }
 
 // WaitArgs is the input for the wait tool.
 type WaitArgs struct {
-	DurationMs int    `json:"duration_ms,omitzero" jsonschema:"Number of milliseconds to wait (default 1000, max 10000)"`
-	Reason     string `json:"reason,omitempty" jsonschema:"Why waiting"`
+	DurationMs int        `json:"duration_ms,omitzero" jsonschema:"Number of milliseconds to wait (default 1000, max 10000)"`
+	Reason     string     `json:"reason,omitempty" jsonschema:"Why waiting"`
+	Reasoning  AgentBrain `json:"reasoning,omitempty" jsonschema:"Your reasoning for this step"`
 }
 
 // WaitResult is the output for the wait tool.
 type WaitResult struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	ErrorCode string `json:"error_code,omitempty"`
+}
+
+// Conditions for the wait_for tool.
+const (
+	WaitNetworkIdle = "network_idle"
+	WaitSelector    = "selector"
+	WaitText        = "text"
+	WaitURL         = "url"
+	WaitDOMQuiet    = "dom_quiet"
+)
+
+// WaitForArgs is the input for the wait_for tool.
+type WaitForArgs struct {
+	Condition   string     `json:"condition" jsonschema:"What to wait for: network_idle, selector, text, url or dom_quiet"`
+	Value       string     `json:"value,omitempty" jsonschema:"For selector: a CSS selector. For text: the text. For url: a URL substring, or a glob where * matches anything"`
+	Gone        bool       `json:"gone,omitempty" jsonschema:"For selector and text: wait for it to disappear instead of appear (e.g. a loading spinner)"`
+	QuietMs     int        `json:"quiet_ms,omitzero" jsonschema:"For network_idle and dom_quiet: how long the page must stay quiet (default 500)"`
+	MaxInflight int        `json:"max_inflight,omitzero" jsonschema:"For network_idle: requests allowed to stay open, e.g. for polling or live updates (default 0)"`
+	TimeoutMs   int        `json:"timeout_ms,omitzero" jsonschema:"Maximum time to wait in milliseconds (default 10000, max 30000)"`
+	Reasoning   AgentBrain `json:"reasoning,omitempty" jsonschema:"Your reasoning for this step"`
+}
+
+// WaitForResult is the output for the wait_for tool.
+type WaitForResult struct {
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	ErrorCode string `json:"error_code,omitempty"`
+	WaitedMs  int64  `json:"waited_ms"`
 }
 
 // NewTabArgs is the input for the new_tab tool.
 type NewTabArgs struct {
-	URL       string `json:"url,omitempty" jsonschema:"Optional URL to open in the new tab"`
-	Reasoning string `json:"reasoning,omitempty" jsonschema:"Why opening a new tab"`
+	URL       string     `json:"url,omitempty" jsonschema:"Optional URL to open in the new tab"`
+	Reasoning AgentBrain `json:"reasoning,omitempty" jsonschema:"Why opening a new tab"`
 }
 
 // NewTabResult is the output for the new_tab tool.
 type NewTabResult struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
-	TabID   string `json:"tab_id,omitempty"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	ErrorCode string `json:"error_code,omitempty"`
+	TabID     string `json:"tab_id,omitempty"`
 }
 
 // SwitchTabArgs is the input for the switch_tab tool.
 type SwitchTabArgs struct {
-	TabID     string `json:"tab_id" jsonschema:"The ID of the tab to switch to"`
-	Reasoning string `json:"reasoning,omitempty" jsonschema:"Why switching to this tab"`
+	TabID     string     `json:"tab_id" jsonschema:"The ID of the tab to switch to"`
+	Reasoning AgentBrain `json:"reasoning,omitempty" jsonschema:"Why switching to this tab"`
 }
 
 // SwitchTabResult is the output for the switch_tab tool.
 type SwitchTabResult struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	ErrorCode string `json:"error_code,omitempty"`
 }
 
 // CloseTabArgs is the input for the close_tab tool.
 type CloseTabArgs struct {
-	TabID     string `json:"tab_id" jsonschema:"The ID of the tab to close"`
-	Reasoning string `json:"reasoning,omitempty" jsonschema:"Why closing this tab"`
+	TabID     string     `json:"tab_id" jsonschema:"The ID of the tab to close"`
+	Reasoning AgentBrain `json:"reasoning,omitempty" jsonschema:"Why closing this tab"`
 }
 
 // CloseTabResult is the output for the close_tab tool.
 type CloseTabResult struct {
-	Success bool   `json:"success"`
-	Message string `json:"message"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	ErrorCode string `json:"error_code,omitempty"`
 }
 
-// ListTabsArgs is the input for the list_tabs tool (no args needed).
-type ListTabsArgs struct{}
+// ListTabsArgs is the input for the list_tabs tool.
+type ListTabsArgs struct {
+	Reasoning AgentBrain `json:"reasoning,omitempty" jsonschema:"Why listing tabs"`
+}
 
 // ADKTabInfo represents information about a browser tab.
 type ADKTabInfo struct {
@@ -299,22 +429,27 @@ type ADKTabInfo struct {
 
 // ListTabsResult is the output for the list_tabs tool.
 type ListTabsResult struct {
-	Success bool         `json:"success"`
-	Message string       `json:"message"`
-	Tabs    []ADKTabInfo `json:"tabs"`
+	Success   bool         `json:"success"`
+	Message   string       `json:"message"`
+	ErrorCode string       `json:"error_code,omitempty"`
+	Tabs      []ADKTabInfo `json:"tabs"`
 }
 
-// GetPageStateArgs is the input for the get_page_state tool (no args needed).
-type GetPageStateArgs struct{}
+// GetPageStateArgs is the input for the get_page_state tool.
+type GetPageStateArgs struct {
+	Reasoning AgentBrain `json:"reasoning,omitempty" jsonschema:"Why refreshing the page state"`
+}
 
 // GetPageStateResult is the output for the get_page_state tool.
 type GetPageStateResult struct {
-	Success  bool   `json:"success"`
-	Message  string `json:"message"`
-	URL      string `json:"url"`
-	Title    string `json:"title"`
-	Elements string `json:"elements"`
-	TabCount int    `json:"tab_count"`
+	Success   bool   `json:"success"`
+	Message   string `json:"message"`
+	ErrorCode string `json:"error_code,omitempty"`
+	URL       string `json:"url"`
+	Title     string `json:"title"`
+	Elements  string `json:"elements"`
+	Scroll    string `json:"scroll,omitempty"`
+	TabCount  int    `json:"tab_count"`
 }
 
 // DoneArgs is the input for the done tool.
@@ -322,6 +457,10 @@ type DoneArgs struct {
 	Success bool   `json:"success" jsonschema:"Whether the task was completed successfully"`
 	Summary string `json:"summary" jsonschema:"Summary of what was accomplished"`
 	Data    any    `json:"data,omitempty" jsonschema:"Any data to return from the task"`
+
+	UseExtractedTables bool `json:"use_extracted_tables,omitempty" jsonschema:"Return the tables from the last extract_table call as the data (all rows, not just the preview)"`
+
+	Reasoning AgentBrain `json:"reasoning,omitempty" jsonschema:"Your final evaluation and reasoning"`
 }
 
 // DoneResult is the output for the done tool.
@@ -338,14 +477,30 @@ func (t *BrowserToolkit) CreateNavigateTool() (tool.Tool, error) {
 	return functiontool.New(
 		functiontool.Config{
 			Name:        "navigate",
-			Description: "Navigate the browser to a specified URL",
+			Description: "Navigate the browser to a specified URL. Reports the final URL, redirects and HTTP status",
 		},
 		func(ctx tool.Context, args NavigateArgs) (NavigateResult, error) {
-			if err := t.browser.Navigate(nil, args.URL); err != nil {
-				return NavigateResult{Success: false, Message: fmt.Sprintf("Navigation failed: %v", err)}, nil
+			nav, err := t.browser.Navigate(ctx, args.URL)
+			if err != nil {
+				return NavigateResult{Success: false, Message: fmt.Sprintf("Navigation failed: %v", err), ErrorCode: ErrorCode(err)}, nil
+			}
+			t.RefreshElementMap(ctx)
+
+			result := NavigateResult{Success: true, URL: nav.URL, HTTPStatus: nav.HTTPStatus}
+			for _, hop := range nav.Redirects {
+				result.Redirects = append(result.Redirects, fmt.Sprintf("%s (%d)", hop.URL, hop.HTTPStatus))
+			}
+			result.Message = fmt.Sprintf("Navigated to %s", nav.URL)
+			if len(nav.Redirects) > 0 {
+				result.Message += fmt.Sprintf(" (redirected from %s)", args.URL)
 			}
-			t.RefreshElementMap()
-			return NavigateResult{Success: true, Message: fmt.Sprintf("Navigated to %s", args.URL), URL: args.URL}, nil
+			if !nav.OK() {
+				// The server's error page loaded; don't mistake it for the requested content
+				result.Success = false
+				result.ErrorCode = ErrCodeNavigationFailed
+				result.Message = fmt.Sprintf("Navigation to %s returned HTTP %d %s; the page shows the server's error page", nav.URL, nav.HTTPStatus, nav.StatusText)
+			}
+			return result, nil
 		},
 	)
 }
@@ -359,12 +514,12 @@ func (t *BrowserToolkit) CreateClickTool() (tool.Tool, error) {
 		},
 		func(ctx tool.Context, args ClickArgs) (ClickResult, error) {
 			if t.elementMap == nil {
-				return ClickResult{Success: false, Message: "No elements available. Call get_page_state first."}, nil
+				return ClickResult{Success: false, Message: "No elements available. Call get_page_state first.", ErrorCode: ErrCodeNoPageState}, nil
 			}
-			if err := t.browser.Click(nil, args.ElementIndex, t.elementMap); err != nil {
-				return ClickResult{Success: false, Message: fmt.Sprintf("Click failed: %v", err)}, nil
+			if err := t.browser.Click(ctx, args.ElementIndex, t.elementMap); err != nil {
+				return ClickResult{Success: false, Message: fmt.Sprintf("Click failed: %v", err), ErrorCode: ErrorCode(err)}, nil
 			}
-			t.RefreshElementMap()
+			t.RefreshElementMap(ctx)
 			return ClickResult{Success: true, Message: fmt.Sprintf("Clicked element [%d]", args.ElementIndex)}, nil
 		},
 	)
@@ -379,10 +534,10 @@ func (t *BrowserToolkit) CreateTypeTextTool() (tool.Tool, error) {
 		},
 		func(ctx tool.Context, args TypeTextArgs) (TypeTextResult, error) {
 			if t.elementMap == nil {
-				return TypeTextResult{Success: false, Message: "No elements available. Call get_page_state first."}, nil
+				return TypeTextResult{Success: false, Message: "No elements available. Call get_page_state first.", ErrorCode: ErrCodeNoPageState}, nil
 			}
-			if err := t.browser.TypeText(nil, args.ElementIndex, args.Text, t.elementMap); err != nil {
-				return TypeTextResult{Success: false, Message: fmt.Sprintf("Type failed: %v", err)}, nil
+			if err := t.browser.TypeText(ctx, args.ElementIndex, args.Text, t.elementMap); err != nil {
+				return TypeTextResult{Success: false, Message: fmt.Sprintf("Type failed: %v", err), ErrorCode: ErrorCode(err)}, nil
 			}
 			return TypeTextResult{Success: true, Message: fmt.Sprintf("Typed text into element [%d]", args.ElementIndex)}, nil
 		},
@@ -398,10 +553,10 @@ func (t *BrowserToolkit) CreateClearAndTypeTool() (tool.Tool, error) {
 		},
 		func(ctx tool.Context, args ClearAndTypeArgs) (ClearAndTypeResult, error) {
 			if t.elementMap == nil {
-				return ClearAndTypeResult{Success: false, Message: "No elements available. Call get_page_state first."}, nil
+				return ClearAndTypeResult{Success: false, Message: "No elements available. Call get_page_state first.", ErrorCode: ErrCodeNoPageState}, nil
 			}
-			if err := t.browser.ClearAndType(nil, args.ElementIndex, args.Text, t.elementMap); err != nil {
-				return ClearAndTypeResult{Success: false, Message: fmt.Sprintf("Clear and type failed: %v", err)}, nil
+			if err := t.browser.ClearAndType(ctx, args.ElementIndex, args.Text, t.elementMap); err != nil {
+				return ClearAndTypeResult{Success: false, Message: fmt.Sprintf("Clear and type failed: %v", err), ErrorCode: ErrorCode(err)}, nil
 			}
 			return ClearAndTypeResult{Success: true, Message: fmt.Sprintf("Cleared and typed into element [%d]", args.ElementIndex)}, nil
 		},
@@ -420,10 +575,10 @@ func (t *BrowserToolkit) CreateScrollTool() (tool.Tool, error) {
 			if amount == 0 {
 				amount = 300
 			}
-			if err := t.browser.Scroll(nil, args.Direction, amount, args.ElementIndex, t.elementMap); err != nil {
-				return ScrollResult{Success: false, Message: fmt.Sprintf("Scroll failed: %v", err)}, nil
+			if err := t.browser.Scroll(ctx, args.Direction, amount, args.ElementIndex, t.elementMap); err != nil {
+				return ScrollResult{Success: false, Message: fmt.Sprintf("Scroll failed: %v", err), ErrorCode: ErrorCode(err)}, nil
 			}
-			t.RefreshElementMap()
+			t.RefreshElementMap(ctx)
 			return ScrollResult{Success: true, Message: fmt.Sprintf("Scrolled %s by %.0f pixels", args.Direction, amount)}, nil
 		},
 	)
@@ -437,10 +592,10 @@ func (t *BrowserToolkit) CreateSendKeysTool() (tool.Tool, error) {
 			Description: "Send keyboard keys (Enter, Escape, Tab, ArrowUp, ArrowDown, etc.)",
 		},
 		func(ctx tool.Context, args SendKeysArgs) (SendKeysResult, error) {
-			if err := t.browser.SendKeys(nil, args.Keys); err != nil {
-				return SendKeysResult{Success: false, Message: fmt.Sprintf("Send keys failed: %v", err)}, nil
+			if err := t.browser.SendKeys(ctx, args.Keys); err != nil {
+				return SendKeysResult{Success: false, Message: fmt.Sprintf("Send keys failed: %v", err), ErrorCode: ErrorCode(err)}, nil
 			}
-			t.RefreshElementMap()
+			t.RefreshElementMap(ctx)
 			return SendKeysResult{Success: true, Message: fmt.Sprintf("Sent keys: %s", args.Keys)}, nil
 		},
 	)
@@ -454,10 +609,10 @@ func (t *BrowserToolkit) CreateGoBackTool() (tool.Tool, error) {
 			Description: "Navigate back in browser history",
 		},
 		func(ctx tool.Context, args GoBackArgs) (GoBackResult, error) {
-			if err := t.browser.GoBack(nil); err != nil {
-				return GoBackResult{Success: false, Message: fmt.Sprintf("Go back failed: %v", err)}, nil
+			if err := t.browser.GoBack(ctx); err != nil {
+				return GoBackResult{Success: false, Message: fmt.Sprintf("Go back failed: %v", err), ErrorCode: ErrorCode(err)}, nil
 			}
-			t.RefreshElementMap()
+			t.RefreshElementMap(ctx)
 			return GoBackResult{Success: true, Message: "Navigated back"}, nil
 		},
 	)
@@ -471,10 +626,10 @@ func (t *BrowserToolkit) CreateGoForwardTool() (tool.Tool, error) {
 			Description: "Navigate forward in browser history",
 		},
 		func(ctx tool.Context, args GoForwardArgs) (GoForwardResult, error) {
-			if err := t.browser.GoForward(nil); err != nil {
-				return GoForwardResult{Success: false, Message: fmt.Sprintf("Go forward failed: %v", err)}, nil
+			if err := t.browser.GoForward(ctx); err != nil {
+				return GoForwardResult{Success: false, Message: fmt.Sprintf("Go forward failed: %v", err), ErrorCode: ErrorCode(err)}, nil
 			}
-			t.RefreshElementMap()
+			t.RefreshElementMap(ctx)
 			return GoForwardResult{Success: true, Message: "Navigated forward"}, nil
 		},
 	)
@@ -489,12 +644,12 @@ func (t *BrowserToolkit) CreateHoverTool() (tool.Tool, error) {
 		},
 		func(ctx tool.Context, args HoverArgs) (HoverResult, error) {
 			if t.elementMap == nil {
-				return HoverResult{Success: false, Message: "No elements available. Call get_page_state first."}, nil
+				return HoverResult{Success: false, Message: "No elements available. Call get_page_state first.", ErrorCode: ErrCodeNoPageState}, nil
 			}
-			if err := t.browser.Hover(nil, args.ElementIndex, t.elementMap); err != nil {
-				return HoverResult{Success: false, Message: fmt.Sprintf("Hover failed: %v", err)}, nil
+			if err := t.browser.Hover(ctx, args.ElementIndex, t.elementMap); err != nil {
+				return HoverResult{Success: false, Message: fmt.Sprintf("Hover failed: %v", err), ErrorCode: ErrorCode(err)}, nil
 			}
-			t.RefreshElementMap()
+			t.RefreshElementMap(ctx)
 			return HoverResult{Success: true, Message: fmt.Sprintf("Hovered over element [%d]", args.ElementIndex)}, nil
 		},
 	)
@@ -509,12 +664,12 @@ func (t *BrowserToolkit) CreateDoubleClickTool() (tool.Tool, error) {
 		},
 		func(ctx tool.Context, args DoubleClickArgs) (DoubleClickResult, error) {
 			if t.elementMap == nil {
-				return DoubleClickResult{Success: false, Message: "No elements available. Call get_page_state first."}, nil
+				return DoubleClickResult{Success: false, Message: "No elements available. Call get_page_state first.", ErrorCode: ErrCodeNoPageState}, nil
 			}
-			if err := t.browser.DoubleClick(nil, args.ElementIndex, t.elementMap); err != nil {
-				return DoubleClickResult{Success: false, Message: fmt.Sprintf("Double-click failed: %v", err)}, nil
+			if err := t.browser.DoubleClick(ctx, args.ElementIndex, t.elementMap); err != nil {
+				return DoubleClickResult{Success: false, Message: fmt.Sprintf("Double-click failed: %v", err), ErrorCode: ErrorCode(err)}, nil
 			}
-			t.RefreshElementMap()
+			t.RefreshElementMap(ctx)
 			return DoubleClickResult{Success: true, Message: fmt.Sprintf("Double-clicked element [%d]", args.ElementIndex)}, nil
 		},
 	)
@@ -529,10 +684,10 @@ func (t *BrowserToolkit) CreateFocusTool() (tool.Tool, error) {
 		},
 		func(ctx tool.Context, args FocusArgs) (FocusResult, error) {
 			if t.elementMap == nil {
-				return FocusResult{Success: false, Message: "No elements available. Call get_page_state first."}, nil
+				return FocusResult{Success: false, Message: "No elements available. Call get_page_state first.", ErrorCode: ErrCodeNoPageState}, nil
 			}
-			if err := t.browser.Focus(nil, args.ElementIndex, t.elementMap); err != nil {
-				return FocusResult{Success: false, Message: fmt.Sprintf("Focus failed: %v", err)}, nil
+			if err := t.browser.Focus(ctx, args.ElementIndex, t.elementMap); err != nil {
+				return FocusResult{Success: false, Message: fmt.Sprintf("Focus failed: %v", err), ErrorCode: ErrorCode(err)}, nil
 			}
 			return FocusResult{Success: true, Message: fmt.Sprintf("Focused element [%d]", args.ElementIndex)}, nil
 		},
@@ -547,10 +702,10 @@ func (t *BrowserToolkit) CreateReloadTool() (tool.Tool, error) {
 			Description: "Reload the current page",
 		},
 		func(ctx tool.Context, args ReloadArgs) (ReloadResult, error) {
-			if err := t.browser.Reload(nil); err != nil {
-				return ReloadResult{Success: false, Message: fmt.Sprintf("Reload failed: %v", err)}, nil
+			if err := t.browser.Reload(ctx); err != nil {
+				return ReloadResult{Success: false, Message: fmt.Sprintf("Reload failed: %v", err), ErrorCode: ErrorCode(err)}, nil
 			}
-			t.RefreshElementMap()
+			t.RefreshElementMap(ctx)
 			return ReloadResult{Success: true, Message: "Page reloaded"}, nil
 		},
 	)
@@ -565,12 +720,12 @@ func (t *BrowserToolkit) CreateScrollToElementTool() (tool.Tool, error) {
 		},
 		func(ctx tool.Context, args ScrollToElementArgs) (ScrollToElementResult, error) {
 			if t.elementMap == nil {
-				return ScrollToElementResult{Success: false, Message: "No elements available. Call get_page_state first."}, nil
+				return ScrollToElementResult{Success: false, Message: "No elements available. Call get_page_state first.", ErrorCode: ErrCodeNoPageState}, nil
 			}
-			if err := t.browser.ScrollToElement(nil, args.ElementIndex, t.elementMap); err != nil {
-				return ScrollToElementResult{Success: false, Message: fmt.Sprintf("Scroll to element failed: %v", err)}, nil
+			if err := t.browser.ScrollToElement(ctx, args.ElementIndex, t.elementMap); err != nil {
+				return ScrollToElementResult{Success: false, Message: fmt.Sprintf("Scroll to element failed: %v", err), ErrorCode: ErrorCode(err)}, nil
 			}
-			t.RefreshElementMap()
+			t.RefreshElementMap(ctx)
 			return ScrollToElementResult{Success: true, Message: fmt.Sprintf("Scrolled to element [%d]", args.ElementIndex)}, nil
 		},
 	)
@@ -581,18 +736,114 @@ func (t *BrowserToolkit) CreateExtractContentTool() (tool.Tool, error) {
 	return functiontool.New(
 		functiontool.Config{
 			Name:        "extract_content",
-			Description: "Extract the main text content from the current page",
+			Description: "Extract page content as Markdown (tables, lists, links with URLs). Optionally scope to a CSS selector or the section containing an element, filter by a query, and page through long content",
 		},
 		func(ctx tool.Context, args ExtractContentArgs) (ExtractContentResult, error) {
-			content, err := t.browser.ExtractContent(nil)
+			scope := browser.ContentScope{Selector: args.Selector}
+			if args.ElementIndex != nil {
+				if t.elementMap == nil {
+					return ExtractContentResult{Success: false, Message: "No elements available. Call get_page_state first.", ErrorCode: ErrCodeNoPageState}, nil
+				}
+				el, ok := t.elementMap.Get(*args.ElementIndex)
+				if !ok {
+					return ExtractContentResult{Success: false, Message: fmt.Sprintf("Extract content failed: element not found: index %d", *args.ElementIndex), ErrorCode: ErrCodeElementNotFound}, nil
+				}
+				scope.Element = el
+			}
+
+			content, err := t.browser.ExtractMarkdown(ctx, scope)
 			if err != nil {
-				return ExtractContentResult{Success: false, Message: fmt.Sprintf("Extract content failed: %v", err)}, nil
+				return ExtractContentResult{Success: false, Message: fmt.Sprintf("Extract content failed: %v", err), ErrorCode: ErrorCode(err)}, nil
 			}
-			// Truncate if too long
-			if len(content) > 10000 {
-				content = content[:10000] + "... (truncated)"
+
+			message := "Content extracted"
+			if args.Query != "" {
+				if filtered := dom.FilterMarkdownSections(content, args.Query, extractContentPageSize); filtered != "" {
+					content = filtered
+					message = fmt.Sprintf("Sections relevant to %q extracted", args.Query)
+				} else {
+					message = fmt.Sprintf("No sections matched %q, returning all content", args.Query)
+				}
 			}
-			return ExtractContentResult{Success: true, Message: "Content extracted", Content: content}, nil
+
+			chunks := dom.ChunkMarkdown(content, extractContentPageSize)
+			if len(chunks) == 0 {
+				return ExtractContentResult{Success: true, Message: "Page has no text content"}, nil
+			}
+
+			page := args.Page
+			if page <= 0 {
+				page = 1
+			}
+			if page > len(chunks) {
+				return ExtractContentResult{Success: false, Message: fmt.Sprintf("Page %d out of range (content has %d pages)", page, len(chunks)), ErrorCode: ErrCodeInvalidArgument}, nil
+			}
+			if len(chunks) > 1 {
+				message += fmt.Sprintf(" (page %d of %d)", page, len(chunks))
+				if page < len(chunks) {
+					message += fmt.Sprintf(", call extract_content with page=%d for more", page+1)
+				}
+			}
+
+			return ExtractContentResult{
+				Success:    true,
+				Message:    message,
+				Content:    chunks[page-1],
+				Page:       page,
+				TotalPages: len(chunks),
+			}, nil
+		},
+	)
+}
+
+// CreateExtractTableTool creates the extract_table function tool.
+func (t *BrowserToolkit) CreateExtractTableTool() (tool.Tool, error) {
+	return functiontool.New(
+		functiontool.Config{
+			Name:        "extract_table",
+			Description: "Extract HTML tables and ARIA grids as structured headers and rows. Optionally scope to a CSS selector or the table containing an element. Use done with use_extracted_tables=true to return them as the task data",
+		},
+		func(ctx tool.Context, args ExtractTableArgs) (ExtractTableResult, error) {
+			scope := browser.ContentScope{Selector: args.Selector}
+			if args.ElementIndex != nil {
+				if t.elementMap == nil {
+					return ExtractTableResult{Success: false, Message: "No elements available. Call get_page_state first.", ErrorCode: ErrCodeNoPageState}, nil
+				}
+				el, ok := t.elementMap.Get(*args.ElementIndex)
+				if !ok {
+					return ExtractTableResult{Success: false, Message: fmt.Sprintf("Extract table failed: element not found: index %d", *args.ElementIndex), ErrorCode: ErrCodeElementNotFound}, nil
+				}
+				scope.Element = el
+			}
+
+			tables, err := t.browser.ExtractTables(ctx, scope)
+			if err != nil {
+				return ExtractTableResult{Success: false, Message: fmt.Sprintf("Extract table failed: %v", err), ErrorCode: ErrorCode(err)}, nil
+			}
+			if len(tables) == 0 {
+				return ExtractTableResult{Success: false, Message: "No tables found", ErrorCode: ErrCodeActionFailed}, nil
+			}
+			t.extractedTables = tables
+
+			preview := make([]dom.Table, len(tables))
+			truncated := false
+			for i, table := range tables {
+				preview[i] = table.Truncate(extractTablePreviewRows)
+				if len(preview[i].Rows) < table.TotalRows {
+					truncated = true
+				}
+			}
+
+			message := fmt.Sprintf("Extracted %d table(s)", len(tables))
+			if truncated {
+				message += fmt.Sprintf(", showing the first %d rows of each; done with use_extracted_tables=true returns all rows", extractTablePreviewRows)
+			}
+
+			return ExtractTableResult{
+				Success: true,
+				Message: message,
+				Tables:  preview,
+			}, nil
 		},
 	)
 }
@@ -605,9 +856,9 @@ func (t *BrowserToolkit) CreateScreenshotTool() (tool.Tool, error) {
 			Description: "Take a screenshot of the current page",
 		},
 		func(ctx tool.Context, args ScreenshotArgs) (ScreenshotResult, error) {
-			data, err := t.browser.Screenshot(nil, args.FullPage)
+			data, err := t.browser.Screenshot(ctx, args.FullPage)
 			if err != nil {
-				return ScreenshotResult{Success: false, Message: fmt.Sprintf("Screenshot failed: %v", err)}, nil
+				return ScreenshotResult{Success: false, Message: fmt.Sprintf("Screenshot failed: %v", err), ErrorCode: ErrorCode(err)}, nil
 			}
 			// Note: We just report success; actual image data would be handled by the agent
 			return ScreenshotResult{Success: true, Message: "Screenshot captured", Size: len(data)}, nil
@@ -623,9 +874,9 @@ func (t *BrowserToolkit) CreateEvaluateJSTool() (tool.Tool, error) {
 			Description: "Execute JavaScript code on the page and return the result",
 		},
 		func(ctx tool.Context, args EvaluateJSArgs) (EvaluateJSResult, error) {
-			result, err := t.browser.EvaluateJS(nil, args.Script)
+			result, err := t.browser.EvaluateJS(ctx, args.Script)
 			if err != nil {
-				return EvaluateJSResult{Success: false, Message: fmt.Sprintf("JS evaluation failed: %v", err)}, nil
+				return EvaluateJSResult{Success: false, Message: fmt.Sprintf("JS evaluation failed: %v", err), ErrorCode: ErrorCode(err)}, nil
 			}
 			return EvaluateJSResult{Success: true, Message: "JavaScript executed", Result: result}, nil
 		},
@@ -647,14 +898,111 @@ func (t *BrowserToolkit) CreateWaitTool() (tool.Tool, error) {
 			if durationMs > 10000 {
 				durationMs = 10000
 			}
-			// Use browser's wait stable
-			t.browser.WaitStable(nil)
-			t.RefreshElementMap()
+			select {
+			case <-ctx.Done():
+				return WaitResult{Success: false, Message: fmt.Sprintf("Wait interrupted: %v", ctx.Err()), ErrorCode: ErrorCode(ctx.Err())}, nil
+			case <-time.After(time.Duration(durationMs) * time.Millisecond):
+			}
+			t.browser.WaitStable(ctx)
+			t.RefreshElementMap(ctx)
 			return WaitResult{Success: true, Message: fmt.Sprintf("Waited for %d ms", durationMs)}, nil
 		},
 	)
 }
 
+// CreateWaitForTool creates the wait_for function tool.
+func (t *BrowserToolkit) CreateWaitForTool() (tool.Tool, error) {
+	return functiontool.New(
+		functiontool.Config{
+			Name:        "wait_for",
+			Description: "Wait until a condition holds: network idle, an element or text appearing or disappearing, the URL matching a pattern, or the DOM settling",
+		},
+		func(ctx tool.Context, args WaitForArgs) (WaitForResult, error) {
+			if err := args.validate(); err != nil {
+				return WaitForResult{Success: false, Message: err.Error(), ErrorCode: ErrCodeInvalidArgument}, nil
+			}
+
+			start := time.Now()
+			err := waitFor(ctx, t.browser, args)
+			waited := time.Since(start).Milliseconds()
+			t.RefreshElementMap(ctx)
+			if err != nil {
+				return WaitForResult{Success: false, Message: fmt.Sprintf("Wait for %s failed: %v", args.describe(), err), ErrorCode: ErrorCode(err), WaitedMs: waited}, nil
+			}
+			return WaitForResult{Success: true, Message: fmt.Sprintf("Waited %d ms for %s", waited, args.describe()), WaitedMs: waited}, nil
+		},
+	)
+}
+
+// validate checks that the condition is known and has the value it needs.
+func (args WaitForArgs) validate() error {
+	switch args.Condition {
+	case WaitNetworkIdle, WaitDOMQuiet:
+		return nil
+	case WaitSelector, WaitText, WaitURL:
+		if args.Value == "" {
+			return fmt.Errorf("the %s condition needs a value", args.Condition)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown condition %q; use network_idle, selector, text, url or dom_quiet", args.Condition)
+	}
+}
+
+// describe names what is being waited for.
+func (args WaitForArgs) describe() string {
+	switch args.Condition {
+	case WaitNetworkIdle:
+		return "network idle"
+	case WaitDOMQuiet:
+		return "DOM to settle"
+	case WaitURL:
+		return fmt.Sprintf("URL matching %q", args.Value)
+	}
+	what := fmt.Sprintf("%s %q", args.Condition, args.Value)
+	if args.Gone {
+		what += " to disappear"
+	}
+	return what
+}
+
+// timeout returns the wait limit: 10s by default, at most 30s.
+func (args WaitForArgs) timeout() time.Duration {
+	ms := args.TimeoutMs
+	if ms <= 0 {
+		ms = 10000
+	}
+	return time.Duration(min(ms, 30000)) * time.Millisecond
+}
+
+// quiet returns the quiet period for network_idle and dom_quiet.
+func (args WaitForArgs) quiet() time.Duration {
+	if args.QuietMs <= 0 {
+		return 500 * time.Millisecond
+	}
+	return time.Duration(args.QuietMs) * time.Millisecond
+}
+
+// waitFor blocks until the condition holds or its timeout passes.
+func waitFor(ctx context.Context, b *browser.Browser, args WaitForArgs) error {
+	ctx, cancel := context.WithTimeout(ctx, args.timeout())
+	defer cancel()
+
+	switch args.Condition {
+	case WaitNetworkIdle:
+		return b.WaitNetworkIdle(ctx, args.quiet(), args.MaxInflight)
+	case WaitSelector:
+		return b.WaitForSelector(ctx, args.Value, args.Gone)
+	case WaitText:
+		return b.WaitForText(ctx, args.Value, args.Gone)
+	case WaitURL:
+		return b.WaitForURL(ctx, args.Value)
+	case WaitDOMQuiet:
+		return b.WaitDOMQuiet(ctx, args.quiet())
+	}
+	return args.validate()
+}
+
 // CreateNewTabTool creates the new_tab function tool.
 func (t *BrowserToolkit) CreateNewTabTool() (tool.Tool, error) {
 	return functiontool.New(
@@ -663,11 +1011,11 @@ func (t *BrowserToolkit) CreateNewTabTool() (tool.Tool, error) {
 			Description: "Open a new browser tab, optionally navigating to a URL",
 		},
 		func(ctx tool.Context, args NewTabArgs) (NewTabResult, error) {
-			tabID, err := t.browser.NewTab(nil, args.URL)
+			tabID, err := t.browser.NewTab(ctx, args.URL)
 			if err != nil {
-				return NewTabResult{Success: false, Message: fmt.Sprintf("New tab failed: %v", err)}, nil
+				return NewTabResult{Success: false, Message: fmt.Sprintf("New tab failed: %v", err), ErrorCode: ErrorCode(err)}, nil
 			}
-			t.RefreshElementMap()
+			t.RefreshElementMap(ctx)
 			return NewTabResult{Success: true, Message: fmt.Sprintf("Opened new tab: %s", tabID), TabID: tabID}, nil
 		},
 	)
@@ -682,9 +1030,9 @@ func (t *BrowserToolkit) CreateSwitchTabTool() (tool.Tool, error) {
 		},
 		func(ctx tool.Context, args SwitchTabArgs) (SwitchTabResult, error) {
 			if err := t.browser.SwitchTab(args.TabID); err != nil {
-				return SwitchTabResult{Success: false, Message: fmt.Sprintf("Switch tab failed: %v", err)}, nil
+				return SwitchTabResult{Success: false, Message: fmt.Sprintf("Switch tab failed: %v", err), ErrorCode: ErrorCode(err)}, nil
 			}
-			t.RefreshElementMap()
+			t.RefreshElementMap(ctx)
 			return SwitchTabResult{Success: true, Message: fmt.Sprintf("Switched to tab: %s", args.TabID)}, nil
 		},
 	)
@@ -699,9 +1047,9 @@ func (t *BrowserToolkit) CreateCloseTabTool() (tool.Tool, error) {
 		},
 		func(ctx tool.Context, args CloseTabArgs) (CloseTabResult, error) {
 			if err := t.browser.CloseTab(args.TabID); err != nil {
-				return CloseTabResult{Success: false, Message: fmt.Sprintf("Close tab failed: %v", err)}, nil
+				return CloseTabResult{Success: false, Message: fmt.Sprintf("Close tab failed: %v", err), ErrorCode: ErrorCode(err)}, nil
 			}
-			t.RefreshElementMap()
+			t.RefreshElementMap(ctx)
 			return CloseTabResult{Success: true, Message: fmt.Sprintf("Closed tab: %s", args.TabID)}, nil
 		},
 	)
@@ -735,11 +1083,11 @@ func (t *BrowserToolkit) CreateGetPageStateTool() (tool.Tool, error) {
 	return functiontool.New(
 		functiontool.Config{
 			Name:        "get_page_state",
-			Description: "Get the current page state including URL, title, and interactive elements",
+			Description: "Get the current page state including URL, title, scroll position, and interactive elements",
 		},
 		func(ctx tool.Context, args GetPageStateArgs) (GetPageStateResult, error) {
-			if err := t.RefreshElementMap(); err != nil {
-				return GetPageStateResult{Success: false, Message: fmt.Sprintf("Failed to get page state: %v", err)}, nil
+			if err := t.RefreshElementMap(ctx); err != nil {
+				return GetPageStateResult{Success: false, Message: fmt.Sprintf("Failed to get page state: %v", err), ErrorCode: ErrorCode(err)}, nil
 			}
 
 			elementsText := t.elementMap.ToTokenStringLimited(100)
@@ -750,6 +1098,7 @@ func (t *BrowserToolkit) CreateGetPageStateTool() (tool.Tool, error) {
 				URL:      t.elementMap.PageURL,
 				Title:    t.elementMap.PageTitle,
 				Elements: elementsText,
+				Scroll:   t.elementMap.ToScrollContextString(),
 				TabCount: len(t.browser.ListTabs()),
 			}, nil
 		},
@@ -775,7 +1124,7 @@ func (t *BrowserToolkit) CreateDoneTool() (tool.Tool, error) {
 
 // CreateAllTools creates all browser automation tools.
 func (t *BrowserToolkit) CreateAllTools() ([]tool.Tool, error) {
-	tools := make([]tool.Tool, 0, 23)
+	tools := make([]tool.Tool, 0, 25)
 
 	navigateTool, err := t.CreateNavigateTool()
 	if err != nil {
@@ -861,6 +1210,12 @@ func (t *BrowserToolkit) CreateAllTools() ([]tool.Tool, error) {
 	}
 	tools = append(tools, extractContentTool)
 
+	extractTableTool, err := t.CreateExtractTableTool()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create extract_table tool: %w", err)
+	}
+	tools = append(tools, extractTableTool)
+
 	screenshotTool, err := t.CreateScreenshotTool()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create screenshot tool: %w", err)
@@ -879,6 +1234,12 @@ func (t *BrowserToolkit) CreateAllTools() ([]tool.Tool, error) {
 	}
 	tools = append(tools, waitTool)
 
+	waitForTool, err := t.CreateWaitForTool()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create wait_for tool: %w", err)
+	}
+	tools = append(tools, waitForTool)
+
 	newTabTool, err := t.CreateNewTabTool()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create new_tab tool: %w", err)
@@ -3,14 +3,18 @@ package agent
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"sync/atomic"
 	"time"
 
 	"github.com/anxuanzi/bua/browser"
+	"github.com/anxuanzi/bua/dom"
 	"google.golang.org/adk/agent"
 	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/model"
 	"google.golang.org/adk/model/gemini"
 	"google.golang.org/adk/runner"
 	"google.golang.org/adk/session"
@@ -34,22 +38,88 @@ type BrowserAgent struct {
 	useVision       bool
 	maxWidth        int
 	showAnnotations bool // Enable element annotations on screenshots
+	checkpointStore CheckpointStore
+	actionCache     ActionCache
+	planner         *Planner
+	onStep          func(Step)
+	batchGuard      *batchGuard // Set when multi-action turns are enabled
+	toolGuard       *toolGuard
+	toolTimer       *toolTimer
+	retries         *atomic.Int64 // Model call retries across runs
+	relaunchBrowser bool
+	maxDuration     time.Duration // Time budget per run (0 = none)
+	stepTimeout     time.Duration // Time limit per turn (0 = none)
+}
+
+// maxRelaunches is how many times a run may relaunch a crashed browser.
+const maxRelaunches = 3
+
+// runState tracks a run in progress so it can be checkpointed and resumed.
+type runState struct {
+	runID       string
+	task        string
+	userID      string
+	sessionID   string
+	startTime   time.Time
+	turnNum     int
+	toolCallNum int
+	firstStep   int // toolCallNum when this task started
+	stepLimit   int // toolCallNum at which the run stops
+	cacheHits   int
+	cacheMisses int
+	cacheUsed   map[string]bool // Keys already replayed this run
+	plan        *Plan
+	planStep    int   // toolCallNum when the plan was last reviewed
+	planChanged bool  // plan changed since it was last attached to a step
+	retryBase   int64 // retries counter value that corresponds to zero retries this run
+	relaunches  int   // browser relaunches after crashes this run
+	wrapUp      bool  // model was told the time budget is nearly used up
+	timeouts    int   // consecutive turns cut off by the step timeout
+}
+
+// Conversation carries the ADK session and history across consecutive
+// tasks so follow-up tasks can refer to earlier ones.
+// A conversation must not be used by concurrent runs.
+type Conversation struct {
+	sessionID string
+	history   []HistoryItem
+}
+
+// NewConversation creates an empty conversation.
+func NewConversation() *Conversation {
+	return &Conversation{}
+}
+
+// SessionID returns the ADK session ID, or "" before the first task.
+func (c *Conversation) SessionID() string {
+	return c.sessionID
 }
 
 // Step represents a single step in the agent's execution.
 type Step struct {
-	Number         int       `json:"number"`
-	Action         string    `json:"action"`
-	Target         string    `json:"target,omitempty"`
-	Thinking       string    `json:"thinking,omitempty"`
-	Evaluation     string    `json:"evaluation,omitempty"`
-	Memory         string    `json:"memory,omitempty"`
-	NextGoal       string    `json:"next_goal,omitempty"`
-	Result         string    `json:"result,omitempty"`
-	Success        bool      `json:"success"`
-	Timestamp      time.Time `json:"timestamp"`
-	DurationMs     int64     `json:"duration_ms"`
-	ScreenshotPath string    `json:"screenshot_path,omitempty"`
+	Number         int          `json:"number"`
+	Action         string       `json:"action"`
+	Target         string       `json:"target,omitempty"`
+	URL            string       `json:"url,omitempty"`
+	Locator        *dom.Locator `json:"locator,omitempty"`
+	Thinking       string       `json:"thinking,omitempty"`
+	Evaluation     string       `json:"evaluation,omitempty"`
+	Memory         string       `json:"memory,omitempty"`
+	NextGoal       string       `json:"next_goal,omitempty"`
+	Result         string       `json:"result,omitempty"`
+	Success        bool         `json:"success"`
+	Timestamp      time.Time    `json:"timestamp"`
+	DurationMs     int64        `json:"duration_ms"`             // ModelMs + ToolMs + ScreenshotMs
+	ModelMs        int64        `json:"model_ms,omitempty"`      // Model latency, on the first step of a turn
+	ToolMs         int64        `json:"tool_ms,omitempty"`       // Tool execution
+	ScreenshotMs   int64        `json:"screenshot_ms,omitempty"` // Screenshot capture around the step
+	Error          string       `json:"error,omitempty"`
+	ErrorCode      string       `json:"error_code,omitempty"` // ErrCode* classification of Error
+	ScreenshotPath string       `json:"screenshot_path,omitempty"`
+	Title          string       `json:"title,omitempty"`
+	Cached         bool         `json:"cached,omitempty"`    // Replayed from the action cache
+	PlanItem       string       `json:"plan_item,omitempty"` // Checklist item being worked on
+	Plan           *Plan        `json:"plan,omitempty"`      // Set when the plan changed before this step
 }
 
 // AgentConfig configures the browser agent.
@@ -59,16 +129,65 @@ type AgentConfig struct {
 	MaxSteps        int
 	MaxHistoryItems int
 	MaxElements     int
+	MaxTextChars    int // Page text budget in page state (0 = default, negative = disabled)
 	MaxFailures     int
 	TextOnly        bool
 	MaxWidth        int
 	Debug           bool
 	ScreenshotDir   string // Directory to save screenshots (empty = no saving)
 	ShowAnnotations bool   // Enable element annotations on screenshots
+
+	// CheckpointStore persists the run after every step (nil = no persistence)
+	CheckpointStore CheckpointStore
+
+	// ActionCache replays previously chosen actions on matching pages
+	// without a model call (nil = no caching)
+	ActionCache ActionCache
+
+	// Planner enables a planner sub-agent that keeps a checklist for the
+	// task and revises it on failure; this agent executes it
+	Planner      bool
+	PlannerModel string // Model for the planner (empty = same as Model)
+
+	// OnStep is called after each step completes
+	OnStep func(Step)
+
+	// MultiAction lets the model issue several actions in one turn. They run
+	// in order, and the rest of the batch is skipped if the page changes
+	MultiAction bool
+
+	// Retry controls retries of failed model calls
+	Retry RetryPolicy
+
+	// FallbackModel is used for the remaining attempts of a model call once
+	// the primary model has failed Retry.FallbackAfter times (empty = none)
+	FallbackModel string
+
+	// RelaunchBrowser relaunches the browser if it crashes or disconnects,
+	// restores its tabs and continues the run from the last step
+	RelaunchBrowser bool
+
+	// MaxDuration is the time budget of a run (0 = none). Near the end the
+	// model is told to wrap up; at the end the run returns a partial result
+	MaxDuration time.Duration
+
+	// StepTimeout bounds each turn: the model call and the actions it
+	// issues (0 = none). A timed-out turn counts as a failed step
+	StepTimeout time.Duration
+
+	// ToolPermissions restricts the tools offered to the model and
+	// validates their arguments (zero value = all tools, no validation)
+	ToolPermissions ToolPermissions
+
+	// CustomTools are user-defined tools offered to the model alongside
+	// the browser tools
+	CustomTools []CustomTool
 }
 
 // Result represents the outcome of an agent run.
 type Result struct {
+	RunID           string        `json:"run_id"`
+	Task            string        `json:"task"`
 	Success         bool          `json:"success"`
 	Data            any           `json:"data,omitempty"`
 	Error           string        `json:"error,omitempty"`
@@ -76,6 +195,11 @@ type Result struct {
 	Duration        time.Duration `json:"duration"`
 	TokensUsed      int           `json:"tokens_used,omitempty"`
 	ScreenshotPaths []string      `json:"screenshot_paths,omitempty"`
+	CacheHits       int           `json:"cache_hits,omitempty"`
+	CacheMisses     int           `json:"cache_misses,omitempty"`
+	Plan            *Plan         `json:"plan,omitempty"`
+	Retries         int           `json:"retries,omitempty"` // Model calls retried after transient errors, planner calls included
+	Partial         bool          `json:"partial,omitempty"` // Stopped by the time budget; Data holds what was gathered
 }
 
 // NewBrowserAgent creates a new browser agent using ADK.
@@ -129,34 +253,117 @@ func NewBrowserAgent(ctx context.Context, cfg AgentConfig, b *browser.Browser) (
 	}
 
 	// Create Gemini model using ADK
-	model, err := gemini.NewModel(ctx, modelName, &genai.ClientConfig{
-		APIKey: apiKey,
+	baseModel, err := gemini.NewModel(ctx, modelName, &genai.ClientConfig{
+		APIKey:     apiKey,
+		HTTPClient: newModelHTTPClient(),
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create Gemini model: %w", err)
 	}
 
+	// Create the fallback model used once the primary keeps failing
+	var fallbackModel model.LLM
+	if cfg.FallbackModel != "" && cfg.FallbackModel != modelName {
+		fallbackModel, err = gemini.NewModel(ctx, cfg.FallbackModel, &genai.ClientConfig{
+			APIKey:     apiKey,
+			HTTPClient: newModelHTTPClient(),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create fallback model: %w", err)
+		}
+	}
+
+	// Retry transient model errors instead of aborting the run. The count is
+	// shared with the planner's model, so a run's retries include its calls
+	retries := new(atomic.Int64)
+	llm := newRetryModel(baseModel, fallbackModel, cfg.Retry, retries, cfg.Debug)
+
+	// Create the planner sub-agent, optionally on a different model
+	var planner *Planner
+	if cfg.Planner {
+		plannerModel := llm
+		if cfg.PlannerModel != "" && cfg.PlannerModel != modelName {
+			basePlannerModel, err := gemini.NewModel(ctx, cfg.PlannerModel, &genai.ClientConfig{
+				APIKey:     apiKey,
+				HTTPClient: newModelHTTPClient(),
+			})
+			if err != nil {
+				return nil, fmt.Errorf("failed to create planner model: %w", err)
+			}
+			plannerModel = newRetryModel(basePlannerModel, fallbackModel, cfg.Retry, retries, cfg.Debug)
+		}
+		planner, err = NewPlanner(plannerModel, cfg.Debug)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Create browser toolkit with tools
 	toolkit := NewBrowserToolkit(b, maxWidth)
 	tools, err := toolkit.CreateAllTools()
 	if err != nil {
 		return nil, fmt.Errorf("failed to create browser tools: %w", err)
 	}
+	customTools, customCategories, err := toolkit.createCustomTools(cfg.CustomTools, tools)
+	if err != nil {
+		return nil, err
+	}
+
+	// Offer the model only the permitted tools
+	permissions, err := newToolGuard(cfg.ToolPermissions, tools, customTools)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tool permissions: %w", err)
+	}
+	tools = permissions.filter(append(tools, customTools...))
 
 	// Create message manager
 	messageManager := NewMessageManager(MessageManagerConfig{
 		MaxHistoryItems: maxHistoryItems,
 		MaxElements:     maxElements,
+		MaxTextChars:    cfg.MaxTextChars,
 		UseVision:       !cfg.TextOnly,
+		MultiAction:     cfg.MultiAction,
+		Tools:           permissions.names(tools),
+		CustomTools:     customCategories,
+		Secrets:         b.SecretNames(),
+		ScrubSecrets:    b.ScrubSecrets,
 	})
 
+	// Time every tool call; the timer must run before any callback that
+	// may skip the tool
+	timer := newToolTimer()
+	beforeTool := []llmagent.BeforeToolCallback{timer.before}
+	afterTool := []llmagent.AfterToolCallback{timer.after}
+
+	// Validate tool arguments before the batch guard counts the call
+	beforeTool = append(beforeTool, permissions.beforeTool)
+
+	// Report page loads the URL policy blocked during an action. It must
+	// run on every call, so it goes before the scrubber, which stops the
+	// chain when it replaces a tool error
+	afterTool = append(afterTool, newBlockedReporter(b).after)
+
+	// Hide secret values from the model, last so it sees the final result
+	if len(b.SecretNames()) > 0 {
+		afterTool = append(afterTool, newSecretScrubber(b).after)
+	}
+
+	// Guard multi-action turns against acting on a page that has changed
+	var guard *batchGuard
+	if cfg.MultiAction {
+		guard = newBatchGuard(b)
+		beforeTool = append(beforeTool, guard.beforeTool)
+	}
+
 	// Create LLM agent using ADK
 	llmAgent, err := llmagent.New(llmagent.Config{
-		Name:        "browser_agent",
-		Model:       model,
-		Description: "An expert web browser automation agent that helps users accomplish tasks by interacting with web pages.",
-		Instruction: messageManager.GetSystemPrompt(),
-		Tools:       tools,
+		Name:                "browser_agent",
+		Model:               llm,
+		Description:         "An expert web browser automation agent that helps users accomplish tasks by interacting with web pages.",
+		Instruction:         messageManager.GetSystemPrompt(),
+		Tools:               tools,
+		BeforeToolCallbacks: beforeTool,
+		AfterToolCallbacks:  afterTool,
 	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to create LLM agent: %w", err)
@@ -199,41 +406,88 @@ func NewBrowserAgent(ctx context.Context, cfg AgentConfig, b *browser.Browser) (
 		useVision:       !cfg.TextOnly,
 		maxWidth:        maxWidth,
 		showAnnotations: cfg.ShowAnnotations,
+		checkpointStore: cfg.CheckpointStore,
+		actionCache:     cfg.ActionCache,
+		planner:         planner,
+		onStep:          cfg.OnStep,
+		retries:         retries,
+		relaunchBrowser: cfg.RelaunchBrowser,
+		maxDuration:     cfg.MaxDuration,
+		stepTimeout:     cfg.StepTimeout,
+		batchGuard:      guard,
+		toolGuard:       permissions,
+		toolTimer:       timer,
 	}, nil
 }
 
 // Run executes a task and returns the result.
+// Each call starts a fresh conversation; use RunConversation to keep
+// context across tasks.
 func (a *BrowserAgent) Run(ctx context.Context, task string) (*Result, error) {
+	return a.RunConversation(ctx, NewConversation(), task)
+}
+
+// RunConversation executes a task as the next turn of a conversation.
+// The ADK session and history from earlier tasks in the conversation are
+// kept, so the model can refer back to them. The step budget applies per task.
+func (a *BrowserAgent) RunConversation(ctx context.Context, conv *Conversation, task string) (*Result, error) {
 	startTime := time.Now()
 	a.steps = make([]Step, 0)
 	a.screenshotPaths = make([]string, 0)
+	a.toolkit.SetExtractedTables(nil)
 	a.messageManager.Clear()
 	a.messageManager.SetTask(task)
+	for _, item := range conv.history {
+		a.messageManager.AddHistoryItem(item)
+	}
+	followUp := len(conv.history) > 0
 
 	// Get initial page state
-	if err := a.toolkit.RefreshElementMap(); err != nil {
+	if err := a.toolkit.RefreshElementMap(ctx); err != nil {
 		// Continue even if initial state fails - page might be blank
 		if a.debug {
 			fmt.Printf("[Debug] Initial page state: %v\n", err)
 		}
 	}
 
-	// Generate a unique session ID for this task
-	sessionID := fmt.Sprintf("session-%d", time.Now().UnixNano())
-	userID := "user"
+	// Generate a unique run ID; the session ID is kept across the conversation
+	now := time.Now()
+	state := &runState{
+		runID:       fmt.Sprintf("run-%d", now.UnixNano()),
+		task:        task,
+		userID:      "user",
+		sessionID:   conv.sessionID,
+		startTime:   startTime,
+		toolCallNum: len(conv.history),
+		firstStep:   len(conv.history),
+		stepLimit:   len(conv.history) + a.maxSteps,
+		retryBase:   a.retries.Load(),
+	}
 
-	// Create session before running
-	_, err := a.sessionService.Create(ctx, &session.CreateRequest{
-		AppName:   "bua-browser-agent",
-		UserID:    userID,
-		SessionID: sessionID,
-	})
-	if err != nil {
-		return nil, fmt.Errorf("failed to create session: %w", err)
+	// Create session before the first task of the conversation
+	if state.sessionID == "" {
+		state.sessionID = fmt.Sprintf("session-%d", now.UnixNano())
+		_, err := a.sessionService.Create(ctx, &session.CreateRequest{
+			AppName:   "bua-browser-agent",
+			UserID:    state.userID,
+			SessionID: state.sessionID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create session: %w", err)
+		}
+		conv.sessionID = state.sessionID
 	}
 
+	// Let the planner break the task down before the first action
+	a.startPlan(ctx, state)
+
 	// Build the initial task message with page state
-	taskMessage := a.messageManager.BuildInitialTaskMessage(task, a.toolkit.GetElementMap())
+	var taskMessage string
+	if followUp {
+		taskMessage = a.messageManager.BuildFollowUpTaskMessage(task, a.toolkit.GetElementMap())
+	} else {
+		taskMessage = a.messageManager.BuildInitialTaskMessage(task, a.toolkit.GetElementMap())
+	}
 
 	// Filter sensitive data
 	taskMessage = a.messageManager.FilterSensitiveData(taskMessage)
@@ -241,7 +495,7 @@ func (a *BrowserAgent) Run(ctx context.Context, task string) (*Result, error) {
 	// Create user message content (with optional screenshot)
 	var userContent *genai.Content
 	if a.useVision {
-		screenshotData, _, err := a.captureAndSaveScreenshot(ctx, 0)
+		screenshotData, _, err := a.captureAndSaveScreenshot(ctx, state.toolCallNum)
 		if err == nil && len(screenshotData) > 0 {
 			userContent = a.createMultimodalContent(taskMessage, screenshotData)
 		} else {
@@ -251,9 +505,50 @@ func (a *BrowserAgent) Run(ctx context.Context, task string) (*Result, error) {
 		userContent = genai.NewContentFromText(taskMessage, "user")
 	}
 
+	result, err := a.runLoop(ctx, state, userContent)
+
+	// Keep the history for the next task, even if this one failed
+	conv.history = append([]HistoryItem(nil), a.messageManager.GetHistory().GetItems()...)
+
+	return result, err
+}
+
+// ResetConversation discards a conversation's ADK session and history so
+// its next task starts fresh.
+func (a *BrowserAgent) ResetConversation(ctx context.Context, conv *Conversation) error {
+	if conv.sessionID != "" {
+		err := a.sessionService.Delete(ctx, &session.DeleteRequest{
+			AppName:   "bua-browser-agent",
+			UserID:    "user",
+			SessionID: conv.sessionID,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to delete session: %w", err)
+		}
+	}
+	conv.sessionID = ""
+	conv.history = nil
+	a.toolkit.SetExtractedTables(nil)
+	return nil
+}
+
+// runLoop drives the agent turn by turn until the task completes, the step
+// or time budget runs out or too many actions fail in a row. A checkpoint is
+// saved after every turn when a checkpoint store is configured.
+func (a *BrowserAgent) runLoop(ctx context.Context, state *runState, userContent *genai.Content) (*Result, error) {
+	// Bound the run by its time budget; the result is still recorded with
+	// the caller's context once the budget is used up
+	finishCtx := ctx
+	if deadline := a.deadline(state); !deadline.IsZero() {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+	timeUp := func() bool {
+		return ctx.Err() != nil && finishCtx.Err() == nil
+	}
+
 	// Run the agent using ADK runner
-	turnNum := 0
-	toolCallNum := 0
 	taskComplete := false
 	var lastResult *Result
 	var lastActionName string
@@ -261,42 +556,84 @@ func (a *BrowserAgent) Run(ctx context.Context, task string) (*Result, error) {
 	var lastActionSuccess bool
 	var lastScreenshotData []byte // Reuse screenshot for continuation message
 
-	for toolCallNum < a.maxSteps && !taskComplete {
-		turnNum++
+	for state.toolCallNum < state.stepLimit && !taskComplete {
+		if timeUp() {
+			return a.finishRun(finishCtx, state, a.timeUpResult(state)), nil
+		}
+		state.turnNum++
 
 		if a.debug {
-			fmt.Printf("[Turn %d] Starting...\n", turnNum)
+			fmt.Printf("[Turn %d] Starting...\n", state.turnNum)
+		}
+
+		// Relaunch a crashed browser before asking the model to act on it
+		if err := a.browser.Err(); err != nil {
+			content, err := a.recoverBrowser(ctx, state, err)
+			if err != nil {
+				return nil, err
+			}
+			userContent = content
 		}
 
-		// Check for too many consecutive failures
-		if a.messageManager.GetHistory().GetConsecutiveFailures() >= a.maxFailures {
+		// Check for too many consecutive failures (ignoring earlier tasks in a conversation)
+		failures := min(a.messageManager.GetHistory().GetConsecutiveFailures(), state.toolCallNum-state.firstStep)
+		if failures >= a.maxFailures || state.timeouts >= a.maxFailures {
 			if a.debug {
-				fmt.Printf("[Turn %d] Too many consecutive failures (%d), forcing completion\n", turnNum, a.maxFailures)
+				fmt.Printf("[Turn %d] Too many consecutive failures (%d), forcing completion\n", state.turnNum, a.maxFailures)
 			}
-			return &Result{
+			return a.finishRun(finishCtx, state, &Result{
 				Success:         false,
 				Error:           fmt.Sprintf("Task aborted after %d consecutive failures", a.maxFailures),
 				Steps:           a.steps,
-				Duration:        time.Since(startTime),
+				Duration:        time.Since(state.startTime),
 				ScreenshotPaths: a.screenshotPaths,
-			}, nil
+			}), nil
+		}
+
+		// Replay a cached action for this page instead of asking the model
+		var cacheKey string
+		if a.actionCache != nil {
+			cacheKey = a.actionCacheKey(state)
+			if outcome, ok := a.runCachedAction(ctx, state, cacheKey); ok {
+				lastActionName = outcome.name
+				lastActionResult = outcome.result
+				lastActionSuccess = outcome.success
+				lastScreenshotData = outcome.screenshot
+				userContent = a.prepareNextTurn(ctx, state, lastActionName, lastActionResult, lastActionSuccess, lastScreenshotData)
+				lastScreenshotData = nil
+				continue
+			}
+		}
+		var turnCalls int
+		var pendingSteps []int // Steps awaiting a tool response, in call order
+		if a.batchGuard != nil {
+			a.batchGuard.begin(a.browser.GetURL(), a.toolkit.GetElementMap())
 		}
 
 		// Capture screenshot at START of each turn (before action execution)
 		// This follows browser-use pattern: model sees current state before deciding
 		// The screenshot path is saved with the Step to record what the model saw
 		var turnScreenshotPath string
+		var turnScreenshotMs int64
 		if a.useVision {
-			_, path, err := a.captureAndSaveScreenshot(ctx, turnNum)
+			shotStart := time.Now()
+			_, path, err := a.captureAndSaveScreenshot(ctx, state.turnNum)
 			if err == nil {
 				turnScreenshotPath = path
 			}
+			turnScreenshotMs = time.Since(shotStart).Milliseconds()
 		}
 
 		// Run the agent for one turn using iter.Seq2 pattern
-		for event, err := range a.runner.Run(ctx, userID, sessionID, userContent, agent.RunConfig{}) {
+		modelStart := time.Now()
+		turnCtx, cancelTurn := a.turnContext(ctx)
+		for event, err := range a.runner.Run(turnCtx, state.userID, state.sessionID, userContent, agent.RunConfig{}) {
 			if err != nil {
-				return nil, fmt.Errorf("agent error at turn %d: %w", turnNum, err)
+				if turnCtx.Err() != nil && finishCtx.Err() == nil {
+					break // Out of time; handled below
+				}
+				cancelTurn()
+				return nil, fmt.Errorf("agent error at turn %d: %w", state.turnNum, err)
 			}
 
 			if event == nil {
@@ -308,38 +645,57 @@ func (a *BrowserAgent) Run(ctx context.Context, task string) (*Result, error) {
 				for _, part := range event.Content.Parts {
 					// Check for function calls
 					if part.FunctionCall != nil {
-						toolCallNum++
+						state.toolCallNum++
+						turnCalls++
 						toolName := part.FunctionCall.Name
 						toolArgs, _ := json.Marshal(part.FunctionCall.Args)
 						callStart := time.Now()
 
 						if a.debug {
-							fmt.Printf("[Step %d] Tool call: %s\n", toolCallNum, toolName)
+							fmt.Printf("[Step %d] Tool call: %s\n", state.toolCallNum, toolName)
 						}
 
 						lastActionName = toolName
 						lastActionSuccess = true // Will be updated by response
+						brain := parseBrain(toolArgs)
+						pageURL, pageTitle := a.pageInfo()
 
 						// Record the step with the screenshot taken at start of this turn
 						step := Step{
-							Number:         toolCallNum,
+							Number:         state.toolCallNum,
 							Action:         toolName,
-							Target:         string(toolArgs),
+							Target:         a.browser.ScrubSecrets(string(toolArgs)),
+							URL:            pageURL,
+							Title:          pageTitle,
+							Thinking:       brain.Thinking,
+							Evaluation:     brain.Evaluation,
+							Memory:         brain.Memory,
+							NextGoal:       brain.NextGoal,
+							Locator:        a.locateTarget(toolArgs),
 							Timestamp:      callStart,
-							DurationMs:     0, // Will be updated
 							Success:        true,
 							ScreenshotPath: turnScreenshotPath,
 						}
+						if turnCalls == 1 {
+							// The model call and turn screenshot belong to the first step of the turn
+							step.ModelMs = callStart.Sub(modelStart).Milliseconds()
+							step.ScreenshotMs = turnScreenshotMs
+						}
+						a.annotateStep(state, &step)
 						a.steps = append(a.steps, step)
+						pendingSteps = append(pendingSteps, len(a.steps)-1)
 
 						// Add to history
 						historyItem := HistoryItem{
-							StepNumber:    toolCallNum,
+							StepNumber:    state.toolCallNum,
 							Timestamp:     callStart,
+							Thinking:      brain.Thinking,
+							Evaluation:    brain.Evaluation,
+							Memory:        brain.Memory,
+							NextGoal:      brain.NextGoal,
 							ActionName:    toolName,
 							ActionParams:  string(toolArgs),
 							ActionSuccess: true,
-							DurationMs:    0,
 						}
 						a.messageManager.AddHistoryItem(historyItem)
 
@@ -348,11 +704,17 @@ func (a *BrowserAgent) Run(ctx context.Context, task string) (*Result, error) {
 							taskComplete = true
 							var doneArgs DoneArgs
 							if err := json.Unmarshal(toolArgs, &doneArgs); err == nil {
+								if doneArgs.UseExtractedTables && doneArgs.Data == nil {
+									if tables := a.toolkit.ExtractedTables(); len(tables) > 0 {
+										doneArgs.Data = tables
+									}
+								}
 								lastResult = &Result{
 									Success:         doneArgs.Success,
+									Partial:         state.wrapUp && !doneArgs.Success,
 									Data:            doneArgs.Data,
 									Steps:           a.steps,
-									Duration:        time.Since(startTime),
+									Duration:        time.Since(state.startTime),
 									ScreenshotPaths: a.screenshotPaths,
 								}
 								if !doneArgs.Success {
@@ -365,8 +727,17 @@ func (a *BrowserAgent) Run(ctx context.Context, task string) (*Result, error) {
 					// Check for function responses (tool results)
 					if part.FunctionResponse != nil {
 						if a.debug {
-							fmt.Printf("[Step %d] Tool response: %s\n", toolCallNum, part.FunctionResponse.Name)
+							fmt.Printf("[Step %d] Tool response: %s\n", state.toolCallNum, part.FunctionResponse.Name)
+						}
+
+						// Responses arrive in call order; match each to its step
+						stepIdx := len(a.steps) - 1
+						if len(pendingSteps) > 0 {
+							stepIdx, pendingSteps = pendingSteps[0], pendingSteps[1:]
 						}
+						lastActionName = part.FunctionResponse.Name
+						lastActionSuccess = true
+						var stepError, stepErrorCode string
 
 						// Extract result for history
 						resp := part.FunctionResponse.Response
@@ -374,22 +745,57 @@ func (a *BrowserAgent) Run(ctx context.Context, task string) (*Result, error) {
 							resultBytes, _ := json.Marshal(resp)
 							lastActionResult = string(resultBytes)
 
-							// Check if action failed
+							// Check if action failed; tools that return an error
+							// are reported by ADK as {"error": "..."}
 							if success, exists := resp["success"]; exists {
 								if successBool, ok := success.(bool); ok {
 									lastActionSuccess = successBool
 								}
 							}
+							if errMsg, ok := resp["error"].(string); ok {
+								lastActionSuccess = false
+								stepError = errMsg
+								stepErrorCode = ErrCodeActionFailed
+							} else if !lastActionSuccess {
+								stepError, _ = resp["message"].(string)
+								stepErrorCode, _ = resp["error_code"].(string)
+							}
+
+							// A done call skipped by the batch guard doesn't end the task
+							if skipped, _ := resp["skipped"].(bool); skipped && lastActionName == "done" {
+								taskComplete = false
+								lastResult = nil
+							}
+						}
+						if stepIdx >= 0 {
+							step := &a.steps[stepIdx]
+							step.Result = lastActionResult
+							step.Success = lastActionSuccess
+							step.Error = stepError
+							step.ErrorCode = stepErrorCode
+							step.ToolMs = a.toolTimer.take(part.FunctionResponse.ID, part.FunctionResponse.Name).Milliseconds()
 						}
 
 						// Capture screenshot after tool execution for continuation message
 						// Uses captureScreenshotAfterAction which waits for page stability
 						// This ensures the screenshot shows the result of the action
-						if a.useVision {
-							data, _, err := a.captureScreenshotAfterAction(ctx, toolCallNum)
+						// (only after the last action of a batch)
+						if a.useVision && len(pendingSteps) == 0 {
+							shotStart := time.Now()
+							data, _, err := a.captureScreenshotAfterAction(ctx, state.toolCallNum)
 							if err == nil && len(data) > 0 {
 								lastScreenshotData = data // Store for continuation message
 							}
+							if stepIdx >= 0 {
+								a.steps[stepIdx].ScreenshotMs += time.Since(shotStart).Milliseconds()
+							}
+						}
+
+						if stepIdx >= 0 {
+							step := &a.steps[stepIdx]
+							step.DurationMs = step.ModelMs + step.ToolMs + step.ScreenshotMs
+							a.messageManager.GetHistory().UpdateItem(step.Number, lastActionResult, lastActionSuccess, step.DurationMs)
+							a.emitStep(*step)
 						}
 					}
 
@@ -400,7 +806,7 @@ func (a *BrowserAgent) Run(ctx context.Context, task string) (*Result, error) {
 						if len(text) > 200 {
 							text = text[:200] + "..."
 						}
-						fmt.Printf("[Turn %d] Agent: %s\n", turnNum, text)
+						fmt.Printf("[Turn %d] Agent: %s\n", state.turnNum, text)
 					}
 				}
 			}
@@ -411,51 +817,615 @@ func (a *BrowserAgent) Run(ctx context.Context, task string) (*Result, error) {
 			}
 		}
 
+		turnTimedOut := turnCtx.Err() != nil
+		cancelTurn()
+
 		// If task is complete, break out of the loop
 		if taskComplete {
 			break
 		}
 
-		// Refresh page state for next iteration
-		if err := a.toolkit.RefreshElementMap(); err != nil {
-			if a.debug {
-				fmt.Printf("[Turn %d] Failed to refresh page state: %v\n", turnNum, err)
+		if timeUp() {
+			return a.finishRun(finishCtx, state, a.timeUpResult(state)), nil
+		}
+		if turnTimedOut {
+			if name, result, ok := a.timeOutTurn(state, pendingSteps); ok {
+				lastActionName, lastActionResult, lastActionSuccess = name, result, false
 			}
+		} else {
+			state.timeouts = 0
 		}
 
-		// Build continuation message with history and updated page state
-		continuationMsg := a.messageManager.BuildContinuationMessage(
-			a.toolkit.GetElementMap(),
-			lastActionName,
-			lastActionResult,
-			lastActionSuccess,
-		)
-
-		// Filter sensitive data
-		continuationMsg = a.messageManager.FilterSensitiveData(continuationMsg)
+		userContent = a.prepareNextTurn(ctx, state, lastActionName, lastActionResult, lastActionSuccess, lastScreenshotData)
+		lastScreenshotData = nil // Clear after use
 
-		// Create content with optional screenshot (reuse the last captured screenshot)
-		if a.useVision && len(lastScreenshotData) > 0 {
-			userContent = a.createMultimodalContent(continuationMsg, lastScreenshotData)
-			lastScreenshotData = nil // Clear after use
-		} else {
-			userContent = genai.NewContentFromText(continuationMsg, "user")
+		// Remember single-action turns so the next visit can skip the model
+		if a.actionCache != nil && turnCalls == 1 && lastActionSuccess && len(a.steps) > 0 {
+			a.storeCachedAction(ctx, cacheKey, a.steps[len(a.steps)-1])
 		}
 	}
 
 	// Return result
 	if lastResult != nil {
-		return lastResult, nil
+		return a.finishRun(finishCtx, state, lastResult), nil
 	}
 
 	// Max steps reached without completion
-	return &Result{
+	return a.finishRun(finishCtx, state, &Result{
 		Success:         false,
 		Error:           fmt.Sprintf("Max steps (%d) reached without completion", a.maxSteps),
 		Steps:           a.steps,
-		Duration:        time.Since(startTime),
+		Duration:        time.Since(state.startTime),
 		ScreenshotPaths: a.screenshotPaths,
-	}, nil
+	}), nil
+}
+
+// prepareNextTurn refreshes the page state, checkpoints the run and builds
+// the continuation message for the next model turn.
+func (a *BrowserAgent) prepareNextTurn(ctx context.Context, state *runState, lastActionName, lastActionResult string, lastActionSuccess bool, screenshotData []byte) *genai.Content {
+	// Refresh page state for next iteration
+	if err := a.toolkit.RefreshElementMap(ctx); err != nil {
+		if a.debug {
+			fmt.Printf("[Turn %d] Failed to refresh page state: %v\n", state.turnNum, err)
+		}
+	}
+
+	// Remember the tabs to restore if the browser crashes
+	if a.relaunchBrowser {
+		if err := a.browser.Snapshot(ctx); err != nil && a.debug {
+			fmt.Printf("[Turn %d] Failed to snapshot browser: %v\n", state.turnNum, err)
+		}
+	}
+
+	// Let the planner review progress after failures and periodically
+	a.reviewPlan(ctx, state, lastActionSuccess)
+
+	// Persist progress so the run can be resumed if the process dies
+	a.saveCheckpoint(ctx, state, false)
+
+	// Build continuation message with history and updated page state
+	continuationMsg := a.messageManager.BuildContinuationMessage(
+		a.toolkit.GetElementMap(),
+		lastActionName,
+		lastActionResult,
+		lastActionSuccess,
+	)
+
+	continuationMsg = a.withTimeBudget(state, continuationMsg)
+
+	// Filter sensitive data
+	continuationMsg = a.messageManager.FilterSensitiveData(continuationMsg)
+
+	// Create content with optional screenshot (reuse the last captured screenshot)
+	if a.useVision && len(screenshotData) > 0 {
+		return a.createMultimodalContent(continuationMsg, screenshotData)
+	}
+	return genai.NewContentFromText(continuationMsg, "user")
+}
+
+// Resume continues a run from its last checkpoint. The browser is restored to
+// the checkpointed cookies, localStorage and tabs, the history and ADK session
+// are reloaded, and the model is told the run was interrupted.
+func (a *BrowserAgent) Resume(ctx context.Context, runID string) (*Result, error) {
+	if a.checkpointStore == nil {
+		return nil, fmt.Errorf("cannot resume run %s: no checkpoint store configured", runID)
+	}
+
+	cp, err := a.checkpointStore.Load(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+	if cp.Done {
+		return nil, fmt.Errorf("cannot resume run %s: run already finished", runID)
+	}
+
+	// Restore browser state
+	if err := a.browser.SetStorageState(ctx, cp.Storage); err != nil {
+		return nil, fmt.Errorf("failed to restore storage state: %w", err)
+	}
+	tabs := cp.Tabs
+	if len(tabs) == 0 && cp.URL != "" {
+		tabs = []browser.TabInfo{{URL: cp.URL, Active: true}}
+	}
+	if err := a.browser.RestoreTabs(ctx, tabs); err != nil {
+		return nil, fmt.Errorf("failed to restore tabs: %w", err)
+	}
+
+	// Restore steps and history
+	a.steps = append(make([]Step, 0, len(cp.Steps)), cp.Steps...)
+	a.screenshotPaths = append(make([]string, 0, len(cp.ScreenshotPaths)), cp.ScreenshotPaths...)
+	a.toolkit.SetExtractedTables(cp.Tables)
+	a.messageManager.Clear()
+	a.messageManager.SetTask(cp.Task)
+	for _, item := range cp.History {
+		a.messageManager.AddHistoryItem(item)
+	}
+
+	state := &runState{
+		runID:       cp.RunID,
+		task:        cp.Task,
+		userID:      "user",
+		sessionID:   cp.SessionID,
+		startTime:   time.Now().Add(-cp.Elapsed),
+		turnNum:     cp.TurnNumber,
+		toolCallNum: cp.StepNumber,
+		firstStep:   cp.FirstStep,
+		stepLimit:   cp.StepLimit,
+		cacheHits:   cp.CacheHits,
+		cacheMisses: cp.CacheMisses,
+		plan:        cp.Plan,
+		planStep:    cp.StepNumber,
+		retryBase:   a.retries.Load() - int64(cp.Retries),
+	}
+	a.messageManager.SetPlan(state.plan)
+	if state.stepLimit <= 0 {
+		state.stepLimit = a.maxSteps
+	}
+
+	// Recreate the ADK session with the recorded conversation
+	_ = a.sessionService.Delete(ctx, &session.DeleteRequest{
+		AppName:   "bua-browser-agent",
+		UserID:    state.userID,
+		SessionID: state.sessionID,
+	})
+	created, err := a.sessionService.Create(ctx, &session.CreateRequest{
+		AppName:   "bua-browser-agent",
+		UserID:    state.userID,
+		SessionID: state.sessionID,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create session: %w", err)
+	}
+	for _, event := range cp.Events {
+		if err := a.sessionService.AppendEvent(ctx, created.Session, event); err != nil {
+			return nil, fmt.Errorf("failed to restore session: %w", err)
+		}
+	}
+
+	if err := a.toolkit.RefreshElementMap(ctx); err != nil {
+		if a.debug {
+			fmt.Printf("[Debug] Resumed page state: %v\n", err)
+		}
+	}
+
+	userContent := a.restoredContent(ctx, state, BuildResumePrompt)
+
+	if a.debug {
+		fmt.Printf("[Resume] Run %s resumed at step %d\n", state.runID, state.toolCallNum)
+	}
+
+	return a.runLoop(ctx, state, userContent)
+}
+
+// restoredContent builds the message that continues a run from the last
+// recorded action after the browser was restored, with the page state and a
+// fresh screenshot. wrap adds the explanation of what happened.
+func (a *BrowserAgent) restoredContent(ctx context.Context, state *runState, wrap func(string) string) *genai.Content {
+	var lastActionName, lastActionResult string
+	lastActionSuccess := true
+	if last := a.messageManager.GetHistory().GetLastItem(); last != nil {
+		lastActionName = last.ActionName
+		lastActionResult = last.ActionResult
+		lastActionSuccess = last.ActionSuccess
+	}
+	msg := wrap(a.messageManager.BuildContinuationMessage(
+		a.toolkit.GetElementMap(),
+		lastActionName,
+		lastActionResult,
+		lastActionSuccess,
+	))
+	msg = a.withTimeBudget(state, msg)
+	msg = a.messageManager.FilterSensitiveData(msg)
+
+	if a.useVision {
+		screenshotData, _, err := a.captureAndSaveScreenshot(ctx, state.turnNum)
+		if err == nil && len(screenshotData) > 0 {
+			return a.createMultimodalContent(msg, screenshotData)
+		}
+	}
+	return genai.NewContentFromText(msg, "user")
+}
+
+// recoverBrowser relaunches a crashed or disconnected browser and returns
+// the message telling the model about it. Without RelaunchBrowser, or once
+// the run has used up its relaunches, the crash ends the run with an error
+// wrapping browser.ErrBrowserClosed; the last checkpoint stays resumable.
+func (a *BrowserAgent) recoverBrowser(ctx context.Context, state *runState, crash error) (*genai.Content, error) {
+	if !a.relaunchBrowser || state.relaunches >= maxRelaunches {
+		return nil, fmt.Errorf("run stopped at step %d: %w", state.toolCallNum, crash)
+	}
+	state.relaunches++
+
+	if a.debug {
+		fmt.Printf("[Turn %d] %v, relaunching (%d/%d)\n", state.turnNum, crash, state.relaunches, maxRelaunches)
+	}
+	if err := a.browser.Relaunch(ctx); err != nil {
+		return nil, fmt.Errorf("run stopped at step %d: %w (%v)", state.toolCallNum, crash, err)
+	}
+	if err := a.toolkit.RefreshElementMap(ctx); err != nil && a.debug {
+		fmt.Printf("[Turn %d] Failed to refresh page state: %v\n", state.turnNum, err)
+	}
+
+	return a.restoredContent(ctx, state, BuildBrowserRestartedPrompt), nil
+}
+
+// finishRun stamps the run ID on the result and records the run as finished.
+func (a *BrowserAgent) finishRun(ctx context.Context, state *runState, result *Result) *Result {
+	result.RunID = state.runID
+	result.Task = state.task
+	result.CacheHits = state.cacheHits
+	result.CacheMisses = state.cacheMisses
+	result.Plan = state.plan
+	result.Retries = a.runRetries(state)
+	a.saveCheckpoint(ctx, state, true)
+	return result
+}
+
+// runRetries returns how many model calls, executor and planner, were
+// retried during the run.
+func (a *BrowserAgent) runRetries(state *runState) int {
+	return int(a.retries.Load() - state.retryBase)
+}
+
+// saveCheckpoint persists the current run state. Failures are logged, not
+// returned, since a missed checkpoint should not abort the run.
+func (a *BrowserAgent) saveCheckpoint(ctx context.Context, state *runState, done bool) {
+	if a.checkpointStore == nil {
+		return
+	}
+
+	cp := &Checkpoint{
+		RunID:           state.runID,
+		Task:            state.task,
+		SessionID:       state.sessionID,
+		Steps:           a.steps,
+		History:         a.messageManager.GetHistory().GetItems(),
+		ScreenshotPaths: a.screenshotPaths,
+		Tables:          a.toolkit.ExtractedTables(),
+		TurnNumber:      state.turnNum,
+		StepNumber:      state.toolCallNum,
+		FirstStep:       state.firstStep,
+		StepLimit:       state.stepLimit,
+		CacheHits:       state.cacheHits,
+		CacheMisses:     state.cacheMisses,
+		Plan:            state.plan,
+		Retries:         a.runRetries(state),
+		URL:             a.browser.GetURL(),
+		Tabs:            a.browser.ListTabs(),
+		Elapsed:         time.Since(state.startTime),
+		UpdatedAt:       time.Now(),
+		Done:            done,
+	}
+
+	resp, err := a.sessionService.Get(ctx, &session.GetRequest{
+		AppName:   "bua-browser-agent",
+		UserID:    state.userID,
+		SessionID: state.sessionID,
+	})
+	if err == nil {
+		for event := range resp.Session.Events().All() {
+			cp.Events = append(cp.Events, withoutImages(event))
+		}
+	}
+
+	if !done {
+		storage, err := a.browser.StorageState(ctx)
+		if err != nil && a.debug {
+			fmt.Printf("[Checkpoint] Failed to capture storage state: %v\n", err)
+		}
+		cp.Storage = storage
+	}
+
+	if err := a.checkpointStore.Save(ctx, cp); err != nil && a.debug {
+		fmt.Printf("[Checkpoint] Failed to save run %s: %v\n", state.runID, err)
+	}
+}
+
+// startPlan asks the planner for the initial checklist. A planner failure
+// is logged and the run continues without a plan.
+func (a *BrowserAgent) startPlan(ctx context.Context, state *runState) {
+	if a.planner == nil {
+		return
+	}
+	pageURL, pageTitle := a.pageInfo()
+	plan, err := a.planner.Start(ctx, state.task, pageURL, pageTitle)
+	if err != nil {
+		if a.debug {
+			fmt.Printf("[Planner] Failed to create plan: %v\n", err)
+		}
+		return
+	}
+	a.setPlan(state, plan)
+}
+
+// reviewPlan asks the planner to update the checklist after a failed action
+// or every plannerInterval steps.
+func (a *BrowserAgent) reviewPlan(ctx context.Context, state *runState, lastActionSuccess bool) {
+	if a.planner == nil || state.plan == nil {
+		return
+	}
+
+	var reason string
+	switch {
+	case !lastActionSuccess:
+		reason = "The executor's last action failed."
+	case state.toolCallNum-state.planStep >= plannerInterval:
+		reason = fmt.Sprintf("%d steps since the plan was last reviewed.", state.toolCallNum-state.planStep)
+	default:
+		return
+	}
+
+	pageURL, pageTitle := a.pageInfo()
+	history := a.messageManager.FilterSensitiveData(a.messageManager.GetHistory().ToDescription())
+	plan, err := a.planner.Update(ctx, state.plan, history, pageURL, pageTitle, reason)
+	if err != nil {
+		if a.debug {
+			fmt.Printf("[Planner] Failed to update plan: %v\n", err)
+		}
+		state.planStep = state.toolCallNum // Don't retry on every turn
+		return
+	}
+	a.setPlan(state, plan)
+}
+
+// setPlan records a new plan and shows it to the executor.
+func (a *BrowserAgent) setPlan(state *runState, plan *Plan) {
+	state.plan = plan
+	state.planStep = state.toolCallNum
+	state.planChanged = true
+	a.messageManager.SetPlan(plan)
+}
+
+// pageInfo returns the current page URL and title.
+func (a *BrowserAgent) pageInfo() (string, string) {
+	var title string
+	if elementMap := a.toolkit.GetElementMap(); elementMap != nil {
+		title = elementMap.PageTitle
+	}
+	return a.browser.GetURL(), title
+}
+
+// annotateStep attaches the current plan item to a step, and a snapshot of
+// the plan if it changed since the previous step.
+func (a *BrowserAgent) annotateStep(state *runState, step *Step) {
+	if state.plan == nil {
+		return
+	}
+	if item := state.plan.Current(); item != nil {
+		step.PlanItem = item.Description
+	}
+	if state.planChanged {
+		step.Plan = state.plan.clone()
+		state.planChanged = false
+	}
+}
+
+// emitStep reports a completed step to the OnStep callback.
+func (a *BrowserAgent) emitStep(step Step) {
+	if a.onStep != nil {
+		a.onStep(step)
+	}
+}
+
+// cacheOutcome is the result of replaying a cached action.
+type cacheOutcome struct {
+	name       string
+	result     string
+	success    bool
+	screenshot []byte
+}
+
+// actionCacheKey fingerprints the current decision point for the action cache.
+func (a *BrowserAgent) actionCacheKey(state *runState) string {
+	var prev string
+	if last := a.messageManager.GetHistory().GetLastItem(); last != nil {
+		prev = cacheActionSignature(last.ActionName, last.ActionParams)
+	}
+	return ActionCacheKey(a.browser.GetURL(), state.task, prev, a.toolkit.GetElementMap())
+}
+
+// runCachedAction executes the cached action for key, if any, and verifies
+// that the page ends up where it did when the action was cached. Returns
+// false without taking a step on a miss. An action whose outcome differs is
+// evicted, and the model is told to check the page.
+func (a *BrowserAgent) runCachedAction(ctx context.Context, state *runState, key string) (*cacheOutcome, bool) {
+	if state.cacheUsed[key] {
+		// Each key is replayed once per run so an unchanged page can't loop
+		state.cacheMisses++
+		return nil, false
+	}
+
+	cached, err := a.actionCache.Get(ctx, key)
+	if err != nil {
+		if !errors.Is(err, ErrCacheMiss) && a.debug {
+			fmt.Printf("[Cache] Lookup failed: %v\n", err)
+		}
+		state.cacheMisses++
+		return nil, false
+	}
+
+	// Cached actions from other configurations must respect this one's
+	// tool permissions
+	if err := a.toolGuard.checkJSON(cached.Action, cached.Args); err != nil {
+		if a.debug {
+			fmt.Printf("[Cache] Skipping cached %s: %v\n", cached.Action, err)
+		}
+		state.cacheMisses++
+		return nil, false
+	}
+
+	// The element must still be on the page before a step is taken
+	var locator *dom.Locator
+	if cached.Locator != nil {
+		el, _ := cached.Locator.Resolve(a.toolkit.GetElementMap())
+		if el == nil {
+			_ = a.actionCache.Delete(ctx, key)
+			state.cacheMisses++
+			return nil, false
+		}
+		locator = dom.NewLocator(el)
+	}
+
+	if state.cacheUsed == nil {
+		state.cacheUsed = make(map[string]bool)
+	}
+	state.cacheUsed[key] = true
+	state.toolCallNum++
+	callStart := time.Now()
+	pageURL, pageTitle := a.pageInfo()
+
+	if a.debug {
+		fmt.Printf("[Step %d] Cache hit: %s\n", state.toolCallNum, cached.Action)
+	}
+
+	replayer := NewReplayer(a.browser, nil, a.debug)
+	_, err = replayer.replayStep(ctx, ScriptStep{Action: cached.Action, Args: cached.Args, Locator: cached.Locator})
+
+	success := err == nil
+	var message, errorCode string
+	switch {
+	case err != nil:
+		message = a.browser.ScrubSecrets(fmt.Sprintf("Cached %s failed: %v", cached.Action, err))
+		errorCode = ErrorCode(err)
+	case a.verifyCachedOutcome(ctx, cached):
+		message = fmt.Sprintf("Replayed cached %s; the page reached the expected state", cached.Action)
+	default:
+		message = fmt.Sprintf("Replayed cached %s, but the page did not reach the expected state. Check the page before continuing", cached.Action)
+		err = fmt.Errorf("unexpected outcome")
+	}
+	if err != nil {
+		state.cacheMisses++
+		if delErr := a.actionCache.Delete(ctx, key); delErr != nil && a.debug {
+			fmt.Printf("[Cache] Failed to evict entry: %v\n", delErr)
+		}
+	} else {
+		state.cacheHits++
+	}
+
+	result := map[string]any{"success": success, "message": message, "cached": true}
+	if errorCode != "" {
+		result["error_code"] = errorCode
+	}
+	resultBytes, _ := json.Marshal(result)
+	toolMs := time.Since(callStart).Milliseconds()
+
+	outcome := &cacheOutcome{name: cached.Action, result: string(resultBytes), success: success}
+	var screenshotMs int64
+	if a.useVision {
+		shotStart := time.Now()
+		data, _, err := a.captureScreenshotAfterAction(ctx, state.toolCallNum)
+		if err == nil && len(data) > 0 {
+			outcome.screenshot = data
+		}
+		screenshotMs = time.Since(shotStart).Milliseconds()
+	}
+
+	// The cache may be shared with runs that have other secrets configured,
+	// so its arguments are scrubbed like the model's own
+	args := a.browser.ScrubSecrets(string(cached.Args))
+	step := Step{
+		Number:       state.toolCallNum,
+		Action:       cached.Action,
+		Target:       args,
+		URL:          pageURL,
+		Title:        pageTitle,
+		Locator:      locator,
+		Result:       string(resultBytes),
+		Success:      success,
+		Timestamp:    callStart,
+		DurationMs:   toolMs + screenshotMs,
+		ToolMs:       toolMs,
+		ScreenshotMs: screenshotMs,
+		Cached:       true,
+	}
+	if !success {
+		step.Error = message
+		step.ErrorCode = errorCode
+	}
+	a.annotateStep(state, &step)
+	a.steps = append(a.steps, step)
+	a.emitStep(step)
+	a.messageManager.AddHistoryItem(HistoryItem{
+		StepNumber:    state.toolCallNum,
+		Timestamp:     callStart,
+		ActionName:    cached.Action,
+		ActionParams:  args,
+		ActionResult:  message,
+		ActionSuccess: success,
+		DurationMs:    step.DurationMs,
+	})
+
+	return outcome, true
+}
+
+// verifyCachedOutcome waits for the page to settle and checks it matches the
+// page recorded after the cached action.
+func (a *BrowserAgent) verifyCachedOutcome(ctx context.Context, cached *CachedAction) bool {
+	_ = a.browser.WaitStable(ctx)
+	if err := a.toolkit.RefreshElementMap(ctx); err != nil {
+		return false
+	}
+	elementMap := a.toolkit.GetElementMap()
+	if elementMap == nil || URLPattern(a.browser.GetURL()) != URLPattern(cached.OutcomeURL) {
+		return false
+	}
+	return elementMap.Fingerprint() == cached.OutcomeFingerprint
+}
+
+// storeCachedAction caches the action the model took at key, along with the
+// page it led to. Call after the element map has been refreshed.
+func (a *BrowserAgent) storeCachedAction(ctx context.Context, key string, step Step) {
+	if key == "" || !cacheableActions[step.Action] || (isElementAction(step.Action) && step.Locator == nil) {
+		return
+	}
+	elementMap := a.toolkit.GetElementMap()
+	if elementMap == nil {
+		return
+	}
+
+	err := a.actionCache.Put(ctx, &CachedAction{
+		Key:                key,
+		Action:             step.Action,
+		Args:               json.RawMessage(step.Target),
+		Locator:            step.Locator,
+		URL:                step.URL,
+		OutcomeURL:         a.browser.GetURL(),
+		OutcomeFingerprint: elementMap.Fingerprint(),
+		CreatedAt:          time.Now(),
+	})
+	if err != nil && a.debug {
+		fmt.Printf("[Cache] Failed to store %s: %v\n", step.Action, err)
+	}
+}
+
+// parseBrain reads the structured reasoning from a tool call's arguments.
+func parseBrain(toolArgs []byte) AgentBrain {
+	var args struct {
+		Reasoning AgentBrain `json:"reasoning"`
+	}
+	_ = json.Unmarshal(toolArgs, &args)
+	return args.Reasoning
+}
+
+// locateTarget captures a locator for the element a tool call targets,
+// so the step can be replayed after indices change. Returns nil for tool
+// calls without an element_index.
+func (a *BrowserAgent) locateTarget(toolArgs []byte) *dom.Locator {
+	var target struct {
+		ElementIndex *int `json:"element_index"`
+	}
+	if err := json.Unmarshal(toolArgs, &target); err != nil || target.ElementIndex == nil {
+		return nil
+	}
+	elementMap := a.toolkit.GetElementMap()
+	if elementMap == nil {
+		return nil
+	}
+	el, ok := elementMap.Get(*target.ElementIndex)
+	if !ok {
+		return nil
+	}
+	return dom.NewLocator(el)
 }
 
 // GetSteps returns all executed steps.
@@ -0,0 +1,110 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/anxuanzi/bua/browser"
+	"github.com/anxuanzi/bua/dom"
+	"google.golang.org/adk/tool"
+)
+
+// maxBatchActions caps how many actions of a multi-action turn are run.
+const maxBatchActions = 10
+
+// batchGuard runs before every tool call when multi-action turns are
+// enabled. The first action of a turn always runs; each later one only runs
+// while the page is still the one the model planned the batch on, i.e. the
+// URL is unchanged, no elements appeared or disappeared, and every index
+// still refers to the same element in the same place, since later actions
+// use the planned page's indices and coordinates. Value and text changes,
+// as caused by typing, are expected and don't stop the batch.
+type batchGuard struct {
+	page pageReader
+
+	mu      sync.Mutex
+	baseURL string
+	baseMap *dom.ElementMap
+	calls   int
+	aborted string // why the rest of the batch is skipped
+}
+
+// pageReader reads the active page; *browser.Browser implements it.
+type pageReader interface {
+	GetURL() string
+	GetElementMap(ctx context.Context) (*dom.ElementMap, error)
+}
+
+var _ pageReader = (*browser.Browser)(nil)
+
+// newBatchGuard creates a guard for the browser's active page.
+func newBatchGuard(page pageReader) *batchGuard {
+	return &batchGuard{page: page}
+}
+
+// begin starts a new turn planned on the given page state.
+func (g *batchGuard) begin(pageURL string, elementMap *dom.ElementMap) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.baseURL = pageURL
+	g.baseMap = elementMap
+	g.calls = 0
+	g.aborted = ""
+}
+
+// beforeTool is an ADK BeforeToolCallback. Returning a non-nil result skips
+// the tool and reports the result to the model instead.
+func (g *batchGuard) beforeTool(ctx tool.Context, t tool.Tool, args map[string]any) (map[string]any, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.calls++
+	if g.calls == 1 {
+		return nil, nil
+	}
+
+	if g.aborted == "" {
+		g.aborted = g.check(ctx)
+		if g.aborted == "" && g.calls > maxBatchActions {
+			g.aborted = fmt.Sprintf("a turn may run at most %d actions", maxBatchActions)
+		}
+	}
+	if g.aborted != "" {
+		return map[string]any{
+			"success": false,
+			"skipped": true,
+			"message": fmt.Sprintf("Skipped %s: %s. Review the new page state and issue the remaining actions again.", t.Name(), g.aborted),
+		}, nil
+	}
+	return nil, nil
+}
+
+// check compares the current page with the one the batch was planned on.
+// Returns why the batch should stop, or "" if it can continue.
+func (g *batchGuard) check(ctx tool.Context) string {
+	if url := g.page.GetURL(); url != g.baseURL {
+		return fmt.Sprintf("the page navigated to %s", url)
+	}
+	if g.baseMap == nil {
+		return ""
+	}
+
+	current, err := g.page.GetElementMap(ctx)
+	if err != nil {
+		return fmt.Sprintf("the page could not be read (%v)", err)
+	}
+	diff := dom.Diff(g.baseMap, current)
+	if diff == nil {
+		return "the page changed"
+	}
+	if len(diff.Added) > 0 || len(diff.Removed) > 0 {
+		return fmt.Sprintf("the page changed (%d elements appeared, %d disappeared)", len(diff.Added), len(diff.Removed))
+	}
+	// Later actions use the indices and coordinates of the planned page
+	if !dom.SameLayout(g.baseMap, current) {
+		return "the page layout changed (elements moved or were reordered)"
+	}
+	return ""
+}
@@ -0,0 +1,140 @@
+package agent
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/anxuanzi/bua/dom"
+)
+
+// fakePage is a pageReader whose state the test sets.
+type fakePage struct {
+	url string
+	em  *dom.ElementMap
+}
+
+func (p *fakePage) GetURL() string { return p.url }
+
+func (p *fakePage) GetElementMap(ctx context.Context) (*dom.ElementMap, error) {
+	return p.em, nil
+}
+
+// batchPage builds an element map of buttons at the given vertical offsets.
+func batchPage(url string, ys ...float64) *dom.ElementMap {
+	m := dom.NewElementMap()
+	m.PageURL = url
+	for i, y := range ys {
+		label := string(rune('A' + i))
+		m.Add(&dom.Element{
+			Index: i, TagName: "BUTTON", Text: label, Selector: "#" + strings.ToLower(label),
+			BoundingBox: dom.BoundingBox{Y: y, Width: 80, Height: 20},
+		})
+	}
+	return m
+}
+
+func TestBatchGuard(t *testing.T) {
+	const url = "https://example.com/form"
+
+	tests := []struct {
+		name       string
+		change     func(p *fakePage) // Applied after the first action
+		wantReason string            // "" = the batch continues
+	}{
+		{name: "unchanged page", change: func(p *fakePage) {}},
+		{
+			name: "typed value",
+			change: func(p *fakePage) {
+				p.em = batchPage(url, 0, 40)
+				el, _ := p.em.Get(0)
+				el.Value = "typed"
+			},
+		},
+		{name: "navigation", change: func(p *fakePage) { p.url = "https://example.com/next" }, wantReason: "navigated"},
+		{name: "element added", change: func(p *fakePage) { p.em = batchPage(url, 0, 40, 80) }, wantReason: "appeared"},
+		{name: "element moved", change: func(p *fakePage) { p.em = batchPage(url, 0, 140) }, wantReason: "layout changed"},
+		{
+			name: "reordered",
+			change: func(p *fakePage) {
+				p.em = dom.NewElementMap()
+				p.em.PageURL = url
+				p.em.Add(&dom.Element{Index: 0, TagName: "BUTTON", Text: "B", Selector: "#b", BoundingBox: dom.BoundingBox{Y: 0, Width: 80, Height: 20}})
+				p.em.Add(&dom.Element{Index: 1, TagName: "BUTTON", Text: "A", Selector: "#a", BoundingBox: dom.BoundingBox{Y: 40, Width: 80, Height: 20}})
+			},
+			wantReason: "layout changed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			base := batchPage(url, 0, 40)
+			page := &fakePage{url: url, em: base}
+			g := newBatchGuard(page)
+			g.begin(url, base)
+
+			if result, _ := g.beforeTool(nil, namedTool("click"), nil); result != nil {
+				t.Fatalf("first action skipped: %v", result)
+			}
+			tt.change(page)
+
+			// The rest of the batch, including done, is skipped once the page changed
+			for _, name := range []string{"type_text", "click", "done"} {
+				result, err := g.beforeTool(nil, namedTool(name), nil)
+				if err != nil {
+					t.Fatalf("beforeTool(%s) error = %v", name, err)
+				}
+				if tt.wantReason == "" {
+					if result != nil {
+						t.Fatalf("beforeTool(%s) = %v, want the batch to continue", name, result)
+					}
+					continue
+				}
+				if result == nil || result["skipped"] != true || result["success"] != false {
+					t.Fatalf("beforeTool(%s) = %v, want it skipped", name, result)
+				}
+				if msg, _ := result["message"].(string); !strings.Contains(msg, tt.wantReason) || !strings.Contains(msg, name) {
+					t.Errorf("message = %q, want it to name %s and mention %q", msg, name, tt.wantReason)
+				}
+			}
+		})
+	}
+}
+
+func TestBatchGuardNewTurn(t *testing.T) {
+	const url = "https://example.com"
+	page := &fakePage{url: url, em: batchPage(url, 0)}
+	g := newBatchGuard(page)
+	g.begin(url, page.em)
+
+	g.beforeTool(nil, namedTool("click"), nil)
+	page.url = "https://example.com/next"
+	if result, _ := g.beforeTool(nil, namedTool("done"), nil); result == nil {
+		t.Fatal("done after a navigation was not skipped")
+	}
+
+	// A new turn is planned on the new page
+	page.em = batchPage(page.url, 0)
+	g.begin(page.url, page.em)
+	for _, name := range []string{"click", "done"} {
+		if result, _ := g.beforeTool(nil, namedTool(name), nil); result != nil {
+			t.Errorf("beforeTool(%s) = %v in a new turn, want it to run", name, result)
+		}
+	}
+}
+
+func TestBatchGuardLimit(t *testing.T) {
+	const url = "https://example.com"
+	page := &fakePage{url: url, em: batchPage(url, 0)}
+	g := newBatchGuard(page)
+	g.begin(url, page.em)
+
+	for i := 1; i <= maxBatchActions; i++ {
+		if result, _ := g.beforeTool(nil, namedTool("scroll"), nil); result != nil {
+			t.Fatalf("action %d skipped: %v", i, result)
+		}
+	}
+	if result, _ := g.beforeTool(nil, namedTool("scroll"), nil); result == nil {
+		t.Errorf("action %d ran, want it skipped", maxBatchActions+1)
+	}
+}
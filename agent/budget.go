@@ -0,0 +1,96 @@
+package agent
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// deadline returns when the run's time budget ends, or the zero time if it
+// has none. Resumed runs continue the budget of the original run.
+func (a *BrowserAgent) deadline(state *runState) time.Time {
+	if a.maxDuration <= 0 {
+		return time.Time{}
+	}
+	return state.startTime.Add(a.maxDuration)
+}
+
+// wrapUpWindow is how long before the deadline the model is told to wrap
+// up: a fifth of the budget, but at least one turn.
+func (a *BrowserAgent) wrapUpWindow() time.Duration {
+	return max(a.maxDuration/5, a.stepTimeout)
+}
+
+// withTimeBudget adds a wrap-up warning to a continuation message once the
+// run is within its wrap-up window.
+func (a *BrowserAgent) withTimeBudget(state *runState, msg string) string {
+	deadline := a.deadline(state)
+	if deadline.IsZero() {
+		return msg
+	}
+	remaining := time.Until(deadline)
+	if remaining > a.wrapUpWindow() {
+		return msg
+	}
+
+	if !state.wrapUp && a.debug {
+		fmt.Printf("[Turn %d] %s left, asking the model to wrap up\n", state.turnNum, remaining.Round(time.Second))
+	}
+	state.wrapUp = true
+	return BuildTimeBudgetPrompt(max(remaining, 0), msg)
+}
+
+// turnContext bounds one turn by the step timeout, if there is one.
+func (a *BrowserAgent) turnContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if a.stepTimeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, a.stepTimeout)
+}
+
+// timeOutTurn fails the steps of a turn cut off by the step timeout that
+// were still waiting for their action to finish, and returns the last of
+// them with the result to report to the model. ok is false if the turn
+// timed out before the model chose an action.
+func (a *BrowserAgent) timeOutTurn(state *runState, pending []int) (name, result string, ok bool) {
+	state.timeouts++
+	if a.debug {
+		fmt.Printf("[Turn %d] Timed out after %s\n", state.turnNum, a.stepTimeout)
+	}
+
+	result = BuildStepTimeoutResult(a.stepTimeout)
+	for _, idx := range pending {
+		step := &a.steps[idx]
+		step.Result = result
+		step.Success = false
+		step.Error = fmt.Sprintf("step timed out after %s", a.stepTimeout)
+		step.ErrorCode = ErrCodeTimeout
+		step.DurationMs = time.Since(step.Timestamp).Milliseconds() + step.ModelMs + step.ScreenshotMs
+		a.messageManager.GetHistory().UpdateItem(step.Number, result, false, step.DurationMs)
+		a.emitStep(*step)
+		name, ok = step.Action, true
+	}
+	return name, result, ok
+}
+
+// timeUpResult is the result of a run stopped by its time budget, with the
+// data gathered so far.
+func (a *BrowserAgent) timeUpResult(state *runState) *Result {
+	if a.debug {
+		fmt.Printf("[Turn %d] Time budget of %s used up, stopping\n", state.turnNum, a.maxDuration)
+	}
+
+	var data any
+	if tables := a.toolkit.ExtractedTables(); len(tables) > 0 {
+		data = tables
+	}
+	return &Result{
+		Success:         false,
+		Partial:         true,
+		Data:            data,
+		Error:           fmt.Sprintf("Time limit (%s) reached before the task completed", a.maxDuration),
+		Steps:           a.steps,
+		Duration:        time.Since(state.startTime),
+		ScreenshotPaths: a.screenshotPaths,
+	}
+}
@@ -0,0 +1,311 @@
+package agent
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/anxuanzi/bua/dom"
+)
+
+// ErrCacheMiss is returned by ActionCache.Get when no action is cached for a key.
+var ErrCacheMiss = errors.New("bua: action not cached")
+
+// cacheableActions are the tools whose effect can be reproduced without the
+// model. Observation tools are left out because their output feeds the
+// model, and tab tools because tab IDs differ between runs.
+var cacheableActions = map[string]bool{
+	"navigate":          true,
+	"go_back":           true,
+	"go_forward":        true,
+	"reload":            true,
+	"click":             true,
+	"double_click":      true,
+	"hover":             true,
+	"focus":             true,
+	"scroll_to_element": true,
+	"type_text":         true,
+	"clear_and_type":    true,
+	"scroll":            true,
+	"send_keys":         true,
+	"wait":              true,
+	"wait_for":          true,
+}
+
+// CachedAction is an action the model chose for a page fingerprint,
+// together with the page it led to so a cached replay can be verified.
+type CachedAction struct {
+	Key     string          `json:"key"`
+	Action  string          `json:"action"`
+	Args    json.RawMessage `json:"args,omitempty"`
+	Locator *dom.Locator    `json:"locator,omitempty"`
+
+	// URL is the page the action was chosen on.
+	URL string `json:"url,omitempty"`
+
+	// OutcomeURL and OutcomeFingerprint describe the page after the action.
+	OutcomeURL         string `json:"outcome_url"`
+	OutcomeFingerprint string `json:"outcome_fingerprint"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ActionCache stores actions keyed by page fingerprint.
+type ActionCache interface {
+	// Get returns the cached action for a key, or ErrCacheMiss.
+	Get(ctx context.Context, key string) (*CachedAction, error)
+
+	// Put stores an action, replacing any previous one for the same key.
+	Put(ctx context.Context, action *CachedAction) error
+
+	// Delete removes a key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// ActionCacheKey fingerprints a decision point: the URL pattern, the task,
+// the previous action and the page's interactive structure. The previous
+// action is included so repeated visits to an unchanged page within a run
+// (e.g. before and after pressing Tab) get distinct keys.
+func ActionCacheKey(pageURL, task, prevAction string, em *dom.ElementMap) string {
+	elements := ""
+	if em != nil {
+		elements = em.Fingerprint()
+	}
+	h := sha256.New()
+	for _, part := range []string{
+		URLPattern(pageURL),
+		strings.ToLower(strings.Join(strings.Fields(task), " ")),
+		prevAction,
+		elements,
+	} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// URLPattern reduces a URL to its scheme, host, path with ID-like segments
+// replaced by "*", and sorted query parameter names.
+func URLPattern(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return rawURL
+	}
+	pattern := u.Scheme + "://" + strings.ToLower(u.Host) + dom.NormalizeHref(u.EscapedPath())
+	if u.RawQuery != "" {
+		keys := make([]string, 0)
+		for key := range u.Query() {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		pattern += "?" + strings.Join(keys, "&")
+	}
+	return pattern
+}
+
+// cacheActionSignature identifies an action for ActionCacheKey, leaving out
+// the free-form reasoning so the same action always has the same signature.
+func cacheActionSignature(name, params string) string {
+	if name == "" {
+		return ""
+	}
+	var args map[string]any
+	if json.Unmarshal([]byte(params), &args) != nil {
+		return name
+	}
+	delete(args, "reasoning")
+	normalized, _ := json.Marshal(args) // map keys are sorted
+	return name + string(normalized)
+}
+
+// MemoryActionCache keeps cached actions in memory for the life of the process.
+type MemoryActionCache struct {
+	mu      sync.RWMutex
+	actions map[string]*CachedAction
+}
+
+// NewMemoryActionCache creates an empty in-memory action cache.
+func NewMemoryActionCache() *MemoryActionCache {
+	return &MemoryActionCache{actions: make(map[string]*CachedAction)}
+}
+
+// Get returns the cached action for a key.
+func (c *MemoryActionCache) Get(ctx context.Context, key string) (*CachedAction, error) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	action, ok := c.actions[key]
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+	cp := *action
+	return &cp, nil
+}
+
+// Put stores an action.
+func (c *MemoryActionCache) Put(ctx context.Context, action *CachedAction) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	cp := *action
+	c.actions[action.Key] = &cp
+	return nil
+}
+
+// Delete removes a key.
+func (c *MemoryActionCache) Delete(ctx context.Context, key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	delete(c.actions, key)
+	return nil
+}
+
+// FileActionCache stores each cached action as a JSON file in a directory.
+type FileActionCache struct {
+	dir string
+}
+
+// NewFileActionCache creates a file-based action cache, creating dir if needed.
+func NewFileActionCache(dir string) (*FileActionCache, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create cache directory: %w", err)
+	}
+	return &FileActionCache{dir: dir}, nil
+}
+
+// path returns the file path for a key, rejecting keys that would escape the directory.
+func (c *FileActionCache) path(key string) (string, error) {
+	if key == "" || strings.ContainsAny(key, `/\`) || key == "." || key == ".." {
+		return "", fmt.Errorf("invalid cache key: %q", key)
+	}
+	return filepath.Join(c.dir, key+".json"), nil
+}
+
+// Get reads the cached action for a key.
+func (c *FileActionCache) Get(ctx context.Context, key string) (*CachedAction, error) {
+	path, err := c.path(key)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, ErrCacheMiss
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cached action: %w", err)
+	}
+
+	var action CachedAction
+	if err := json.Unmarshal(data, &action); err != nil {
+		return nil, fmt.Errorf("failed to decode cached action: %w", err)
+	}
+	return &action, nil
+}
+
+// Put writes the action atomically.
+func (c *FileActionCache) Put(ctx context.Context, action *CachedAction) error {
+	path, err := c.path(action.Key)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(action)
+	if err != nil {
+		return fmt.Errorf("failed to encode cached action: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write cached action: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write cached action: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the cached action file.
+func (c *FileActionCache) Delete(ctx context.Context, key string) error {
+	path, err := c.path(key)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete cached action: %w", err)
+	}
+	return nil
+}
+
+// SQLiteActionCache stores cached actions in a SQLite table.
+// The caller opens the database with the SQLite driver of their choice.
+type SQLiteActionCache struct {
+	db *sql.DB
+}
+
+// NewSQLiteActionCache creates a SQLite action cache, creating its table if needed.
+func NewSQLiteActionCache(ctx context.Context, db *sql.DB) (*SQLiteActionCache, error) {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS bua_action_cache (
+		key        TEXT PRIMARY KEY,
+		data       BLOB NOT NULL,
+		created_at INTEGER NOT NULL
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create action cache table: %w", err)
+	}
+	return &SQLiteActionCache{db: db}, nil
+}
+
+// Get reads the cached action for a key.
+func (c *SQLiteActionCache) Get(ctx context.Context, key string) (*CachedAction, error) {
+	var data []byte
+	err := c.db.QueryRowContext(ctx, `SELECT data FROM bua_action_cache WHERE key = ?`, key).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrCacheMiss
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cached action: %w", err)
+	}
+
+	var action CachedAction
+	if err := json.Unmarshal(data, &action); err != nil {
+		return nil, fmt.Errorf("failed to decode cached action: %w", err)
+	}
+	return &action, nil
+}
+
+// Put upserts the action.
+func (c *SQLiteActionCache) Put(ctx context.Context, action *CachedAction) error {
+	data, err := json.Marshal(action)
+	if err != nil {
+		return fmt.Errorf("failed to encode cached action: %w", err)
+	}
+
+	_, err = c.db.ExecContext(ctx, `INSERT INTO bua_action_cache (key, data, created_at) VALUES (?, ?, ?)
+		ON CONFLICT(key) DO UPDATE SET data = excluded.data, created_at = excluded.created_at`,
+		action.Key, data, action.CreatedAt.UnixMilli())
+	if err != nil {
+		return fmt.Errorf("failed to save cached action: %w", err)
+	}
+	return nil
+}
+
+// Delete removes a key.
+func (c *SQLiteActionCache) Delete(ctx context.Context, key string) error {
+	if _, err := c.db.ExecContext(ctx, `DELETE FROM bua_action_cache WHERE key = ?`, key); err != nil {
+		return fmt.Errorf("failed to delete cached action: %w", err)
+	}
+	return nil
+}
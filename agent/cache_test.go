@@ -0,0 +1,141 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/anxuanzi/bua/dom"
+)
+
+func TestURLPattern(t *testing.T) {
+	tests := []struct {
+		url  string
+		want string
+	}{
+		{url: "https://example.com/", want: "https://example.com/"},
+		{url: "https://Example.COM/Orders", want: "https://example.com/Orders"},
+		{url: "https://example.com/orders/12345", want: "https://example.com/orders/*"},
+		{url: "https://example.com/orders/12345/items/9", want: "https://example.com/orders/*/items/*"},
+		{url: "https://example.com/doc/3f2a9c1e7b", want: "https://example.com/doc/*"},
+		{url: "https://example.com/u/550e8400-e29b-41d4-a716-446655440000", want: "https://example.com/u/*"},
+		{url: "https://example.com/page/cafe", want: "https://example.com/page/cafe"},
+		{url: "https://example.com/search?q=shoes&page=2", want: "https://example.com/search?page&q"},
+		{url: "https://example.com/search?page=1&q=hats", want: "https://example.com/search?page&q"},
+		{url: "https://example.com/a#section", want: "https://example.com/a"},
+		{url: "about:blank", want: "about:blank"},
+		{url: "not a url", want: "not a url"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			if got := URLPattern(tt.url); got != tt.want {
+				t.Errorf("URLPattern(%q) = %q, want %q", tt.url, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCacheActionSignature(t *testing.T) {
+	tests := []struct {
+		name   string
+		action string
+		params string
+		want   string
+	}{
+		{name: "no action", action: "", params: `{"element_index":1}`, want: ""},
+		{name: "reasoning ignored", action: "click", params: `{"element_index":1,"reasoning":{"thinking":"first try"}}`, want: `click{"element_index":1}`},
+		{name: "keys sorted", action: "type_text", params: `{"text":"a","element_index":2}`, want: `type_text{"element_index":2,"text":"a"}`},
+		{name: "invalid params", action: "reload", params: `not json`, want: "reload"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cacheActionSignature(tt.action, tt.params); got != tt.want {
+				t.Errorf("cacheActionSignature() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	a := cacheActionSignature("click", `{"element_index":1,"reasoning":{"thinking":"a"}}`)
+	b := cacheActionSignature("click", `{"reasoning":{"thinking":"b","next_goal":"c"},"element_index":1}`)
+	if a != b {
+		t.Errorf("signatures differ by reasoning: %q vs %q", a, b)
+	}
+}
+
+func TestActionCacheKey(t *testing.T) {
+	page := func(labels ...string) *dom.ElementMap {
+		m := dom.NewElementMap()
+		for i, label := range labels {
+			m.Add(&dom.Element{Index: i, TagName: "BUTTON", Text: label})
+		}
+		return m
+	}
+	base := ActionCacheKey("https://example.com/orders/1", "Open the order", "", page("Open"))
+
+	tests := []struct {
+		name string
+		key  string
+		same bool
+	}{
+		{name: "same inputs", key: ActionCacheKey("https://example.com/orders/1", "Open the order", "", page("Open")), same: true},
+		{name: "other record", key: ActionCacheKey("https://example.com/orders/2", "Open the order", "", page("Open")), same: true},
+		{name: "task case and spacing", key: ActionCacheKey("https://example.com/orders/1", "  open THE   order ", "", page("Open")), same: true},
+		{name: "other task", key: ActionCacheKey("https://example.com/orders/1", "Close the order", "", page("Open")), same: false},
+		{name: "previous action", key: ActionCacheKey("https://example.com/orders/1", "Open the order", `click{"element_index":0}`, page("Open")), same: false},
+		{name: "other page", key: ActionCacheKey("https://example.com/orders/1", "Open the order", "", page("Open", "Cancel")), same: false},
+		{name: "other path", key: ActionCacheKey("https://example.com/invoices/1", "Open the order", "", page("Open")), same: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.key == base; got != tt.same {
+				t.Errorf("key equal to base = %v, want %v", got, tt.same)
+			}
+		})
+	}
+}
+
+func TestFileActionCache(t *testing.T) {
+	ctx := context.Background()
+	cache, err := NewFileActionCache(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	action := &CachedAction{Key: "abc123", Action: "click", Args: []byte(`{"element_index":1}`), OutcomeURL: "https://example.com"}
+	if err := cache.Put(ctx, action); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	got, err := cache.Get(ctx, "abc123")
+	if err != nil {
+		t.Fatalf("Get: %v", err)
+	}
+	if got.Action != "click" || string(got.Args) != `{"element_index":1}` || got.OutcomeURL != action.OutcomeURL {
+		t.Errorf("Get() = %+v, want %+v", got, action)
+	}
+
+	if err := cache.Delete(ctx, "abc123"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := cache.Get(ctx, "abc123"); !errors.Is(err, ErrCacheMiss) {
+		t.Errorf("Get after Delete = %v, want ErrCacheMiss", err)
+	}
+	if err := cache.Delete(ctx, "abc123"); err != nil {
+		t.Errorf("Delete of a missing key = %v", err)
+	}
+}
+
+func TestFileActionCachePath(t *testing.T) {
+	cache := &FileActionCache{dir: "cache"}
+
+	for _, key := range []string{"", ".", "..", "../escape", "a/b", `a\b`, "/abs"} {
+		if _, err := cache.path(key); err == nil {
+			t.Errorf("path(%q) succeeded, want an error", key)
+		}
+	}
+	if _, err := cache.path("abc123"); err != nil {
+		t.Errorf("path(%q) = %v", "abc123", err)
+	}
+}
@@ -0,0 +1,282 @@
+package agent
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/anxuanzi/bua/browser"
+	"github.com/anxuanzi/bua/dom"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// ErrCheckpointNotFound is returned when no checkpoint exists for a run ID.
+var ErrCheckpointNotFound = errors.New("bua: checkpoint not found")
+
+// Checkpoint is a snapshot of a run taken after every step.
+// It holds everything needed to continue the run in a new process.
+type Checkpoint struct {
+	RunID     string `json:"run_id"`
+	Task      string `json:"task"`
+	SessionID string `json:"session_id"`
+
+	// Events is the ADK session conversation so far. Screenshots are left
+	// out, since the checkpoint is rewritten on every step; they are kept
+	// on disk in ScreenshotPaths.
+	Events []*session.Event `json:"events,omitempty"`
+
+	Steps           []Step        `json:"steps"`
+	History         []HistoryItem `json:"history"`
+	ScreenshotPaths []string      `json:"screenshot_paths,omitempty"`
+	Tables          []dom.Table   `json:"tables,omitempty"` // From the last extract_table call
+	TurnNumber      int           `json:"turn_number"`
+	StepNumber      int           `json:"step_number"`
+	FirstStep       int           `json:"first_step,omitempty"`
+	StepLimit       int           `json:"step_limit,omitempty"`
+	CacheHits       int           `json:"cache_hits,omitempty"`
+	CacheMisses     int           `json:"cache_misses,omitempty"`
+	Plan            *Plan         `json:"plan,omitempty"`
+	Retries         int           `json:"retries,omitempty"`
+
+	// Browser state
+	URL     string                `json:"url"`
+	Tabs    []browser.TabInfo     `json:"tabs,omitempty"`
+	Storage *browser.StorageState `json:"storage,omitempty"`
+
+	// Elapsed is the run time so far, excluding time spent interrupted.
+	Elapsed   time.Duration `json:"elapsed"`
+	UpdatedAt time.Time     `json:"updated_at"`
+
+	// Done is set once the run has finished; finished runs cannot be resumed.
+	Done bool `json:"done"`
+}
+
+// omittedImage stands in for a screenshot left out of a checkpoint.
+const omittedImage = "[screenshot omitted]"
+
+// withoutImages returns the event with its inline images replaced by a
+// note. The event itself is not modified, since it belongs to the session.
+func withoutImages(event *session.Event) *session.Event {
+	if event.Content == nil || !slices.ContainsFunc(event.Content.Parts, isImage) {
+		return event
+	}
+
+	content := *event.Content
+	content.Parts = make([]*genai.Part, len(event.Content.Parts))
+	for i, part := range event.Content.Parts {
+		if isImage(part) {
+			part = &genai.Part{Text: omittedImage}
+		}
+		content.Parts[i] = part
+	}
+
+	copied := *event
+	copied.Content = &content
+	return &copied
+}
+
+// isImage reports whether a part carries inline image data.
+func isImage(part *genai.Part) bool {
+	return part != nil && part.InlineData != nil && strings.HasPrefix(part.InlineData.MIMEType, "image/")
+}
+
+// CheckpointStore persists run checkpoints.
+// Save is called after every step, so implementations should be cheap to overwrite.
+type CheckpointStore interface {
+	// Save stores the checkpoint, replacing any previous one for the same run.
+	Save(ctx context.Context, cp *Checkpoint) error
+
+	// Load returns the latest checkpoint for a run, or ErrCheckpointNotFound.
+	Load(ctx context.Context, runID string) (*Checkpoint, error)
+
+	// Delete removes a run's checkpoint. Deleting a missing run is not an error.
+	Delete(ctx context.Context, runID string) error
+
+	// List returns the IDs of all stored runs.
+	List(ctx context.Context) ([]string, error)
+}
+
+// FileCheckpointStore stores each run as a JSON file in a directory.
+type FileCheckpointStore struct {
+	dir string
+}
+
+// NewFileCheckpointStore creates a file-based checkpoint store, creating dir if needed.
+func NewFileCheckpointStore(dir string) (*FileCheckpointStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create checkpoint directory: %w", err)
+	}
+	return &FileCheckpointStore{dir: dir}, nil
+}
+
+// path returns the file path for a run, rejecting IDs that would escape the directory.
+func (s *FileCheckpointStore) path(runID string) (string, error) {
+	if runID == "" || strings.ContainsAny(runID, `/\`) || runID == "." || runID == ".." {
+		return "", fmt.Errorf("invalid run ID: %q", runID)
+	}
+	return filepath.Join(s.dir, runID+".json"), nil
+}
+
+// Save writes the checkpoint atomically so a crash mid-write keeps the previous one.
+func (s *FileCheckpointStore) Save(ctx context.Context, cp *Checkpoint) error {
+	path, err := s.path(cp.RunID)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to write checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Load reads a run's checkpoint.
+func (s *FileCheckpointStore) Load(ctx context.Context, runID string) (*Checkpoint, error) {
+	path, err := s.path(runID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrCheckpointNotFound, runID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read checkpoint: %w", err)
+	}
+
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("failed to decode checkpoint: %w", err)
+	}
+	return &cp, nil
+}
+
+// Delete removes a run's checkpoint file.
+func (s *FileCheckpointStore) Delete(ctx context.Context, runID string) error {
+	path, err := s.path(runID)
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf("failed to delete checkpoint: %w", err)
+	}
+	return nil
+}
+
+// List returns the stored run IDs in name order.
+func (s *FileCheckpointStore) List(ctx context.Context) ([]string, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checkpoints: %w", err)
+	}
+
+	ids := make([]string, 0, len(entries))
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		ids = append(ids, strings.TrimSuffix(e.Name(), ".json"))
+	}
+	sort.Strings(ids)
+	return ids, nil
+}
+
+// SQLiteCheckpointStore stores checkpoints in a SQLite table.
+// The caller opens the database with the SQLite driver of their choice
+// (e.g. modernc.org/sqlite or github.com/mattn/go-sqlite3).
+type SQLiteCheckpointStore struct {
+	db *sql.DB
+}
+
+// NewSQLiteCheckpointStore creates a SQLite checkpoint store, creating its table if needed.
+func NewSQLiteCheckpointStore(ctx context.Context, db *sql.DB) (*SQLiteCheckpointStore, error) {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS bua_checkpoints (
+		run_id     TEXT PRIMARY KEY,
+		data       BLOB NOT NULL,
+		updated_at INTEGER NOT NULL
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create checkpoint table: %w", err)
+	}
+	return &SQLiteCheckpointStore{db: db}, nil
+}
+
+// Save upserts the checkpoint.
+func (s *SQLiteCheckpointStore) Save(ctx context.Context, cp *Checkpoint) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return fmt.Errorf("failed to encode checkpoint: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `INSERT INTO bua_checkpoints (run_id, data, updated_at) VALUES (?, ?, ?)
+		ON CONFLICT(run_id) DO UPDATE SET data = excluded.data, updated_at = excluded.updated_at`,
+		cp.RunID, data, cp.UpdatedAt.UnixMilli())
+	if err != nil {
+		return fmt.Errorf("failed to save checkpoint: %w", err)
+	}
+	return nil
+}
+
+// Load reads a run's checkpoint.
+func (s *SQLiteCheckpointStore) Load(ctx context.Context, runID string) (*Checkpoint, error) {
+	var data []byte
+	err := s.db.QueryRowContext(ctx, `SELECT data FROM bua_checkpoints WHERE run_id = ?`, runID).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: %s", ErrCheckpointNotFound, runID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load checkpoint: %w", err)
+	}
+
+	var cp Checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return nil, fmt.Errorf("failed to decode checkpoint: %w", err)
+	}
+	return &cp, nil
+}
+
+// Delete removes a run's checkpoint.
+func (s *SQLiteCheckpointStore) Delete(ctx context.Context, runID string) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM bua_checkpoints WHERE run_id = ?`, runID); err != nil {
+		return fmt.Errorf("failed to delete checkpoint: %w", err)
+	}
+	return nil
+}
+
+// List returns the stored run IDs, most recently updated first.
+func (s *SQLiteCheckpointStore) List(ctx context.Context) ([]string, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT run_id FROM bua_checkpoints ORDER BY updated_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list checkpoints: %w", err)
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, fmt.Errorf("failed to list checkpoints: %w", err)
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
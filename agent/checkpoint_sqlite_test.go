@@ -0,0 +1,32 @@
+//go:build sqlite
+
+// The SQLite store tests need a driver, which bua doesn't depend on. Run
+// them with:
+//
+//	go get modernc.org/sqlite && go test -tags sqlite ./agent
+
+package agent
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func TestSQLiteCheckpointStore(t *testing.T) {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+	// Every connection to :memory: is a new database
+	db.SetMaxOpenConns(1)
+
+	store, err := NewSQLiteCheckpointStore(context.Background(), db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCheckpointStore(t, store)
+}
@@ -0,0 +1,215 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// testCheckpointStore runs the CheckpointStore contract against a store.
+func testCheckpointStore(t *testing.T, store CheckpointStore) {
+	t.Helper()
+	ctx := context.Background()
+
+	if _, err := store.Load(ctx, "missing"); !errors.Is(err, ErrCheckpointNotFound) {
+		t.Fatalf("Load of a missing run = %v, want ErrCheckpointNotFound", err)
+	}
+
+	cp := &Checkpoint{
+		RunID:      "run-1",
+		Task:       "Download the invoices",
+		SessionID:  "session-1",
+		Steps:      []Step{{Number: 1, Action: "click", Success: true}},
+		History:    []HistoryItem{{StepNumber: 1, ActionName: "click", ActionSuccess: true}},
+		StepNumber: 1,
+		URL:        "https://example.com",
+		Elapsed:    3 * time.Second,
+		UpdatedAt:  time.UnixMilli(1000),
+	}
+	if err := store.Save(ctx, cp); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+
+	got, err := store.Load(ctx, "run-1")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got.Task != cp.Task || got.SessionID != cp.SessionID || got.URL != cp.URL ||
+		got.Elapsed != cp.Elapsed || len(got.Steps) != 1 || got.Steps[0].Action != "click" ||
+		len(got.History) != 1 || got.Done {
+		t.Errorf("Load() = %+v, want %+v", got, cp)
+	}
+
+	// Saving again replaces the checkpoint
+	cp.StepNumber = 2
+	cp.Done = true
+	cp.UpdatedAt = time.UnixMilli(2000)
+	if err := store.Save(ctx, cp); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	got, err = store.Load(ctx, "run-1")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got.StepNumber != 2 || !got.Done {
+		t.Errorf("Load() after overwrite = step %d, done %v; want 2, true", got.StepNumber, got.Done)
+	}
+
+	if err := store.Save(ctx, &Checkpoint{RunID: "run-2", UpdatedAt: time.UnixMilli(3000)}); err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	ids, err := store.List(ctx)
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	slices.Sort(ids)
+	if !slices.Equal(ids, []string{"run-1", "run-2"}) {
+		t.Errorf("List() = %v, want [run-1 run-2]", ids)
+	}
+
+	if err := store.Delete(ctx, "run-1"); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := store.Load(ctx, "run-1"); !errors.Is(err, ErrCheckpointNotFound) {
+		t.Errorf("Load after Delete = %v, want ErrCheckpointNotFound", err)
+	}
+	if err := store.Delete(ctx, "run-1"); err != nil {
+		t.Errorf("Delete of a missing run = %v", err)
+	}
+}
+
+func TestFileCheckpointStore(t *testing.T) {
+	dir := t.TempDir()
+	store, err := NewFileCheckpointStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	testCheckpointStore(t, store)
+
+	// Saves go through a temporary file that is renamed into place
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if filepath.Ext(e.Name()) != ".json" {
+			t.Errorf("unexpected file left behind: %s", e.Name())
+		}
+	}
+}
+
+func TestFileCheckpointStoreKeepsPreviousOnFailedWrite(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	store, err := NewFileCheckpointStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(ctx, &Checkpoint{RunID: "run-1", StepNumber: 1}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A directory in place of the temporary file makes the next write fail
+	if err := os.Mkdir(filepath.Join(dir, "run-1.json.tmp"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Save(ctx, &Checkpoint{RunID: "run-1", StepNumber: 2}); err == nil {
+		t.Fatal("Save succeeded, want an error")
+	}
+
+	got, err := store.Load(ctx, "run-1")
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	if got.StepNumber != 1 {
+		t.Errorf("StepNumber = %d, want the previous checkpoint's 1", got.StepNumber)
+	}
+}
+
+func TestFileCheckpointStoreRunIDs(t *testing.T) {
+	ctx := context.Background()
+	store, err := NewFileCheckpointStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"", ".", "..", "../escape", "a/b", `a\b`, "/abs"} {
+		if err := store.Save(ctx, &Checkpoint{RunID: id}); err == nil {
+			t.Errorf("Save(%q) succeeded, want an error", id)
+		}
+		if _, err := store.Load(ctx, id); err == nil || errors.Is(err, ErrCheckpointNotFound) {
+			t.Errorf("Load(%q) = %v, want an invalid run ID error", id, err)
+		}
+		if err := store.Delete(ctx, id); err == nil {
+			t.Errorf("Delete(%q) succeeded, want an error", id)
+		}
+	}
+}
+
+func TestWithoutImages(t *testing.T) {
+	image := &genai.Part{InlineData: &genai.Blob{Data: []byte{0xff, 0xd8}, MIMEType: "image/jpeg"}}
+	pdf := &genai.Part{InlineData: &genai.Blob{Data: []byte("%PDF"), MIMEType: "application/pdf"}}
+	text := &genai.Part{Text: "page state"}
+	event := func(parts ...*genai.Part) *session.Event {
+		return &session.Event{
+			ID:          "e1",
+			Author:      "user",
+			LLMResponse: model.LLMResponse{Content: &genai.Content{Role: "user", Parts: parts}},
+		}
+	}
+
+	tests := []struct {
+		name  string
+		event *session.Event
+		want  []string // Text of each part, "" for non-text parts
+		same  bool     // The event is returned unchanged
+	}{
+		{name: "no content", event: &session.Event{ID: "e1"}, same: true},
+		{name: "text only", event: event(text), want: []string{"page state"}, same: true},
+		{name: "other inline data", event: event(text, pdf), want: []string{"page state", ""}, same: true},
+		{name: "screenshot", event: event(text, image), want: []string{"page state", omittedImage}},
+		{name: "only screenshots", event: event(image, image), want: []string{omittedImage, omittedImage}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var before []*genai.Part
+			if tt.event.Content != nil {
+				before = slices.Clone(tt.event.Content.Parts)
+			}
+
+			got := withoutImages(tt.event)
+			if (got == tt.event) != tt.same {
+				t.Fatalf("returned the same event = %v, want %v", got == tt.event, tt.same)
+			}
+			if got.ID != tt.event.ID || got.Author != tt.event.Author {
+				t.Errorf("event fields not kept: %+v", got)
+			}
+			if tt.event.Content == nil {
+				return
+			}
+
+			var texts []string
+			for _, part := range got.Content.Parts {
+				texts = append(texts, part.Text)
+				if part.InlineData != nil && part.InlineData.MIMEType == "image/jpeg" {
+					t.Error("image part left in the event")
+				}
+			}
+			if !slices.Equal(texts, tt.want) {
+				t.Errorf("parts = %q, want %q", texts, tt.want)
+			}
+			if !slices.Equal(tt.event.Content.Parts, before) {
+				t.Error("the session's event was modified")
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package agent
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/anxuanzi/bua/browser"
+	"github.com/anxuanzi/bua/dom"
+	"google.golang.org/adk/tool"
+)
+
+// customCategory is the system prompt category of custom tools that don't
+// name one.
+const customCategory = "custom"
+
+// CustomTool is a user-defined tool offered to the model alongside the
+// browser tools, e.g. a CRM lookup or an OTP fetch. Its calls are recorded
+// as steps like those of the browser tools. Results with success, message
+// and error_code fields are interpreted the same way; an error returned by
+// the tool fails the step.
+type CustomTool struct {
+	// Category is the system prompt tool category the tool is listed under,
+	// e.g. "page_state" (empty = "custom").
+	Category string
+
+	// New creates the tool, typically with functiontool.New. Add a
+	// Reasoning AgentBrain `json:"reasoning,omitempty"` field to the
+	// arguments to record the model's reasoning on the step.
+	New func(env ToolEnv) (tool.Tool, error)
+}
+
+// ToolEnv gives custom tools access to the browser the agent drives.
+type ToolEnv struct {
+	// Browser is the browser of the run. It stays the same across
+	// relaunches.
+	Browser *browser.Browser
+
+	toolkit *BrowserToolkit
+}
+
+// ElementMap returns the elements of the page state the model last saw, so
+// element indices in tool arguments can be resolved. Returns nil before
+// the first page state.
+func (e ToolEnv) ElementMap() *dom.ElementMap {
+	return e.toolkit.GetElementMap()
+}
+
+// createCustomTools creates the custom tools and groups them by prompt
+// category. Names must not clash with each other or the browser tools.
+func (t *BrowserToolkit) createCustomTools(custom []CustomTool, builtin []tool.Tool) ([]tool.Tool, map[string][]tool.Tool, error) {
+	names := make([]string, 0, len(builtin)+len(custom))
+	for _, bt := range builtin {
+		names = append(names, bt.Name())
+	}
+
+	env := ToolEnv{Browser: t.browser, toolkit: t}
+	tools := make([]tool.Tool, 0, len(custom))
+	categories := make(map[string][]tool.Tool)
+	for i, ct := range custom {
+		if ct.New == nil {
+			return nil, nil, fmt.Errorf("custom tool %d has no New function", i)
+		}
+		created, err := ct.New(env)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create custom tool %d: %w", i, err)
+		}
+		if slices.Contains(names, created.Name()) {
+			return nil, nil, fmt.Errorf("custom tool %q clashes with an existing tool", created.Name())
+		}
+		names = append(names, created.Name())
+
+		category := ct.Category
+		if category == "" {
+			category = customCategory
+		}
+		tools = append(tools, created)
+		categories[category] = append(categories[category], created)
+	}
+	return tools, categories, nil
+}
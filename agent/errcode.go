@@ -0,0 +1,53 @@
+package agent
+
+import (
+	"context"
+	"errors"
+
+	"github.com/anxuanzi/bua/browser"
+)
+
+// Error codes reported as error_code in failed tool results and on Step,
+// so the model and callers can tell failures apart without parsing messages.
+const (
+	ErrCodeElementNotFound   = "element_not_found"   // Index not in the current page state
+	ErrCodeElementNotVisible = "element_not_visible" // Element outside the viewport
+	ErrCodeNavigationFailed  = "navigation_failed"   // Page could not be loaded
+	ErrCodeURLBlocked        = "url_blocked"         // Page load rejected by the URL policy
+	ErrCodeTimeout           = "timeout"             // Operation timed out
+	ErrCodeBrowserClosed     = "browser_closed"      // Browser crashed or disconnected
+	ErrCodeNoPageState       = "no_page_state"       // No elements loaded yet
+	ErrCodeInvalidArgument   = "invalid_argument"    // Arguments out of range or malformed
+	ErrCodePermissionDenied  = "permission_denied"   // Tool or arguments not permitted by ToolPermissions
+	ErrCodeCanceled          = "canceled"            // Context canceled by the caller
+	ErrCodeActionFailed      = "action_failed"       // Any other failure
+)
+
+// ErrorCode classifies an error returned by a browser operation.
+// Returns "" for a nil error.
+func ErrorCode(err error) string {
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, browser.ErrBrowserClosed):
+		return ErrCodeBrowserClosed
+	case errors.Is(err, browser.ErrElementNotFound):
+		return ErrCodeElementNotFound
+	case errors.Is(err, browser.ErrElementNotVisible):
+		return ErrCodeElementNotVisible
+	case errors.Is(err, browser.ErrUnknownSecret),
+		errors.Is(err, browser.ErrInvalidArgument),
+		errors.Is(err, browser.ErrTabNotFound):
+		return ErrCodeInvalidArgument
+	case errors.Is(err, browser.ErrURLBlocked):
+		return ErrCodeURLBlocked
+	case errors.Is(err, browser.ErrTimeout), errors.Is(err, context.DeadlineExceeded):
+		return ErrCodeTimeout
+	case errors.Is(err, browser.ErrNavigationFailed):
+		return ErrCodeNavigationFailed
+	case errors.Is(err, context.Canceled):
+		return ErrCodeCanceled
+	default:
+		return ErrCodeActionFailed
+	}
+}
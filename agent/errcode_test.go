@@ -0,0 +1,76 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/anxuanzi/bua/browser"
+)
+
+func TestErrorCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{name: "nil", err: nil, want: ""},
+		{name: "browser closed", err: browser.ErrBrowserClosed, want: ErrCodeBrowserClosed},
+		{name: "wrapped browser closed", err: fmt.Errorf("%w: browser not started", browser.ErrBrowserClosed), want: ErrCodeBrowserClosed},
+		{name: "element not found", err: browser.ErrElementNotFound, want: ErrCodeElementNotFound},
+		{name: "element not visible", err: browser.ErrElementNotVisible, want: ErrCodeElementNotVisible},
+		{name: "unknown secret", err: fmt.Errorf("%w %q", browser.ErrUnknownSecret, "x"), want: ErrCodeInvalidArgument},
+		{name: "invalid argument", err: fmt.Errorf("%w: invalid scroll direction", browser.ErrInvalidArgument), want: ErrCodeInvalidArgument},
+		{name: "tab not found", err: fmt.Errorf("%w: tab-1", browser.ErrTabNotFound), want: ErrCodeInvalidArgument},
+		{name: "URL blocked", err: browser.ErrURLBlocked, want: ErrCodeURLBlocked},
+		{name: "timeout", err: browser.ErrTimeout, want: ErrCodeTimeout},
+		{name: "deadline exceeded", err: fmt.Errorf("click failed: %w", context.DeadlineExceeded), want: ErrCodeTimeout},
+		{name: "navigation failed", err: browser.ErrNavigationFailed, want: ErrCodeNavigationFailed},
+		{name: "canceled", err: fmt.Errorf("click failed: %w", context.Canceled), want: ErrCodeCanceled},
+		{name: "other", err: errors.New("boom"), want: ErrCodeActionFailed},
+		{
+			name: "element error not found",
+			err:  &browser.ElementError{Index: 3, Reason: "not in the current page state", Err: browser.ErrElementNotFound},
+			want: ErrCodeElementNotFound,
+		},
+		{
+			name: "element error not visible",
+			err:  &browser.ElementError{Index: 3, Reason: "outside the viewport", Err: browser.ErrElementNotVisible},
+			want: ErrCodeElementNotVisible,
+		},
+		{
+			name: "element error timeout",
+			err:  &browser.ElementError{Index: 3, Reason: "click failed", Err: fmt.Errorf("%w: %w", browser.ErrTimeout, context.DeadlineExceeded)},
+			want: ErrCodeTimeout,
+		},
+		{
+			name: "element error other",
+			err:  &browser.ElementError{Index: 3, Reason: "click failed", Err: errors.New("detached")},
+			want: ErrCodeActionFailed,
+		},
+		{
+			name: "navigation error",
+			err:  &browser.NavigationError{URL: "https://example.com", NetErr: "net::ERR_NAME_NOT_RESOLVED"},
+			want: ErrCodeNavigationFailed,
+		},
+		{
+			name: "navigation error timeout",
+			err:  &browser.NavigationError{URL: "https://example.com", Err: fmt.Errorf("%w: %w", browser.ErrTimeout, context.DeadlineExceeded)},
+			want: ErrCodeTimeout,
+		},
+		{
+			name: "policy error",
+			err:  &browser.PolicyError{URL: "https://evil.net", Reason: "domain evil.net is blocked"},
+			want: ErrCodeURLBlocked,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ErrorCode(tt.err); got != tt.want {
+				t.Errorf("ErrorCode(%v) = %q, want %q", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,605 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/anxuanzi/bua/browser"
+	"github.com/anxuanzi/bua/dom"
+)
+
+// rodKeys maps send_keys names to go-rod input key constants.
+var rodKeys = map[string]string{
+	"Enter":      "input.Enter",
+	"Escape":     "input.Escape",
+	"Tab":        "input.Tab",
+	"Backspace":  "input.Backspace",
+	"Delete":     "input.Delete",
+	"ArrowUp":    "input.ArrowUp",
+	"ArrowDown":  "input.ArrowDown",
+	"ArrowLeft":  "input.ArrowLeft",
+	"ArrowRight": "input.ArrowRight",
+	"Home":       "input.Home",
+	"End":        "input.End",
+	"PageUp":     "input.PageUp",
+	"PageDown":   "input.PageDown",
+	"Space":      "input.Space",
+}
+
+// scriptCode accumulates generated source lines with indentation.
+type scriptCode struct {
+	sb     strings.Builder
+	indent string
+}
+
+func (c *scriptCode) line(format string, args ...any) {
+	if format == "" {
+		c.sb.WriteString("\n")
+		return
+	}
+	c.sb.WriteString(c.indent)
+	c.sb.WriteString(fmt.Sprintf(format, args...))
+	c.sb.WriteString("\n")
+}
+
+// ExportGoTest generates a go-rod Go test that performs the recorded steps
+// with concrete selectors and asserts the final URL. testName is the test
+// function name, with or without the "Test" prefix.
+func (s *Script) ExportGoTest(pkg, testName string) string {
+	if pkg == "" {
+		pkg = "e2e"
+	}
+	name := exportIdentifier(testName)
+	if !strings.HasPrefix(name, "Test") {
+		name = "Test" + name
+	}
+
+	body := &scriptCode{indent: "\t"}
+	imports := map[string]bool{"testing": true, "github.com/go-rod/rod": true}
+	tabs := map[string]string{}
+	tabCount := 0
+
+	body.line("browser := rod.New().MustConnect()")
+	body.line("defer browser.MustClose()")
+	body.line("")
+	body.line("page := browser.MustPage(%s).MustWaitLoad()", strconv.Quote(s.startURL()))
+	if s.usesTabs() {
+		body.line("firstPage := page")
+	}
+
+	for i, step := range s.Steps {
+		body.line("")
+		body.line("// Step %d: %s", i+1, commentText(describeScriptStep(step)))
+
+		el := ""
+		if step.Locator != nil {
+			el = rodElement(step.Locator)
+		} else if isElementAction(step.Action) {
+			body.line("// %s target was not recorded, skipped", step.Action)
+			continue
+		}
+
+		switch step.Action {
+		case "navigate":
+			var args NavigateArgs
+			_ = json.Unmarshal(step.Args, &args)
+			body.line("page.MustNavigate(%s).MustWaitLoad()", strconv.Quote(args.URL))
+		case "go_back":
+			body.line("page.MustNavigateBack()")
+		case "go_forward":
+			body.line("page.MustNavigateForward()")
+		case "reload":
+			body.line("page.MustReload()")
+		case "click":
+			body.line("%s.MustClick()", el)
+		case "double_click":
+			body.line("%s.MustDoubleClick()", el)
+		case "hover":
+			body.line("%s.MustHover()", el)
+		case "focus":
+			body.line("%s.MustFocus()", el)
+		case "scroll_to_element":
+			body.line("%s.MustScrollIntoView()", el)
+		case "type_text", "clear_and_type":
+			var args TypeTextArgs
+			_ = json.Unmarshal(step.Args, &args)
+			text, usesEnv := typedText(args.Text, strconv.Quote, func(name string) string {
+				return fmt.Sprintf("os.Getenv(%s)", strconv.Quote(name))
+			})
+			if usesEnv {
+				imports["os"] = true
+			}
+			body.line("%s.MustSelectAllText().MustInput(%s)", el, text)
+		case "send_keys":
+			var args SendKeysArgs
+			_ = json.Unmarshal(step.Args, &args)
+			if key, ok := rodKeys[args.Keys]; ok {
+				imports["github.com/go-rod/rod/lib/input"] = true
+				body.line("page.Keyboard.MustType(%s)", key)
+			} else {
+				body.line("page.MustInsertText(%s)", strconv.Quote(args.Keys))
+			}
+		case "scroll":
+			var args ScrollArgs
+			_ = json.Unmarshal(step.Args, &args)
+			x, y := scrollDelta(args)
+			body.line("page.Mouse.MustScroll(%g, %g)", x, y)
+		case "wait":
+			var args WaitArgs
+			_ = json.Unmarshal(step.Args, &args)
+			imports["time"] = true
+			body.line("time.Sleep(%d * time.Millisecond)", waitMs(args))
+		case "wait_for":
+			var args WaitForArgs
+			_ = json.Unmarshal(step.Args, &args)
+			imports["time"] = true
+			waitPage := fmt.Sprintf("page.Timeout(%d * time.Millisecond)", args.timeout().Milliseconds())
+			switch args.Condition {
+			case WaitNetworkIdle:
+				body.line("%s.MustWaitRequestIdle()()", waitPage)
+			case WaitDOMQuiet:
+				body.line("%s.MustWaitDOMStable()", waitPage)
+			default:
+				js, param := waitForPredicate(args)
+				body.line("%s.MustWait(%s, %s)", waitPage, strconv.Quote(js), strconv.Quote(param))
+			}
+		case "evaluate_js":
+			var args EvaluateJSArgs
+			_ = json.Unmarshal(step.Args, &args)
+			body.line("page.MustEval(%s)", strconv.Quote(wrapScript(args.Script)))
+		case "new_tab":
+			var args NewTabArgs
+			_ = json.Unmarshal(step.Args, &args)
+			tabCount++
+			tabVar := fmt.Sprintf("tab%d", tabCount)
+			if id := recordedTabID(step); id != "" {
+				tabs[id] = tabVar
+			}
+			body.line("%s := browser.MustPage(%s).MustWaitLoad()", tabVar, strconv.Quote(args.URL))
+			body.line("page = %s", tabVar)
+		case "switch_tab":
+			var args SwitchTabArgs
+			_ = json.Unmarshal(step.Args, &args)
+			body.line("page = %s.MustActivate()", tabVariable(tabs, args.TabID, "firstPage"))
+		case "close_tab":
+			var args CloseTabArgs
+			_ = json.Unmarshal(step.Args, &args)
+			tabVar := tabVariable(tabs, args.TabID, "firstPage")
+			body.line("%s.MustClose()", tabVar)
+			body.line("if page == %s {", tabVar)
+			body.line("\tpage = firstPage")
+			body.line("}")
+		default:
+			body.line("// %s is not exported", step.Action)
+			continue
+		}
+
+		if isPageChangingAction(step.Action) {
+			body.line("page.MustWaitStable()")
+		}
+	}
+
+	body.line("")
+	if s.FinalURL != "" {
+		body.line("if got, want := page.MustInfo().URL, %s; got != want {", strconv.Quote(s.FinalURL))
+		body.line("\tt.Errorf(\"final URL = %%q, want %%q\", got, want)")
+		body.line("}")
+	}
+
+	out := &scriptCode{}
+	out.line("// Code generated from a recorded bua run. Review before committing.")
+	out.line("")
+	out.line("package %s", pkg)
+	out.line("")
+	out.line("import (")
+	for _, imp := range []string{"os", "testing", "time", "", "github.com/go-rod/rod", "github.com/go-rod/rod/lib/input"} {
+		if imp == "" {
+			out.line("")
+			continue
+		}
+		if imports[imp] {
+			out.line("\t%s", strconv.Quote(imp))
+		}
+	}
+	out.line(")")
+	out.line("")
+	if s.Task != "" {
+		out.line("// %s replays the task: %s", name, commentText(s.Task))
+	}
+	out.line("func %s(t *testing.T) {", name)
+	out.sb.WriteString(body.sb.String())
+	out.line("}")
+	return out.sb.String()
+}
+
+// ExportPlaywright generates a Playwright TypeScript test that performs the
+// recorded steps with role, text or CSS locators and asserts the final URL.
+func (s *Script) ExportPlaywright(testName string) string {
+	if testName == "" {
+		testName = s.Task
+	}
+
+	body := &scriptCode{indent: "  "}
+	tabs := map[string]string{}
+	tabCount := 0
+
+	if s.usesTabs() {
+		body.line("let page = firstPage;")
+	}
+	body.line("await page.goto(%s);", jsString(s.startURL()))
+
+	for i, step := range s.Steps {
+		body.line("")
+		body.line("// Step %d: %s", i+1, commentText(describeScriptStep(step)))
+
+		el := ""
+		if step.Locator != nil {
+			el = playwrightLocator(step.Locator)
+		} else if isElementAction(step.Action) {
+			body.line("// %s target was not recorded, skipped", step.Action)
+			continue
+		}
+
+		switch step.Action {
+		case "navigate":
+			var args NavigateArgs
+			_ = json.Unmarshal(step.Args, &args)
+			body.line("await page.goto(%s);", jsString(args.URL))
+		case "go_back":
+			body.line("await page.goBack();")
+		case "go_forward":
+			body.line("await page.goForward();")
+		case "reload":
+			body.line("await page.reload();")
+		case "click":
+			body.line("await %s.click();", el)
+		case "double_click":
+			body.line("await %s.dblclick();", el)
+		case "hover":
+			body.line("await %s.hover();", el)
+		case "focus":
+			body.line("await %s.focus();", el)
+		case "scroll_to_element":
+			body.line("await %s.scrollIntoViewIfNeeded();", el)
+		case "type_text", "clear_and_type":
+			var args TypeTextArgs
+			_ = json.Unmarshal(step.Args, &args)
+			text, _ := typedText(args.Text, jsString, func(name string) string {
+				return "process.env." + name + "!"
+			})
+			body.line("await %s.fill(%s);", el, text)
+		case "send_keys":
+			var args SendKeysArgs
+			_ = json.Unmarshal(step.Args, &args)
+			if _, ok := rodKeys[args.Keys]; ok {
+				key := args.Keys
+				if key == "Space" {
+					key = " "
+				}
+				body.line("await page.keyboard.press(%s);", jsString(key))
+			} else {
+				body.line("await page.keyboard.insertText(%s);", jsString(args.Keys))
+			}
+		case "scroll":
+			var args ScrollArgs
+			_ = json.Unmarshal(step.Args, &args)
+			x, y := scrollDelta(args)
+			body.line("await page.mouse.wheel(%g, %g);", x, y)
+		case "wait":
+			var args WaitArgs
+			_ = json.Unmarshal(step.Args, &args)
+			body.line("await page.waitForTimeout(%d);", waitMs(args))
+		case "wait_for":
+			var args WaitForArgs
+			_ = json.Unmarshal(step.Args, &args)
+			timeout := args.timeout().Milliseconds()
+			state := "visible"
+			if args.Gone {
+				state = "hidden"
+			}
+			switch args.Condition {
+			case WaitNetworkIdle:
+				body.line("await page.waitForLoadState('networkidle', { timeout: %d });", timeout)
+			case WaitDOMQuiet:
+				body.line("await page.waitForTimeout(%d);", args.quiet().Milliseconds())
+			case WaitSelector:
+				body.line("await page.waitForSelector(%s, { state: '%s', timeout: %d });", jsString(args.Value), state, timeout)
+			case WaitText:
+				body.line("await page.getByText(%s).first().waitFor({ state: '%s', timeout: %d });", jsString(args.Value), state, timeout)
+			case WaitURL:
+				body.line("await page.waitForURL(new RegExp(%s), { timeout: %d });", jsString(urlPatternRegexp(args.Value)), timeout)
+			}
+		case "evaluate_js":
+			var args EvaluateJSArgs
+			_ = json.Unmarshal(step.Args, &args)
+			body.line("await page.evaluate(%s);", wrapScript(args.Script))
+		case "new_tab":
+			var args NewTabArgs
+			_ = json.Unmarshal(step.Args, &args)
+			tabCount++
+			tabVar := fmt.Sprintf("tab%d", tabCount)
+			if id := recordedTabID(step); id != "" {
+				tabs[id] = tabVar
+			}
+			body.line("const %s = await context.newPage();", tabVar)
+			if args.URL != "" {
+				body.line("await %s.goto(%s);", tabVar, jsString(args.URL))
+			}
+			body.line("page = %s;", tabVar)
+		case "switch_tab":
+			var args SwitchTabArgs
+			_ = json.Unmarshal(step.Args, &args)
+			body.line("page = %s;", tabVariable(tabs, args.TabID, "firstPage"))
+			body.line("await page.bringToFront();")
+		case "close_tab":
+			var args CloseTabArgs
+			_ = json.Unmarshal(step.Args, &args)
+			tabVar := tabVariable(tabs, args.TabID, "firstPage")
+			body.line("await %s.close();", tabVar)
+			body.line("if (page === %s) page = firstPage;", tabVar)
+		default:
+			body.line("// %s is not exported", step.Action)
+			continue
+		}
+
+		if isPageChangingAction(step.Action) {
+			body.line("await page.waitForLoadState();")
+		}
+	}
+
+	if s.FinalURL != "" {
+		body.line("")
+		body.line("await expect(page).toHaveURL(%s);", jsString(s.FinalURL))
+	}
+
+	out := &scriptCode{}
+	out.line("// Code generated from a recorded bua run. Review before committing.")
+	out.line("import { test, expect } from '@playwright/test';")
+	out.line("")
+	if s.usesTabs() {
+		out.line("test(%s, async ({ page: firstPage, context }) => {", jsString(testName))
+	} else {
+		out.line("test(%s, async ({ page }) => {", jsString(testName))
+	}
+	out.sb.WriteString(body.sb.String())
+	out.line("});")
+	return out.sb.String()
+}
+
+// typedText returns an expression for typed text. Secret placeholders are
+// read from BUA_SECRET_<NAME> environment variables, so the exported test
+// types the real value without it being written into the file. env returns
+// the expression reading a variable; usesEnv reports whether it was used.
+func typedText(text string, quote, env func(string) string) (expr string, usesEnv bool) {
+	var parts []string
+	last := 0
+	for _, loc := range browser.FindSecretPlaceholders(text) {
+		if loc[0] > last {
+			parts = append(parts, quote(text[last:loc[0]]))
+		}
+		parts = append(parts, env(secretEnvVar(text[loc[2]:loc[3]])))
+		last = loc[1]
+	}
+	if last == 0 {
+		return quote(text), false
+	}
+	if last < len(text) {
+		parts = append(parts, quote(text[last:]))
+	}
+	return strings.Join(parts, " + "), true
+}
+
+// secretEnvVar returns the environment variable an exported test reads a
+// secret from, e.g. BUA_SECRET_GITHUB_PASSWORD for github_password.
+func secretEnvVar(name string) string {
+	var sb strings.Builder
+	sb.WriteString("BUA_SECRET_")
+	for _, r := range name {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			sb.WriteRune(unicode.ToUpper(r))
+		} else {
+			sb.WriteRune('_')
+		}
+	}
+	return sb.String()
+}
+
+// startURL returns the URL the exported test opens first.
+func (s *Script) startURL() string {
+	if s.StartURL == "" {
+		return "about:blank"
+	}
+	return s.StartURL
+}
+
+// usesTabs reports whether the script opens, switches or closes tabs.
+func (s *Script) usesTabs() bool {
+	for _, step := range s.Steps {
+		switch step.Action {
+		case "new_tab", "switch_tab", "close_tab":
+			return true
+		}
+	}
+	return false
+}
+
+// isElementAction reports whether an action targets an indexed element.
+func isElementAction(action string) bool {
+	switch action {
+	case "click", "double_click", "hover", "focus", "scroll_to_element", "type_text", "clear_and_type":
+		return true
+	}
+	return false
+}
+
+// rodElement returns a go-rod expression that finds the located element.
+func rodElement(l *dom.Locator) string {
+	switch {
+	case l.Selector != "" && l.Selector != l.TagName:
+		return fmt.Sprintf("page.MustElement(%s)", strconv.Quote(l.Selector))
+	case l.AriaLabel != "":
+		return fmt.Sprintf("page.MustElement(%s)", strconv.Quote(fmt.Sprintf("%s[aria-label=%s]", l.TagName, strconv.Quote(l.AriaLabel))))
+	case l.Placeholder != "":
+		return fmt.Sprintf("page.MustElement(%s)", strconv.Quote(fmt.Sprintf("%s[placeholder=%s]", l.TagName, strconv.Quote(l.Placeholder))))
+	case l.Text != "":
+		return fmt.Sprintf("page.MustElementR(%s, %s)", strconv.Quote(l.TagName), strconv.Quote("^"+regexp.QuoteMeta(strings.TrimSpace(l.Text))+"$"))
+	default:
+		return fmt.Sprintf("page.MustElement(%s)", strconv.Quote(l.TagName))
+	}
+}
+
+// playwrightLocator returns a Playwright locator expression, preferring
+// user-facing locators (role, label, placeholder, text) over CSS.
+func playwrightLocator(l *dom.Locator) string {
+	name := l.AriaLabel
+	if name == "" {
+		name = l.Name
+	}
+	switch {
+	case l.Role != "" && name != "":
+		return fmt.Sprintf("page.getByRole(%s, { name: %s, exact: true })", jsString(l.Role), jsString(name))
+	case l.Placeholder != "":
+		return fmt.Sprintf("page.getByPlaceholder(%s, { exact: true })", jsString(l.Placeholder))
+	case l.Selector != "" && l.Selector != l.TagName:
+		return fmt.Sprintf("page.locator(%s)", jsString(l.Selector))
+	case l.Text != "":
+		return fmt.Sprintf("page.getByText(%s, { exact: true })", jsString(strings.TrimSpace(l.Text)))
+	default:
+		return fmt.Sprintf("page.locator(%s).first()", jsString(l.TagName))
+	}
+}
+
+// recordedTabID returns the tab ID from a recorded new_tab result.
+func recordedTabID(step ScriptStep) string {
+	var recorded NewTabResult
+	if json.Unmarshal([]byte(step.Result), &recorded) != nil {
+		return ""
+	}
+	return recorded.TabID
+}
+
+// tabVariable maps a recorded tab ID to its variable. Tabs not opened during
+// the run are assumed to be the first tab.
+func tabVariable(tabs map[string]string, tabID, fallback string) string {
+	if v, ok := tabs[tabID]; ok {
+		return v
+	}
+	return fallback
+}
+
+// isPageChangingAction reports whether an action may trigger navigation.
+func isPageChangingAction(action string) bool {
+	switch action {
+	case "click", "double_click", "send_keys", "go_back", "go_forward", "reload":
+		return true
+	}
+	return false
+}
+
+// scrollDelta converts scroll arguments to x/y pixel deltas.
+func scrollDelta(args ScrollArgs) (float64, float64) {
+	amount := float64(args.Amount)
+	if amount == 0 {
+		amount = 300
+	}
+	switch args.Direction {
+	case "up":
+		return 0, -amount
+	case "left":
+		return -amount, 0
+	case "right":
+		return amount, 0
+	default:
+		return 0, amount
+	}
+}
+
+// waitMs returns the clamped wait duration in milliseconds.
+func waitMs(args WaitArgs) int {
+	ms := args.DurationMs
+	if ms <= 0 {
+		ms = 1000
+	}
+	return min(ms, 10000)
+}
+
+// waitForPredicate returns a JS predicate and its argument for a selector,
+// text or url wait_for condition.
+func waitForPredicate(args WaitForArgs) (string, string) {
+	switch args.Condition {
+	case WaitSelector:
+		if args.Gone {
+			return "(s) => !document.querySelector(s)", args.Value
+		}
+		return "(s) => !!document.querySelector(s)", args.Value
+	case WaitText:
+		if args.Gone {
+			return "(t) => !document.body.innerText.includes(t)", args.Value
+		}
+		return "(t) => document.body.innerText.includes(t)", args.Value
+	default:
+		return "(re) => new RegExp(re).test(location.href)", urlPatternRegexp(args.Value)
+	}
+}
+
+// urlPatternRegexp converts a wait_for URL pattern, a substring or a glob
+// with *, to a regular expression.
+func urlPatternRegexp(pattern string) string {
+	if !strings.Contains(pattern, "*") {
+		return regexp.QuoteMeta(pattern)
+	}
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	return "^" + strings.Join(parts, ".*") + "$"
+}
+
+// wrapScript wraps a script body in an arrow function, as EvaluateJS does.
+func wrapScript(script string) string {
+	if len(script) > 0 && script[0] != '(' {
+		return fmt.Sprintf("() => { %s }", script)
+	}
+	return script
+}
+
+// jsString quotes s as a JavaScript string literal.
+func jsString(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+// commentText flattens text for a single-line comment.
+func commentText(s string) string {
+	s = strings.Join(strings.Fields(s), " ")
+	if len(s) > 120 {
+		s = s[:120] + "..."
+	}
+	return s
+}
+
+// exportIdentifier converts a name to an exported Go identifier.
+func exportIdentifier(name string) string {
+	var sb strings.Builder
+	upper := true
+	for _, r := range name {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			upper = true
+			continue
+		}
+		if upper {
+			r = unicode.ToUpper(r)
+			upper = false
+		}
+		sb.WriteRune(r)
+	}
+	id := sb.String()
+	if id == "" || unicode.IsDigit(rune(id[0])) {
+		id = "Recorded" + id
+	}
+	return id
+}
@@ -0,0 +1,121 @@
+package agent
+
+import (
+	"encoding/json"
+	"flag"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/anxuanzi/bua/dom"
+)
+
+var update = flag.Bool("update", false, "rewrite golden files")
+
+// exportScript is a small recorded login run used by the export tests.
+func exportScript() *Script {
+	args := func(v any) json.RawMessage {
+		data, _ := json.Marshal(v)
+		return data
+	}
+	return &Script{
+		Version:  1,
+		Task:     "Log in and open the settings",
+		StartURL: "https://example.com/login",
+		FinalURL: "https://example.com/settings",
+		Steps: []ScriptStep{
+			{
+				Action:  "type_text",
+				Args:    args(TypeTextArgs{Text: "alice"}),
+				Locator: &dom.Locator{TagName: "input", Selector: "#user", Placeholder: "Username"},
+			},
+			{
+				Action:  "clear_and_type",
+				Args:    args(TypeTextArgs{Text: "<secret>github_password</secret>"}),
+				Locator: &dom.Locator{TagName: "input", Selector: "#password", Type: "password"},
+			},
+			{
+				Action:  "type_text",
+				Args:    args(TypeTextArgs{Text: "otp-<secret>otp.code</secret>!"}),
+				Locator: &dom.Locator{TagName: "input", Selector: "#otp"},
+			},
+			{
+				Action:  "click",
+				Locator: &dom.Locator{TagName: "button", Role: "button", Name: "Log in", Text: "Log in"},
+			},
+			{Action: "send_keys", Args: args(SendKeysArgs{Keys: "Enter"})},
+			{Action: "wait", Args: args(WaitArgs{DurationMs: 500})},
+			{Action: "navigate", Args: args(NavigateArgs{URL: "https://example.com/settings"})},
+			{Action: "type_text", Args: args(TypeTextArgs{Text: "lost"})},
+		},
+	}
+}
+
+// checkGolden compares got with a file in testdata, rewriting it with -update.
+func checkGolden(t *testing.T, name, got string) {
+	t.Helper()
+	path := filepath.Join("testdata", name)
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read golden file: %v (run with -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s mismatch (run with -update to accept)\ngot:\n%s\nwant:\n%s", name, got, want)
+	}
+}
+
+func TestExportGoTest(t *testing.T) {
+	got := exportScript().ExportGoTest("e2e", "log in")
+	checkGolden(t, "export_go.golden", got)
+
+	if _, err := parser.ParseFile(token.NewFileSet(), "login_test.go", got, parser.AllErrors); err != nil {
+		t.Errorf("generated Go does not parse: %v", err)
+	}
+	if strings.Contains(got, "<secret>") {
+		t.Error("generated Go contains a secret placeholder")
+	}
+}
+
+func TestExportPlaywright(t *testing.T) {
+	got := exportScript().ExportPlaywright("")
+	checkGolden(t, "export_playwright.golden", got)
+
+	if strings.Contains(got, "<secret>") {
+		t.Error("generated Playwright test contains a secret placeholder")
+	}
+}
+
+func TestTypedText(t *testing.T) {
+	env := func(name string) string { return "env(" + name + ")" }
+	quote := func(s string) string { return "'" + s + "'" }
+
+	tests := []struct {
+		text    string
+		want    string
+		usesEnv bool
+	}{
+		{text: "plain", want: "'plain'"},
+		{text: "", want: "''"},
+		{text: "<secret>pw</secret>", want: "env(BUA_SECRET_PW)", usesEnv: true},
+		{text: "a<secret>pw</secret>b", want: "'a' + env(BUA_SECRET_PW) + 'b'", usesEnv: true},
+		{text: "<secret>user</secret>:<secret>pw</secret>", want: "env(BUA_SECRET_USER) + ':' + env(BUA_SECRET_PW)", usesEnv: true},
+		{text: "<secret>github-token.v2</secret>", want: "env(BUA_SECRET_GITHUB_TOKEN_V2)", usesEnv: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			got, usesEnv := typedText(tt.text, quote, env)
+			if got != tt.want || usesEnv != tt.usesEnv {
+				t.Errorf("typedText(%q) = %q, %v; want %q, %v", tt.text, got, usesEnv, tt.want, tt.usesEnv)
+			}
+		})
+	}
+}
@@ -77,6 +77,18 @@ func (h *AgentHistory) UpdateLastItem(result string, success bool) {
 	h.items[len(h.items)-1].ActionSuccess = success
 }
 
+// UpdateItem records the outcome and duration of the item for a step.
+func (h *AgentHistory) UpdateItem(stepNumber int, result string, success bool, durationMs int64) {
+	for i := len(h.items) - 1; i >= 0; i-- {
+		if h.items[i].StepNumber == stepNumber {
+			h.items[i].ActionResult = result
+			h.items[i].ActionSuccess = success
+			h.items[i].DurationMs = durationMs
+			return
+		}
+	}
+}
+
 // GetCurrentMemory returns the accumulated memory from history.
 func (h *AgentHistory) GetCurrentMemory() string {
 	return h.currentMemory
@@ -2,10 +2,13 @@ package agent
 
 import (
 	"fmt"
+	"maps"
 	"regexp"
+	"slices"
 	"strings"
 
 	"github.com/anxuanzi/bua/dom"
+	"google.golang.org/adk/tool"
 )
 
 // MessageManager handles conversation state and message construction for the LLM.
@@ -14,14 +17,34 @@ type MessageManager struct {
 	history         *AgentHistory
 	sensitiveFilter *SensitiveDataFilter
 	maxElements     int
+	maxTextChars    int
 	useVision       bool
+
+	// lastElementMap is the element map sent in the previous message,
+	// used to highlight what changed between steps.
+	lastElementMap *dom.ElementMap
+
+	// plan is the planner's checklist, included in every message when set.
+	plan *Plan
 }
 
 // MessageManagerConfig configures the message manager.
 type MessageManagerConfig struct {
 	MaxHistoryItems int
 	MaxElements     int
+	MaxTextChars    int // Page text budget (0 = default, negative = disabled)
 	UseVision       bool
+	MultiAction     bool     // Allow several actions per turn
+	Tools           []string // Names of the tools offered to the model (nil = all)
+
+	// CustomTools are user-defined tools to list in the system prompt,
+	// by tool category
+	CustomTools map[string][]tool.Tool
+
+	// Secrets are the names of the secrets the model may type as
+	// placeholders; ScrubSecrets replaces their values in messages
+	Secrets      []string
+	ScrubSecrets func(string) string
 }
 
 // NewMessageManager creates a new message manager.
@@ -36,11 +59,36 @@ func NewMessageManager(cfg MessageManagerConfig) *MessageManager {
 		maxElements = 100
 	}
 
+	maxTextChars := cfg.MaxTextChars
+	if maxTextChars == 0 {
+		maxTextChars = 2000
+	} else if maxTextChars < 0 {
+		maxTextChars = 0
+	}
+
+	systemPrompt := SystemPrompt()
+	if cfg.MultiAction {
+		systemPrompt = MultiActionSystemPrompt()
+	}
+	for _, category := range slices.Sorted(maps.Keys(cfg.CustomTools)) {
+		systemPrompt = AddToolPrompt(systemPrompt, category, cfg.CustomTools[category])
+	}
+	if cfg.Tools != nil {
+		systemPrompt = RestrictToolPrompt(systemPrompt, cfg.Tools)
+	}
+	if len(cfg.Secrets) > 0 {
+		systemPrompt += "\n\n" + BuildSecretsPrompt(cfg.Secrets)
+	}
+
+	sensitiveFilter := NewSensitiveDataFilter()
+	sensitiveFilter.scrubSecrets = cfg.ScrubSecrets
+
 	return &MessageManager{
-		systemPrompt:    SystemPrompt(),
+		systemPrompt:    systemPrompt,
 		history:         NewAgentHistory(maxHistory),
-		sensitiveFilter: NewSensitiveDataFilter(),
+		sensitiveFilter: sensitiveFilter,
 		maxElements:     maxElements,
+		maxTextChars:    maxTextChars,
 		useVision:       cfg.UseVision,
 	}
 }
@@ -60,24 +108,56 @@ func (m *MessageManager) AddHistoryItem(item HistoryItem) {
 	m.history.AddItem(item)
 }
 
+// SetPlan sets the planner checklist shown to the executor (nil = no plan).
+func (m *MessageManager) SetPlan(plan *Plan) {
+	m.plan = plan
+}
+
 // GetHistory returns the agent history.
 func (m *MessageManager) GetHistory() *AgentHistory {
 	return m.history
 }
 
+// buildPageState renders the page state block for an element map.
+// When the page is the same as in the previous message, new elements are
+// marked and an unchanged element list is replaced by a short note. The note
+// is only used when every index still refers to the same element, since the
+// diff alone doesn't notice reordered elements.
+func (m *MessageManager) buildPageState(elementMap *dom.ElementMap, screenshotIncluded bool) string {
+	diff := dom.Diff(m.lastElementMap, elementMap)
+	sameIndices := dom.SameIndices(m.lastElementMap, elementMap)
+	m.lastElementMap = elementMap
+
+	var elementsText string
+	if diff != nil && diff.IsEmpty() && sameIndices {
+		elementsText = fmt.Sprintf("Unchanged since last step (%d elements, same indices as the previous page state). Use get_page_state for the full list.", elementMap.Len())
+	} else {
+		opts := dom.DefaultSerializeOptions()
+		opts.MaxElements = m.maxElements
+		opts.MaxTextChars = m.maxTextChars
+		opts.Diff = diff
+		elementsText = elementMap.ToTokenString(opts)
+		if changes := diff.ToTokenString(10); changes != "" {
+			elementsText += "\n" + changes + "\n(* marks elements that are new since last step)"
+		}
+	}
+
+	return BuildPageStatePrompt(
+		elementMap.PageURL,
+		elementMap.PageTitle,
+		elementMap.ToScrollContextString(),
+		elementsText,
+		screenshotIncluded,
+	)
+}
+
 // BuildStateMessage builds the current state message for the LLM.
 func (m *MessageManager) BuildStateMessage(elementMap *dom.ElementMap, lastActionResult string, screenshotIncluded bool) string {
 	var sb strings.Builder
 
 	// Add current page state
 	if elementMap != nil {
-		pageState := BuildPageStatePrompt(
-			elementMap.PageURL,
-			elementMap.PageTitle,
-			elementMap.ToTokenStringLimited(m.maxElements),
-			screenshotIncluded,
-		)
-		sb.WriteString(pageState)
+		sb.WriteString(m.buildPageState(elementMap, screenshotIncluded))
 		sb.WriteString("\n\n")
 	}
 
@@ -87,6 +167,12 @@ func (m *MessageManager) BuildStateMessage(elementMap *dom.ElementMap, lastActio
 		sb.WriteString("\n\n")
 	}
 
+	// Add the planner checklist
+	if plan := m.plan.ToDescription(); plan != "" {
+		sb.WriteString(plan)
+		sb.WriteString("\n\n")
+	}
+
 	// Add last action result if provided
 	if lastActionResult != "" {
 		sb.WriteString("<last_action_result>\n")
@@ -116,13 +202,35 @@ func (m *MessageManager) BuildInitialTaskMessage(task string, elementMap *dom.El
 
 	// Add initial page state if available
 	if elementMap != nil {
-		pageState := BuildPageStatePrompt(
-			elementMap.PageURL,
-			elementMap.PageTitle,
-			elementMap.ToTokenStringLimited(m.maxElements),
-			false,
-		)
-		sb.WriteString(pageState)
+		sb.WriteString(m.buildPageState(elementMap, false))
+	}
+
+	if plan := m.plan.ToDescription(); plan != "" {
+		sb.WriteString("\n\n")
+		sb.WriteString(plan)
+	}
+
+	return sb.String()
+}
+
+// BuildFollowUpTaskMessage builds the first message of a follow-up task
+// in a conversation that already has history.
+func (m *MessageManager) BuildFollowUpTaskMessage(task string, elementMap *dom.ElementMap) string {
+	var sb strings.Builder
+
+	sb.WriteString(BuildFollowUpTaskPrompt(task))
+	sb.WriteString("\n\n")
+
+	if elementMap != nil {
+		sb.WriteString(m.buildPageState(elementMap, false))
+		sb.WriteString("\n\n")
+	}
+
+	sb.WriteString(m.history.ToDescription())
+
+	if plan := m.plan.ToDescription(); plan != "" {
+		sb.WriteString("\n\n")
+		sb.WriteString(plan)
 	}
 
 	return sb.String()
@@ -151,13 +259,7 @@ func (m *MessageManager) BuildErrorRecoveryMessage(elementMap *dom.ElementMap, e
 
 	// Add page state
 	if elementMap != nil {
-		pageState := BuildPageStatePrompt(
-			elementMap.PageURL,
-			elementMap.PageTitle,
-			elementMap.ToTokenStringLimited(m.maxElements),
-			false,
-		)
-		sb.WriteString(pageState)
+		sb.WriteString(m.buildPageState(elementMap, false))
 		sb.WriteString("\n\n")
 	}
 
@@ -175,11 +277,16 @@ func (m *MessageManager) FilterSensitiveData(message string) string {
 // Clear resets the message manager state.
 func (m *MessageManager) Clear() {
 	m.history.Clear()
+	m.lastElementMap = nil
+	m.plan = nil
 }
 
 // SensitiveDataFilter filters sensitive data from messages.
 type SensitiveDataFilter struct {
 	patterns map[string]*regexp.Regexp
+
+	// scrubSecrets replaces configured secret values by their placeholders
+	scrubSecrets func(string) string
 }
 
 // NewSensitiveDataFilter creates a new sensitive data filter.
@@ -199,9 +306,16 @@ func NewSensitiveDataFilter() *SensitiveDataFilter {
 // Filter replaces sensitive data with placeholders.
 func (f *SensitiveDataFilter) Filter(text string) string {
 	result := text
+	if f.scrubSecrets != nil {
+		result = f.scrubSecrets(result)
+	}
 
 	for name, pattern := range f.patterns {
 		result = pattern.ReplaceAllStringFunc(result, func(match string) string {
+			// Keep secret placeholders usable, e.g. "password: <secret>pw</secret>"
+			if strings.Contains(match, "<secret>") {
+				return match
+			}
 			return fmt.Sprintf("<secret type=\"%s\">[REDACTED]</secret>", name)
 		})
 	}
@@ -0,0 +1,185 @@
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	"google.golang.org/adk/tool"
+)
+
+// ToolProfile is a predefined set of tools the model may use.
+type ToolProfile string
+
+const (
+	// ToolProfileFull allows every tool, including evaluate_js.
+	ToolProfileFull ToolProfile = "full"
+
+	// ToolProfileFormFilling allows browsing, typing and key presses, but
+	// not running scripts.
+	ToolProfileFormFilling ToolProfile = "form_filling"
+
+	// ToolProfileReadOnly allows navigating, clicking, scrolling, reading
+	// and tabs, but not typing, key presses or scripts.
+	ToolProfileReadOnly ToolProfile = "read_only"
+)
+
+// readOnlyTools are the tools of ToolProfileReadOnly.
+var readOnlyTools = []string{
+	"navigate", "go_back", "go_forward", "reload",
+	"click", "double_click", "hover", "focus", "scroll", "scroll_to_element",
+	"get_page_state", "wait", "wait_for", "extract_content", "extract_table", "screenshot",
+	"new_tab", "switch_tab", "close_tab", "list_tabs",
+	"done",
+}
+
+// profileTools lists the tools of each profile; nil allows all.
+var profileTools = map[ToolProfile][]string{
+	ToolProfileFull:        nil,
+	ToolProfileFormFilling: append(slices.Clone(readOnlyTools), "type_text", "clear_and_type", "send_keys"),
+	ToolProfileReadOnly:    readOnlyTools,
+}
+
+// ToolValidator checks the arguments of a tool call before it runs. A
+// non-nil error rejects the call and is reported to the model.
+type ToolValidator func(args map[string]any) error
+
+// ToolPermissions restricts the tools the model may use. Tools that are
+// not permitted are not offered to the model at all. done is always
+// permitted so a run can finish, and profiles don't restrict custom tools.
+type ToolPermissions struct {
+	// Profile is the base set of tools (empty = ToolProfileFull).
+	Profile ToolProfile
+
+	// Allowed, if set, further limits the profile to these tools.
+	Allowed []string
+
+	// Denied tools are removed from the profile.
+	Denied []string
+
+	// Validators check the arguments of calls to a tool, keyed by tool name.
+	Validators map[string]ToolValidator
+}
+
+// toolGuard enforces ToolPermissions. It filters the tools offered to the
+// model and, as an ADK BeforeToolCallback, runs the argument validators.
+type toolGuard struct {
+	permissions ToolPermissions
+	custom      []string // Custom tools, which profiles don't restrict
+}
+
+// newToolGuard checks the permissions against the available tools, so a
+// misspelled tool name fails early instead of silently permitting it.
+// Custom tools are permitted by every profile.
+func newToolGuard(p ToolPermissions, tools, custom []tool.Tool) (*toolGuard, error) {
+	if _, ok := profileTools[p.Profile]; !ok && p.Profile != "" {
+		return nil, fmt.Errorf("unknown tool profile %q", p.Profile)
+	}
+
+	names := make([]string, 0, len(tools)+len(custom))
+	customNames := make([]string, 0, len(custom))
+	for _, t := range tools {
+		names = append(names, t.Name())
+	}
+	for _, t := range custom {
+		names = append(names, t.Name())
+		customNames = append(customNames, t.Name())
+	}
+	known := func(kind, name string) error {
+		if !slices.Contains(names, name) {
+			return fmt.Errorf("unknown tool %q in %s tools", name, kind)
+		}
+		return nil
+	}
+	for _, name := range p.Allowed {
+		if err := known("allowed", name); err != nil {
+			return nil, err
+		}
+	}
+	for _, name := range p.Denied {
+		if err := known("denied", name); err != nil {
+			return nil, err
+		}
+	}
+	for name := range p.Validators {
+		if err := known("validated", name); err != nil {
+			return nil, err
+		}
+	}
+
+	return &toolGuard{permissions: p, custom: customNames}, nil
+}
+
+// permits reports whether a tool is permitted, ignoring validators.
+func (g *toolGuard) permits(name string) bool {
+	if name == "done" {
+		return true
+	}
+	p := g.permissions
+	profile := p.Profile
+	if profile == "" {
+		profile = ToolProfileFull
+	}
+	tools := profileTools[profile]
+	if tools != nil && !slices.Contains(tools, name) && !slices.Contains(g.custom, name) {
+		return false
+	}
+	if len(p.Allowed) > 0 && !slices.Contains(p.Allowed, name) {
+		return false
+	}
+	return !slices.Contains(p.Denied, name)
+}
+
+// filter returns the permitted tools.
+func (g *toolGuard) filter(tools []tool.Tool) []tool.Tool {
+	return slices.DeleteFunc(slices.Clone(tools), func(t tool.Tool) bool {
+		return !g.permits(t.Name())
+	})
+}
+
+// names returns the names of the permitted tools.
+func (g *toolGuard) names(tools []tool.Tool) []string {
+	names := make([]string, 0, len(tools))
+	for _, t := range g.filter(tools) {
+		names = append(names, t.Name())
+	}
+	return names
+}
+
+// check returns why a call is not permitted, or nil if it may run.
+func (g *toolGuard) check(name string, args map[string]any) error {
+	if !g.permits(name) {
+		return fmt.Errorf("tool %s is not permitted", name)
+	}
+	if validate := g.permissions.Validators[name]; validate != nil {
+		if err := validate(args); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkJSON is check for arguments in their JSON encoding, as recorded
+// for cached actions.
+func (g *toolGuard) checkJSON(name string, rawArgs json.RawMessage) error {
+	var args map[string]any
+	if len(rawArgs) > 0 {
+		if err := json.Unmarshal(rawArgs, &args); err != nil {
+			return fmt.Errorf("invalid arguments: %w", err)
+		}
+	}
+	return g.check(name, args)
+}
+
+// beforeTool is an ADK BeforeToolCallback. Returning a non-nil result skips
+// the tool and reports the result to the model instead.
+func (g *toolGuard) beforeTool(ctx tool.Context, t tool.Tool, args map[string]any) (map[string]any, error) {
+	if err := g.check(t.Name(), args); err != nil {
+		return map[string]any{
+			"success":    false,
+			"error_code": ErrCodePermissionDenied,
+			"message":    fmt.Sprintf("Not permitted: %v. Do not retry this call; find another way or call done explaining what is not allowed.", err),
+		}, nil
+	}
+	return nil, nil
+}
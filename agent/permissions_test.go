@@ -0,0 +1,195 @@
+package agent
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"google.golang.org/adk/tool"
+)
+
+// namedTool is a tool with only a name, for permission checks.
+type namedTool string
+
+func (t namedTool) Name() string        { return string(t) }
+func (t namedTool) Description() string { return "" }
+func (t namedTool) IsLongRunning() bool { return false }
+
+func namedTools(names ...string) []tool.Tool {
+	tools := make([]tool.Tool, len(names))
+	for i, name := range names {
+		tools[i] = namedTool(name)
+	}
+	return tools
+}
+
+// browserTools are the built-in tools the permission tests check against.
+var browserTools = namedTools("navigate", "click", "type_text", "send_keys", "evaluate_js", "extract_table", "done")
+
+func TestNewToolGuard(t *testing.T) {
+	tests := []struct {
+		name    string
+		perms   ToolPermissions
+		wantErr string
+	}{
+		{name: "zero value", perms: ToolPermissions{}},
+		{name: "known profile", perms: ToolPermissions{Profile: ToolProfileReadOnly}},
+		{name: "unknown profile", perms: ToolPermissions{Profile: "admin"}, wantErr: `unknown tool profile "admin"`},
+		{name: "unknown allowed tool", perms: ToolPermissions{Allowed: []string{"clik"}}, wantErr: `unknown tool "clik" in allowed tools`},
+		{name: "unknown denied tool", perms: ToolPermissions{Denied: []string{"eval"}}, wantErr: `unknown tool "eval" in denied tools`},
+		{
+			name:    "unknown validated tool",
+			perms:   ToolPermissions{Validators: map[string]ToolValidator{"go": func(map[string]any) error { return nil }}},
+			wantErr: `unknown tool "go" in validated tools`,
+		},
+		{name: "custom tool allowed", perms: ToolPermissions{Allowed: []string{"lookup_order"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newToolGuard(tt.perms, browserTools, namedTools("lookup_order"))
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("newToolGuard() = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("newToolGuard() = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestToolGuardPermits(t *testing.T) {
+	all := []string{"navigate", "click", "type_text", "send_keys", "evaluate_js", "extract_table", "done", "lookup_order"}
+
+	tests := []struct {
+		name  string
+		perms ToolPermissions
+		want  []string
+	}{
+		{name: "default is full", perms: ToolPermissions{}, want: all},
+		{name: "full", perms: ToolPermissions{Profile: ToolProfileFull}, want: all},
+		{
+			name:  "form filling",
+			perms: ToolPermissions{Profile: ToolProfileFormFilling},
+			want:  []string{"navigate", "click", "type_text", "send_keys", "extract_table", "done", "lookup_order"},
+		},
+		{
+			name:  "read only",
+			perms: ToolPermissions{Profile: ToolProfileReadOnly},
+			want:  []string{"navigate", "click", "extract_table", "done", "lookup_order"},
+		},
+		{
+			name:  "allowed narrows the profile",
+			perms: ToolPermissions{Profile: ToolProfileReadOnly, Allowed: []string{"navigate", "extract_table"}},
+			want:  []string{"navigate", "extract_table", "done"},
+		},
+		{
+			name:  "allowed does not widen the profile",
+			perms: ToolPermissions{Profile: ToolProfileReadOnly, Allowed: []string{"navigate", "evaluate_js"}},
+			want:  []string{"navigate", "done"},
+		},
+		{
+			name:  "denied wins over allowed",
+			perms: ToolPermissions{Allowed: []string{"navigate", "click"}, Denied: []string{"click"}},
+			want:  []string{"navigate", "done"},
+		},
+		{
+			name:  "denied removes from profile",
+			perms: ToolPermissions{Profile: ToolProfileFormFilling, Denied: []string{"send_keys", "lookup_order"}},
+			want:  []string{"navigate", "click", "type_text", "extract_table", "done"},
+		},
+		{
+			name:  "done can't be denied",
+			perms: ToolPermissions{Denied: []string{"done"}, Allowed: []string{"navigate"}},
+			want:  []string{"navigate", "done"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g, err := newToolGuard(tt.perms, browserTools, namedTools("lookup_order"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, name := range all {
+				if g.permits(name) {
+					got = append(got, name)
+				}
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("permitted = %v, want %v", got, tt.want)
+			}
+			if names := g.names(append(slices.Clone(browserTools), namedTool("lookup_order"))); !slices.Equal(names, tt.want) {
+				t.Errorf("names() = %v, want %v", names, tt.want)
+			}
+		})
+	}
+}
+
+func TestToolGuardCheck(t *testing.T) {
+	errExternal := errors.New("only example.com may be opened")
+	perms := ToolPermissions{
+		Denied: []string{"evaluate_js"},
+		Validators: map[string]ToolValidator{
+			"navigate": func(args map[string]any) error {
+				if url, _ := args["url"].(string); !strings.HasPrefix(url, "https://example.com/") {
+					return errExternal
+				}
+				return nil
+			},
+		},
+	}
+	g, err := newToolGuard(perms, browserTools, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		tool    string
+		args    string
+		wantErr error // nil = permitted; errAny = any error
+	}{
+		{name: "permitted", tool: "click", args: `{"element_index":1}`},
+		{name: "no arguments", tool: "click", args: ``},
+		{name: "denied tool", tool: "evaluate_js", args: `{"script":"1"}`, wantErr: errAny},
+		{name: "validator passes", tool: "navigate", args: `{"url":"https://example.com/a"}`},
+		{name: "validator rejects", tool: "navigate", args: `{"url":"https://evil.net/"}`, wantErr: errExternal},
+		{name: "validator sees missing argument", tool: "navigate", args: `{}`, wantErr: errExternal},
+		{name: "malformed arguments", tool: "click", args: `{"element_index":`, wantErr: errAny},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := g.checkJSON(tt.tool, json.RawMessage(tt.args))
+			switch {
+			case tt.wantErr == nil && err != nil:
+				t.Errorf("checkJSON() = %v, want nil", err)
+			case tt.wantErr == errAny && err == nil:
+				t.Error("checkJSON() = nil, want an error")
+			case tt.wantErr != nil && tt.wantErr != errAny && !errors.Is(err, tt.wantErr):
+				t.Errorf("checkJSON() = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	result, err := g.beforeTool(nil, namedTool("navigate"), map[string]any{"url": "https://evil.net/"})
+	if err != nil {
+		t.Fatalf("beforeTool() error = %v", err)
+	}
+	if result == nil || result["success"] != false || result["error_code"] != ErrCodePermissionDenied {
+		t.Errorf("beforeTool() = %v, want a permission_denied result", result)
+	}
+	if result, _ := g.beforeTool(nil, namedTool("click"), nil); result != nil {
+		t.Errorf("beforeTool() = %v for a permitted call, want nil", result)
+	}
+}
+
+// errAny marks a test case that expects some error.
+var errAny = errors.New("any error")
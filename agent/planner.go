@@ -0,0 +1,250 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"google.golang.org/adk/agent"
+	"google.golang.org/adk/agent/llmagent"
+	"google.golang.org/adk/model"
+	"google.golang.org/adk/runner"
+	"google.golang.org/adk/session"
+	"google.golang.org/genai"
+)
+
+// Plan item statuses.
+const (
+	PlanPending    = "pending"
+	PlanInProgress = "in_progress"
+	PlanDone       = "done"
+	PlanFailed     = "failed"
+)
+
+// plannerInterval is how many executor steps may pass before the planner
+// reviews progress even if nothing failed.
+const plannerInterval = 5
+
+// PlanItem is one sub-goal of the plan.
+type PlanItem struct {
+	Description string `json:"description"`
+	Status      string `json:"status"`
+}
+
+// Plan is the planner's checklist for the task.
+type Plan struct {
+	Items     []PlanItem `json:"items"`
+	Reasoning string     `json:"reasoning,omitempty"`
+
+	// Revision counts planner updates, starting at 1 for the initial plan.
+	Revision int `json:"revision"`
+}
+
+// Current returns the item the executor should work on: the first item in
+// progress, else the first pending one. Returns nil when nothing is left.
+func (p *Plan) Current() *PlanItem {
+	if p == nil {
+		return nil
+	}
+	for i := range p.Items {
+		if p.Items[i].Status == PlanInProgress {
+			return &p.Items[i]
+		}
+	}
+	for i := range p.Items {
+		if p.Items[i].Status == PlanPending {
+			return &p.Items[i]
+		}
+	}
+	return nil
+}
+
+// ToDescription renders the plan as a checklist for the executor.
+func (p *Plan) ToDescription() string {
+	if p == nil || len(p.Items) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("<plan>\n")
+	for i, item := range p.Items {
+		mark := " "
+		switch item.Status {
+		case PlanDone:
+			mark = "x"
+		case PlanInProgress:
+			mark = ">"
+		case PlanFailed:
+			mark = "!"
+		}
+		sb.WriteString(fmt.Sprintf("[%s] %d. %s\n", mark, i+1, item.Description))
+	}
+	sb.WriteString("</plan>")
+	return sb.String()
+}
+
+// clone returns a deep copy of the plan.
+func (p *Plan) clone() *Plan {
+	if p == nil {
+		return nil
+	}
+	cp := *p
+	cp.Items = append([]PlanItem(nil), p.Items...)
+	return &cp
+}
+
+// planSchema is the planner's structured output.
+var planSchema = &genai.Schema{
+	Type: genai.TypeObject,
+	Properties: map[string]*genai.Schema{
+		"items": {
+			Type: genai.TypeArray,
+			Items: &genai.Schema{
+				Type: genai.TypeObject,
+				Properties: map[string]*genai.Schema{
+					"description": {Type: genai.TypeString},
+					"status": {
+						Type: genai.TypeString,
+						Enum: []string{PlanPending, PlanInProgress, PlanDone, PlanFailed},
+					},
+				},
+				Required: []string{"description", "status"},
+			},
+		},
+		"reasoning": {Type: genai.TypeString},
+	},
+	Required: []string{"items"},
+}
+
+// Planner is a tool-less sub-agent that decomposes the task into a checklist
+// and revises it as the executor reports progress.
+type Planner struct {
+	runner         *runner.Runner
+	sessionService session.Service
+	sessionID      string
+	debug          bool
+}
+
+// NewPlanner creates a planner backed by the given model.
+func NewPlanner(llm model.LLM, debug bool) (*Planner, error) {
+	plannerAgent, err := llmagent.New(llmagent.Config{
+		Name:         "planner",
+		Model:        llm,
+		Description:  "Plans browser automation tasks as a checklist of sub-goals and tracks their progress.",
+		Instruction:  PlannerSystemPrompt(),
+		OutputSchema: planSchema,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create planner agent: %w", err)
+	}
+
+	sessionService := session.InMemoryService()
+	plannerRunner, err := runner.New(runner.Config{
+		AppName:        "bua-planner",
+		Agent:          plannerAgent,
+		SessionService: sessionService,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create planner runner: %w", err)
+	}
+
+	return &Planner{
+		runner:         plannerRunner,
+		sessionService: sessionService,
+		debug:          debug,
+	}, nil
+}
+
+// Start begins planning a new task in a fresh planner session.
+func (p *Planner) Start(ctx context.Context, task, pageURL, pageTitle string) (*Plan, error) {
+	if err := p.newSession(ctx); err != nil {
+		return nil, err
+	}
+
+	plan, err := p.run(ctx, BuildPlannerStartPrompt(task, pageURL, pageTitle))
+	if err != nil {
+		return nil, err
+	}
+	plan.Revision = 1
+	return plan, nil
+}
+
+// Update asks the planner to revise the plan given the executor's progress.
+// reason explains why the planner is being consulted.
+func (p *Planner) Update(ctx context.Context, plan *Plan, history, pageURL, pageTitle, reason string) (*Plan, error) {
+	if p.sessionID == "" {
+		// Resumed run: the plan itself carries the progress so far
+		if err := p.newSession(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	updated, err := p.run(ctx, BuildPlannerUpdatePrompt(plan.ToDescription(), history, pageURL, pageTitle, reason))
+	if err != nil {
+		return nil, err
+	}
+	updated.Revision = plan.Revision + 1
+	return updated, nil
+}
+
+// newSession starts a fresh planner conversation.
+func (p *Planner) newSession(ctx context.Context) error {
+	p.sessionID = fmt.Sprintf("planner-%d", time.Now().UnixNano())
+	_, err := p.sessionService.Create(ctx, &session.CreateRequest{
+		AppName:   "bua-planner",
+		UserID:    "user",
+		SessionID: p.sessionID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to create planner session: %w", err)
+	}
+	return nil
+}
+
+// run sends one message to the planner and parses the plan from its reply.
+func (p *Planner) run(ctx context.Context, prompt string) (*Plan, error) {
+	var text strings.Builder
+	content := genai.NewContentFromText(prompt, "user")
+	for event, err := range p.runner.Run(ctx, "user", p.sessionID, content, agent.RunConfig{}) {
+		if err != nil {
+			return nil, fmt.Errorf("planner error: %w", err)
+		}
+		if event == nil || event.Content == nil || !event.IsFinalResponse() {
+			continue
+		}
+		for _, part := range event.Content.Parts {
+			text.WriteString(part.Text)
+		}
+	}
+
+	raw := strings.TrimSpace(text.String())
+	raw = strings.TrimPrefix(raw, "```json")
+	raw = strings.TrimSuffix(strings.TrimPrefix(raw, "```"), "```")
+
+	var plan Plan
+	if err := json.Unmarshal([]byte(raw), &plan); err != nil {
+		return nil, fmt.Errorf("failed to parse plan: %w", err)
+	}
+	if len(plan.Items) == 0 {
+		return nil, fmt.Errorf("planner returned an empty plan")
+	}
+	for i := range plan.Items {
+		switch plan.Items[i].Status {
+		case PlanPending, PlanInProgress, PlanDone, PlanFailed:
+		default:
+			plan.Items[i].Status = PlanPending
+		}
+	}
+
+	if p.debug {
+		fmt.Printf("[Planner] %d items, current: %s\n", len(plan.Items), func() string {
+			if item := plan.Current(); item != nil {
+				return item.Description
+			}
+			return "(none)"
+		}())
+	}
+	return &plan, nil
+}
@@ -0,0 +1,39 @@
+package agent
+
+import (
+	"fmt"
+
+	"github.com/anxuanzi/bua/browser"
+	"google.golang.org/adk/tool"
+)
+
+// blockedReporter reports page loads the URL policy blocked while a tool
+// ran, e.g. a link click leading off the allowed domains. The tool itself
+// succeeded, so its result is turned into a failure the model can act on.
+type blockedReporter struct {
+	browser *browser.Browser
+}
+
+// newBlockedReporter creates a reporter for the browser.
+func newBlockedReporter(b *browser.Browser) *blockedReporter {
+	return &blockedReporter{browser: b}
+}
+
+// after is an ADK AfterToolCallback. It always drains the blocked load, so
+// it isn't reported against a later call, and rewrites the result in place
+// so the callbacks after it still run.
+func (r *blockedReporter) after(ctx tool.Context, tl tool.Tool, args, result map[string]any, err error) (map[string]any, error) {
+	blocked := r.browser.TakeBlocked()
+	if blocked == nil || result == nil {
+		return nil, nil
+	}
+	// Navigate already reports its own blocked URL
+	if code, _ := result["error_code"].(string); code == ErrCodeURLBlocked {
+		return nil, nil
+	}
+
+	result["success"] = false
+	result["error_code"] = ErrCodeURLBlocked
+	result["message"] = fmt.Sprintf("Blocked: %v. The page did not change; do not try to reach this URL another way.", blocked)
+	return nil, nil
+}
@@ -2,7 +2,11 @@ package agent
 
 import (
 	"fmt"
+	"slices"
 	"strings"
+	"time"
+
+	"google.golang.org/adk/tool"
 )
 
 // SystemPrompt returns the system prompt for the browser agent.
@@ -11,6 +15,84 @@ func SystemPrompt() string {
 	return systemPromptTemplate
 }
 
+// MultiActionSystemPrompt returns the system prompt for agents that may
+// issue several actions in one turn.
+func MultiActionSystemPrompt() string {
+	return strings.NewReplacer(
+		oneActionGuideline, multiActionGuideline,
+		oneActionRule, multiActionRule,
+	).Replace(systemPromptTemplate)
+}
+
+// AddToolPrompt lists tools in a tool category of a system prompt, adding
+// the category before completion if it doesn't exist.
+func AddToolPrompt(prompt, category string, tools []tool.Tool) string {
+	var lines strings.Builder
+	for _, t := range tools {
+		summary, _, _ := strings.Cut(strings.TrimSpace(t.Description()), "\n")
+		lines.WriteString(fmt.Sprintf("- %s: %s\n", t.Name(), summary))
+	}
+
+	open := fmt.Sprintf("<category name=%q>\n", category)
+	if start := strings.Index(prompt, open); start >= 0 {
+		end := start + strings.Index(prompt[start:], "</category>")
+		return prompt[:end] + lines.String() + prompt[end:]
+	}
+	completion := strings.Index(prompt, `<category name="completion">`)
+	if completion < 0 {
+		return prompt
+	}
+	return prompt[:completion] + open + lines.String() + "</category>\n\n" + prompt[completion:]
+}
+
+// RestrictToolPrompt removes the tools not in tools from the tool categories
+// of a system prompt, and categories left empty, so the model isn't told
+// about tools it doesn't have.
+func RestrictToolPrompt(prompt string, tools []string) string {
+	start := strings.Index(prompt, "<tool_categories>")
+	end := strings.Index(prompt, "</tool_categories>")
+	if start < 0 || end < start {
+		return prompt
+	}
+
+	var sb strings.Builder
+	var category []string
+	listed, written := false, false
+	for _, line := range strings.Split(prompt[start:end], "\n") {
+		switch {
+		case strings.HasPrefix(line, "<category "):
+			category, listed = []string{line}, false
+		case line == "</category>":
+			if listed {
+				if written {
+					sb.WriteString("\n")
+				}
+				sb.WriteString(strings.Join(append(category, line), "\n") + "\n")
+				written = true
+			}
+			category = nil
+		case category != nil:
+			name, _, ok := strings.Cut(strings.TrimPrefix(line, "- "), ":")
+			if ok && strings.HasPrefix(line, "- ") && !slices.Contains(tools, name) {
+				continue
+			}
+			category = append(category, line)
+			listed = true
+		case line != "":
+			sb.WriteString(line + "\n")
+		}
+	}
+	return prompt[:start] + sb.String() + prompt[end:]
+}
+
+// Lines of the system prompt that differ when multi-action turns are enabled.
+const (
+	oneActionGuideline   = "<guideline>Take one action at a time - don't try to do too much at once</guideline>"
+	multiActionGuideline = `<guideline>You may call several tools in one turn when they all target the current page and none of them is expected to change it, e.g. filling several form fields. Put navigation, submits and clicks that open new content last, since the remaining actions are skipped if the page changes</guideline>`
+	oneActionRule        = "- Always take exactly ONE action per turn"
+	multiActionRule      = "- Batch independent actions on the same page into one turn (at most 10); otherwise take one action per turn"
+)
+
 // systemPromptTemplate is the core system prompt defining agent behavior.
 // Uses XML-style structure for better LLM parsing.
 const systemPromptTemplate = `<role>
@@ -50,8 +132,10 @@ You have access to browser automation tools. Use them by making function calls.
 
 <category name="page_state">
 - get_page_state: Get current page state with all interactive elements
-- wait: Wait for page stability or loading
-- extract_content: Extract text content from the page
+- wait: Wait a fixed time for page stability or loading
+- wait_for: Wait until the network is idle, an element or text appears or disappears, the URL matches, or the DOM settles
+- extract_content: Extract page content as Markdown, optionally scoped by selector/element, filtered by query, and paginated
+- extract_table: Extract tables and grids as structured headers and rows
 - screenshot: Take a screenshot of the page
 - evaluate_js: Execute JavaScript code on the page
 </category>
@@ -72,6 +156,10 @@ You have access to browser automation tools. Use them by making function calls.
 <element_interaction_rules>
 <rule>Elements are identified by index numbers: [0], [1], [2], etc.</rule>
 <rule>Only interact with elements visible in the current page state</rule>
+<rule>Check scroll_context for elements above or below the viewport (e.g. "Next page" buttons) and scroll to reach them</rule>
+<rule>Lines without an [index] are page text (headings as #, alerts as !, table rows as |) for reading only - they cannot be clicked</rule>
+<rule>Elements prefixed with * (e.g. *[12]) appeared since your last action - check them for toasts, validation errors or opened menus</rule>
+<rule>Elements marked [offscreen] are outside the viewport; use scroll_to_element before interacting with them</rule>
 <rule>After clicks or form submissions, wait for page updates before next action</rule>
 <rule>If content may have changed, use get_page_state to refresh your view</rule>
 <rule>For text inputs, verify the element is an input/textarea before typing</rule>
@@ -83,7 +171,9 @@ You have access to browser automation tools. Use them by making function calls.
 <guideline>Take one action at a time - don't try to do too much at once</guideline>
 <guideline>If an action fails, analyze why and try an alternative approach</guideline>
 <guideline>Verify task completion before calling the done tool</guideline>
-<guideline>Use reasoning parameter in tools to explain your intent</guideline>
+<guideline>For tabular data use extract_table, then call done with use_extracted_tables=true instead of copying rows into data by hand</guideline>
+<guideline>Fill the reasoning object on every tool call (see reasoning_format)</guideline>
+<guideline>If a <plan> checklist is provided, work on the current item (marked [>]); a planner tracks progress and revises the plan</guideline>
 </execution_guidelines>
 
 <response_behavior>
@@ -95,49 +185,87 @@ Before each action, think through:
 
 Then call the appropriate tool with clear reasoning.
 
+<reasoning_format>
+Every tool call takes a reasoning object:
+- evaluation_previous_goal: Did the previous action achieve its goal? "Success", "Failed" or "Unknown", with a short reason
+- memory: What to remember for later steps - progress counts, collected data, approaches already tried. It is shown back to you in the history, so carry forward anything still needed
+- next_goal: The immediate goal this action works towards
+- thinking: Brief reasoning about the current state and why this action
+</reasoning_format>
+
 IMPORTANT:
 - Always take exactly ONE action per turn
 - Use the done tool ONLY when the task is fully complete
-- Include helpful reasoning in your tool calls
+- Include the reasoning object in every tool call
 </response_behavior>
 
 <example_task>
 Task: Search for "golang tutorials" on Google
 
-Turn 1: Call navigate with url="https://www.google.com" and reasoning="Going to Google to perform the search"
+Turn 1: Call navigate with url="https://www.google.com" and reasoning={evaluation_previous_goal: "Unknown - first step", memory: "Need to search for golang tutorials", next_goal: "Open Google"}
 
 Turn 2: (After seeing page state with search box at element [0])
-Call type_text with element_index=0, text="golang tutorials", reasoning="Typing search query into Google search box"
+Call type_text with element_index=0, text="golang tutorials", reasoning={evaluation_previous_goal: "Success - Google is open", next_goal: "Enter the search query"}
 
-Turn 3: Call send_keys with keys="Enter", reasoning="Submitting the search query"
+Turn 3: Call send_keys with keys="Enter", reasoning={evaluation_previous_goal: "Success - query typed", next_goal: "Submit the search"}
 
 Turn 4: (After seeing search results displayed)
 Call done with success=true, summary="Successfully searched for 'golang tutorials' on Google. Search results are now displayed."
 </example_task>
 
 <error_handling>
+Failed actions include an error_code naming the scenario below.
 <scenario type="element_not_found">
 If an element index is invalid, use get_page_state to refresh and find the correct element.
 </scenario>
+<scenario type="element_not_visible">
+The element is outside the viewport. Use scroll_to_element, then retry the action.
+</scenario>
 <scenario type="navigation_failed">
 Check the URL, try an alternative URL, or use search to find the correct page.
 </scenario>
+<scenario type="url_blocked">
+The URL is not allowed by the browser's URL policy. Do not retry it or reach it another way; work with the allowed sites, or call done explaining what could not be reached.
+</scenario>
+<scenario type="permission_denied">
+The call is not permitted for this agent. Do not retry it; find another way with the tools you have, or call done explaining what is not allowed.
+</scenario>
 <scenario type="action_blocked">
 The page may have popups, modals, or overlays. Look for close buttons or use send_keys with "Escape".
 </scenario>
 <scenario type="page_loading">
-Use wait tool to allow the page to fully load before interacting.
+Use wait_for with the condition you expect (e.g. a selector for the results, or a spinner gone) rather than a fixed wait.
+</scenario>
+<scenario type="timeout">
+The page was too slow to respond. Wait, then check the page state before retrying.
 </scenario>
 </error_handling>`
 
+// BuildSecretsPrompt tells the model which secrets it can type by name
+// without seeing their values.
+func BuildSecretsPrompt(names []string) string {
+	return fmt.Sprintf(`<secrets>
+These secrets are available: %s
+To type a secret, put its placeholder in the text of type_text or clear_and_type, e.g. <secret>%s</secret>. The value is typed for you.
+You never see the values: they appear as placeholders in page state and results. Never ask for them or try to reveal them.
+</secrets>`, strings.Join(names, ", "), names[0])
+}
+
 // BuildPageStatePrompt creates a prompt describing the current page state.
-func BuildPageStatePrompt(pageURL, pageTitle, elementsText string, screenshotIncluded bool) string {
+// scrollContext describes the scroll position and offscreen elements; it is omitted when empty.
+func BuildPageStatePrompt(pageURL, pageTitle, scrollContext, elementsText string, screenshotIncluded bool) string {
 	var sb strings.Builder
 
 	sb.WriteString("<current_page_state>\n")
 	sb.WriteString(fmt.Sprintf("<url>%s</url>\n", pageURL))
 	sb.WriteString(fmt.Sprintf("<title>%s</title>\n", pageTitle))
 
+	if scrollContext != "" {
+		sb.WriteString("<scroll_context>\n")
+		sb.WriteString(scrollContext)
+		sb.WriteString("\n</scroll_context>\n")
+	}
+
 	if screenshotIncluded {
 		sb.WriteString("<screenshot>A screenshot of the current page is attached.</screenshot>\n")
 	}
@@ -155,6 +283,11 @@ func BuildTaskPrompt(task string) string {
 	return fmt.Sprintf("<task>\n%s\n</task>\n\n<instruction>Accomplish this task by interacting with the web page. Analyze what needs to be done and take the first action.</instruction>", task)
 }
 
+// BuildFollowUpTaskPrompt creates the prompt for a new task in an ongoing conversation.
+func BuildFollowUpTaskPrompt(task string) string {
+	return fmt.Sprintf("<task>\n%s\n</task>\n\n<instruction>This is a follow-up task in the same conversation. Earlier tasks, their results and your memory are in the history; refer to them to resolve references like \"the second result\" or \"that item\". Start from the current page and take the first action.</instruction>", task)
+}
+
 // BuildContinuationPrompt creates a prompt for continuing after an action.
 func BuildContinuationPrompt(previousAction, actionResult string) string {
 	var sb strings.Builder
@@ -189,3 +322,123 @@ func BuildErrorRecoveryPrompt(errorMsg string) string {
 
 	return sb.String()
 }
+
+// BuildResumePrompt prefixes a continuation message with a note that the run
+// was interrupted and restored from a checkpoint.
+func BuildResumePrompt(continuation string) string {
+	var sb strings.Builder
+
+	sb.WriteString("<resumed>\n")
+	sb.WriteString("This task was interrupted and has been resumed from the last saved step.\n")
+	sb.WriteString("The browser was restored to the saved tabs, cookies and storage, but pages were reloaded:\n")
+	sb.WriteString("form inputs, open menus and dialogs may have been reset. Check the page state before continuing.\n")
+	sb.WriteString("</resumed>\n\n")
+	sb.WriteString(continuation)
+
+	return sb.String()
+}
+
+// BuildBrowserRestartedPrompt wraps the continuation message after the
+// browser crashed and was relaunched.
+func BuildBrowserRestartedPrompt(continuation string) string {
+	var sb strings.Builder
+
+	sb.WriteString("<browser_restarted>\n")
+	sb.WriteString("The browser crashed or disconnected and has been restarted. Open tabs were reopened at their\n")
+	sb.WriteString("last URLs, but pages were reloaded: form inputs, open menus and dialogs may have been reset,\n")
+	sb.WriteString("and your last action may not have taken effect. Check the page state before continuing.\n")
+	sb.WriteString("</browser_restarted>\n\n")
+	sb.WriteString(continuation)
+
+	return sb.String()
+}
+
+// BuildTimeBudgetPrompt prepends a warning that the run's time budget is
+// nearly used up to the continuation message.
+func BuildTimeBudgetPrompt(remaining time.Duration, continuation string) string {
+	var sb strings.Builder
+
+	sb.WriteString("<time_budget>\n")
+	sb.WriteString(fmt.Sprintf("Less than %s remain before this task is stopped. Wrap up now and do not start new work.\n", remaining.Round(time.Second)))
+	sb.WriteString("Call done with the data gathered so far. If the task is not finished, set success=false and\n")
+	sb.WriteString("say in the summary what is still missing.\n")
+	sb.WriteString("</time_budget>\n\n")
+	sb.WriteString(continuation)
+
+	return sb.String()
+}
+
+// BuildStepTimeoutResult is the action result reported to the model for a
+// turn cut off by the step timeout.
+func BuildStepTimeoutResult(timeout time.Duration) string {
+	return fmt.Sprintf(`{"success":false,"message":"Step timed out after %s; the action may not have completed","error_code":%q}`, timeout, ErrCodeTimeout)
+}
+
+// BuildReplayFallbackPrompt creates the task for the agent when a replayed
+// step cannot be executed directly.
+func BuildReplayFallbackPrompt(task, step string) string {
+	var sb strings.Builder
+
+	sb.WriteString("You are helping replay a recorded workflow. The recorded step below could not be performed\n")
+	sb.WriteString("automatically because its target element was not found (the page may have changed).\n\n")
+	sb.WriteString(fmt.Sprintf("<workflow_task>%s</workflow_task>\n", task))
+	sb.WriteString(fmt.Sprintf("<recorded_step>%s</recorded_step>\n\n", step))
+	sb.WriteString("Perform ONLY this step on the current page (find the equivalent element if it moved or was renamed),\n")
+	sb.WriteString("then call done with success=true. Do not continue with the rest of the workflow.\n")
+	sb.WriteString("If the step is impossible on this page, call done with success=false and explain why.")
+
+	return sb.String()
+}
+
+// PlannerSystemPrompt returns the system prompt for the planner sub-agent.
+func PlannerSystemPrompt() string {
+	return plannerPromptTemplate
+}
+
+// plannerPromptTemplate defines the planner's behavior.
+const plannerPromptTemplate = `<role>
+You are the planner for a web browser automation agent. A separate executor agent performs browser actions one at a time.
+Your job is to break the user's task into a short checklist of sub-goals, track which are done, and revise the checklist when the executor gets stuck.
+</role>
+
+<planning_rules>
+<rule>Use 2-10 items. Each item is a sub-goal whose completion can be checked on the page (e.g. "Log in", "Open the March invoice"), not a single click</rule>
+<rule>Mark an item done only when the executor's history or the current page shows it was achieved</rule>
+<rule>Mark exactly one item in_progress: the one the executor should work on next</rule>
+<rule>When an approach failed, mark the item failed and add alternative items after it rather than repeating the same approach</rule>
+<rule>Keep done and failed items in place; only add, reorder or reword pending items</rule>
+<rule>The last item is always reporting the result with the done tool</rule>
+</planning_rules>
+
+<output_format>
+Respond with JSON only:
+{"items": [{"description": "...", "status": "pending|in_progress|done|failed"}], "reasoning": "one or two sentences on what changed and why"}
+</output_format>`
+
+// BuildPlannerStartPrompt creates the planner's first message for a task.
+func BuildPlannerStartPrompt(task, pageURL, pageTitle string) string {
+	var sb strings.Builder
+
+	sb.WriteString(fmt.Sprintf("<task>\n%s\n</task>\n\n", task))
+	sb.WriteString(fmt.Sprintf("<current_page>\n<url>%s</url>\n<title>%s</title>\n</current_page>\n\n", pageURL, pageTitle))
+	sb.WriteString("<instruction>Create the initial checklist for this task. Mark the first item in_progress.</instruction>")
+
+	return sb.String()
+}
+
+// BuildPlannerUpdatePrompt asks the planner to review progress and revise the plan.
+func BuildPlannerUpdatePrompt(plan, history, pageURL, pageTitle, reason string) string {
+	var sb strings.Builder
+
+	sb.WriteString(plan)
+	sb.WriteString("\n\n")
+	if history != "" {
+		sb.WriteString(history)
+		sb.WriteString("\n\n")
+	}
+	sb.WriteString(fmt.Sprintf("<current_page>\n<url>%s</url>\n<title>%s</title>\n</current_page>\n\n", pageURL, pageTitle))
+	sb.WriteString(fmt.Sprintf("<reason>%s</reason>\n\n", reason))
+	sb.WriteString("<instruction>Update the item statuses from the executor's history. If the current item failed or is stuck, replan. Return the full checklist.</instruction>")
+
+	return sb.String()
+}
@@ -0,0 +1,434 @@
+package agent
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/anxuanzi/bua/browser"
+	"github.com/anxuanzi/bua/dom"
+)
+
+// scriptVersion is the current script file format version.
+const scriptVersion = 1
+
+// Script is a recorded run that can be replayed without the LLM.
+type Script struct {
+	Version  int          `json:"version"`
+	Task     string       `json:"task"`
+	StartURL string       `json:"start_url,omitempty"`
+	FinalURL string       `json:"final_url,omitempty"` // Page URL when the run finished
+	Steps    []ScriptStep `json:"steps"`
+}
+
+// ScriptStep is a single recorded tool call.
+type ScriptStep struct {
+	Action string          `json:"action"`
+	Args   json.RawMessage `json:"args,omitempty"`
+
+	// Locator finds the target element again for element-indexed actions.
+	Locator *dom.Locator `json:"locator,omitempty"`
+
+	// URL is the page URL when the action was recorded.
+	URL string `json:"url,omitempty"`
+
+	// Result is the recorded tool result, used to map tab IDs on replay.
+	Result string `json:"result,omitempty"`
+}
+
+// replaySkippedActions are tools that only observe the page or end the run.
+var replaySkippedActions = map[string]bool{
+	"get_page_state":  true,
+	"screenshot":      true,
+	"extract_content": true,
+	"extract_table":   true,
+	"list_tabs":       true,
+	"done":            true,
+}
+
+// NewScript builds a replay script from a run's steps.
+// Failed and observation-only steps are left out.
+func NewScript(task string, steps []Step) *Script {
+	script := &Script{Version: scriptVersion, Task: task}
+	for _, step := range steps {
+		if script.StartURL == "" && step.URL != "" {
+			script.StartURL = step.URL
+		}
+		if step.URL != "" {
+			script.FinalURL = step.URL
+		}
+		if !step.Success || replaySkippedActions[step.Action] {
+			continue
+		}
+		script.Steps = append(script.Steps, ScriptStep{
+			Action:  step.Action,
+			Args:    json.RawMessage(step.Target),
+			Locator: step.Locator,
+			URL:     step.URL,
+			Result:  step.Result,
+		})
+	}
+	return script
+}
+
+// Save writes the script to a JSON file.
+func (s *Script) Save(path string) error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode script: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write script: %w", err)
+	}
+	return nil
+}
+
+// LoadScript reads a script from a JSON file.
+func LoadScript(path string) (*Script, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read script: %w", err)
+	}
+
+	var script Script
+	if err := json.Unmarshal(data, &script); err != nil {
+		return nil, fmt.Errorf("failed to decode script: %w", err)
+	}
+	if script.Version > scriptVersion {
+		return nil, fmt.Errorf("unsupported script version %d", script.Version)
+	}
+	return &script, nil
+}
+
+// ReplayStep records how a single script step was replayed.
+type ReplayStep struct {
+	Number   int    `json:"number"`
+	Action   string `json:"action"`
+	Strategy string `json:"strategy,omitempty"` // Locator strategy that matched
+	FellBack bool   `json:"fell_back"`          // Step was handed to the LLM agent
+	Success  bool   `json:"success"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ReplayResult is the outcome of a replay.
+type ReplayResult struct {
+	Success   bool          `json:"success"`
+	Error     string        `json:"error,omitempty"`
+	Steps     []ReplayStep  `json:"steps"`
+	Fallbacks int           `json:"fallbacks"`
+	Duration  time.Duration `json:"duration"`
+}
+
+// Replayer executes recorded scripts directly against the browser.
+// Steps whose element cannot be located are handed to the fallback agent.
+type Replayer struct {
+	browser  *browser.Browser
+	fallback *BrowserAgent
+	debug    bool
+
+	// tabIDs maps recorded tab IDs to the IDs of tabs opened during replay.
+	tabIDs map[string]string
+}
+
+// NewReplayer creates a replayer. fallback may be nil, in which case a step
+// whose locator fails ends the replay.
+func NewReplayer(b *browser.Browser, fallback *BrowserAgent, debug bool) *Replayer {
+	return &Replayer{
+		browser:  b,
+		fallback: fallback,
+		debug:    debug,
+	}
+}
+
+// Replay runs the script from its start URL.
+func (r *Replayer) Replay(ctx context.Context, script *Script) (*ReplayResult, error) {
+	startTime := time.Now()
+	r.tabIDs = make(map[string]string)
+	result := &ReplayResult{Steps: make([]ReplayStep, 0, len(script.Steps))}
+
+	if script.StartURL != "" && !strings.HasPrefix(script.StartURL, "about:") {
+		if _, err := r.browser.Navigate(ctx, script.StartURL); err != nil {
+			return nil, fmt.Errorf("failed to open start URL: %w", err)
+		}
+	}
+
+	for i, step := range script.Steps {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		rs := ReplayStep{Number: i + 1, Action: step.Action}
+		strategy, err := r.replayStep(ctx, step)
+		rs.Strategy = strategy
+
+		if err != nil && r.fallback != nil {
+			if r.debug {
+				fmt.Printf("[Replay] Step %d (%s): %v, falling back to agent\n", rs.Number, step.Action, err)
+			}
+			rs.FellBack = true
+			result.Fallbacks++
+			err = r.runFallback(ctx, script, step)
+		}
+
+		if err != nil {
+			rs.Error = err.Error()
+			result.Steps = append(result.Steps, rs)
+			result.Error = fmt.Sprintf("step %d (%s) failed: %v", rs.Number, step.Action, err)
+			result.Duration = time.Since(startTime)
+			return result, nil
+		}
+
+		rs.Success = true
+		result.Steps = append(result.Steps, rs)
+		if r.debug {
+			fmt.Printf("[Replay] Step %d: %s ok%s\n", rs.Number, step.Action, func() string {
+				if rs.FellBack {
+					return " (agent)"
+				}
+				return ""
+			}())
+		}
+	}
+
+	result.Success = true
+	result.Duration = time.Since(startTime)
+	return result, nil
+}
+
+// replayStep executes one step directly. Returns the locator strategy used
+// for element actions.
+func (r *Replayer) replayStep(ctx context.Context, step ScriptStep) (string, error) {
+	var elementMap *dom.ElementMap
+	var element *dom.Element
+	var strategy string
+	if step.Locator != nil {
+		em, err := r.browser.GetElementMap(ctx)
+		if err != nil {
+			return "", fmt.Errorf("failed to read page: %w", err)
+		}
+		element, strategy = step.Locator.Resolve(em)
+		if element == nil {
+			return "", fmt.Errorf("could not locate %s: %w", step.Locator.Description(), browser.ErrElementNotFound)
+		}
+		elementMap = em
+	}
+
+	switch step.Action {
+	case "navigate":
+		var args NavigateArgs
+		if err := json.Unmarshal(step.Args, &args); err != nil {
+			return "", fmt.Errorf("invalid arguments: %w", err)
+		}
+		_, err := r.browser.Navigate(ctx, args.URL)
+		return "", err
+
+	case "go_back":
+		return "", r.browser.GoBack(ctx)
+
+	case "go_forward":
+		return "", r.browser.GoForward(ctx)
+
+	case "reload":
+		return "", r.browser.Reload(ctx)
+
+	case "click":
+		return strategy, r.requireElement(element, func() error {
+			return r.browser.Click(ctx, element.Index, elementMap)
+		})
+
+	case "double_click":
+		return strategy, r.requireElement(element, func() error {
+			return r.browser.DoubleClick(ctx, element.Index, elementMap)
+		})
+
+	case "hover":
+		return strategy, r.requireElement(element, func() error {
+			return r.browser.Hover(ctx, element.Index, elementMap)
+		})
+
+	case "focus":
+		return strategy, r.requireElement(element, func() error {
+			return r.browser.Focus(ctx, element.Index, elementMap)
+		})
+
+	case "scroll_to_element":
+		return strategy, r.requireElement(element, func() error {
+			return r.browser.ScrollToElement(ctx, element.Index, elementMap)
+		})
+
+	case "type_text":
+		var args TypeTextArgs
+		if err := json.Unmarshal(step.Args, &args); err != nil {
+			return "", fmt.Errorf("invalid arguments: %w", err)
+		}
+		return strategy, r.requireElement(element, func() error {
+			return r.browser.TypeText(ctx, element.Index, args.Text, elementMap)
+		})
+
+	case "clear_and_type":
+		var args ClearAndTypeArgs
+		if err := json.Unmarshal(step.Args, &args); err != nil {
+			return "", fmt.Errorf("invalid arguments: %w", err)
+		}
+		return strategy, r.requireElement(element, func() error {
+			return r.browser.ClearAndType(ctx, element.Index, args.Text, elementMap)
+		})
+
+	case "scroll":
+		var args ScrollArgs
+		if err := json.Unmarshal(step.Args, &args); err != nil {
+			return "", fmt.Errorf("invalid arguments: %w", err)
+		}
+		amount := float64(args.Amount)
+		if amount == 0 {
+			amount = 300
+		}
+		var index *int
+		if element != nil {
+			index = &element.Index
+		}
+		return strategy, r.browser.Scroll(ctx, args.Direction, amount, index, elementMap)
+
+	case "send_keys":
+		var args SendKeysArgs
+		if err := json.Unmarshal(step.Args, &args); err != nil {
+			return "", fmt.Errorf("invalid arguments: %w", err)
+		}
+		return "", r.browser.SendKeys(ctx, args.Keys)
+
+	case "wait":
+		var args WaitArgs
+		if err := json.Unmarshal(step.Args, &args); err != nil {
+			return "", fmt.Errorf("invalid arguments: %w", err)
+		}
+		durationMs := args.DurationMs
+		if durationMs <= 0 {
+			durationMs = 1000
+		}
+		if durationMs > 10000 {
+			durationMs = 10000
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(time.Duration(durationMs) * time.Millisecond):
+		}
+		return "", r.browser.WaitStable(ctx)
+
+	case "wait_for":
+		var args WaitForArgs
+		if err := json.Unmarshal(step.Args, &args); err != nil {
+			return "", fmt.Errorf("invalid arguments: %w", err)
+		}
+		if err := args.validate(); err != nil {
+			return "", err
+		}
+		return "", waitFor(ctx, r.browser, args)
+
+	case "evaluate_js":
+		var args EvaluateJSArgs
+		if err := json.Unmarshal(step.Args, &args); err != nil {
+			return "", fmt.Errorf("invalid arguments: %w", err)
+		}
+		_, err := r.browser.EvaluateJS(ctx, args.Script)
+		return "", err
+
+	case "new_tab":
+		var args NewTabArgs
+		if err := json.Unmarshal(step.Args, &args); err != nil {
+			return "", fmt.Errorf("invalid arguments: %w", err)
+		}
+		tabID, err := r.browser.NewTab(ctx, args.URL)
+		if err != nil {
+			return "", err
+		}
+		var recorded NewTabResult
+		if json.Unmarshal([]byte(step.Result), &recorded) == nil && recorded.TabID != "" {
+			r.tabIDs[recorded.TabID] = tabID
+		}
+		return "", nil
+
+	case "switch_tab":
+		var args SwitchTabArgs
+		if err := json.Unmarshal(step.Args, &args); err != nil {
+			return "", fmt.Errorf("invalid arguments: %w", err)
+		}
+		return "", r.browser.SwitchTab(r.tabID(args.TabID))
+
+	case "close_tab":
+		var args CloseTabArgs
+		if err := json.Unmarshal(step.Args, &args); err != nil {
+			return "", fmt.Errorf("invalid arguments: %w", err)
+		}
+		return "", r.browser.CloseTab(r.tabID(args.TabID))
+
+	default:
+		if replaySkippedActions[step.Action] {
+			return "", nil
+		}
+		return "", fmt.Errorf("action %q cannot be replayed", step.Action)
+	}
+}
+
+// requireElement runs an element action, failing if the step had no locator.
+func (r *Replayer) requireElement(element *dom.Element, action func() error) error {
+	if element == nil {
+		return fmt.Errorf("step has no element locator")
+	}
+	return action()
+}
+
+// tabID maps a recorded tab ID to the replayed tab's ID.
+func (r *Replayer) tabID(recorded string) string {
+	if id, ok := r.tabIDs[recorded]; ok {
+		return id
+	}
+	return recorded
+}
+
+// runFallback asks the LLM agent to perform a single step the replayer
+// could not execute directly.
+func (r *Replayer) runFallback(ctx context.Context, script *Script, step ScriptStep) error {
+	result, err := r.fallback.Run(ctx, BuildReplayFallbackPrompt(script.Task, describeScriptStep(step)))
+	if err != nil {
+		return fmt.Errorf("fallback agent failed: %w", err)
+	}
+	if !result.Success {
+		return fmt.Errorf("fallback agent could not complete the step: %s", result.Error)
+	}
+	return nil
+}
+
+// describeScriptStep describes a recorded step for the fallback agent.
+func describeScriptStep(step ScriptStep) string {
+	var sb strings.Builder
+	sb.WriteString(step.Action)
+	if step.Locator != nil {
+		sb.WriteString(" on ")
+		sb.WriteString(step.Locator.Description())
+		if step.Locator.Role != "" {
+			sb.WriteString(fmt.Sprintf(" (role %s)", step.Locator.Role))
+		}
+	}
+
+	var args map[string]any
+	if json.Unmarshal(step.Args, &args) == nil {
+		reasoning := parseBrain(step.Args).String()
+		delete(args, "element_index")
+		delete(args, "reasoning")
+		if len(args) > 0 {
+			argsJSON, _ := json.Marshal(args)
+			sb.WriteString(" with ")
+			sb.Write(argsJSON)
+		}
+		if reasoning != "" {
+			sb.WriteString(" (intent: ")
+			sb.WriteString(reasoning)
+			sb.WriteString(")")
+		}
+	}
+	return sb.String()
+}
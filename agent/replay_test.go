@@ -0,0 +1,23 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestReplayWaitCanceled(t *testing.T) {
+	r := NewReplayer(nil, nil, false)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := r.replayStep(ctx, ScriptStep{Action: "wait", Args: []byte(`{"duration_ms":10000}`)})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("replayStep() = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("wait ran for %v after the context ended", elapsed)
+	}
+}
@@ -0,0 +1,263 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"math"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+// RetryPolicy controls how failed model calls are retried.
+// Zero fields use the defaults noted below.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts per model call, including the
+	// first (0 = 5, 1 = no retries).
+	MaxAttempts int
+
+	// InitialBackoff is the delay before the first retry (0 = 1s).
+	InitialBackoff time.Duration
+
+	// MaxBackoff caps the delay between attempts, including a delay
+	// requested by the server (0 = 30s).
+	MaxBackoff time.Duration
+
+	// Multiplier grows the delay after each attempt (0 = 2).
+	Multiplier float64
+
+	// Jitter randomizes each delay by up to this fraction, e.g. 0.2 for
+	// ±20%, so concurrent agents don't retry in lockstep (0 = 0.2,
+	// negative = none).
+	Jitter float64
+
+	// FallbackAfter is the number of failed attempts on the primary model
+	// before the remaining attempts of a call use the fallback model
+	// (0 = 2). Only applies when a fallback model is configured.
+	FallbackAfter int
+}
+
+// withDefaults returns the policy with zero fields set to their defaults.
+func (p RetryPolicy) withDefaults() RetryPolicy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 5
+	}
+	if p.InitialBackoff <= 0 {
+		p.InitialBackoff = time.Second
+	}
+	if p.MaxBackoff <= 0 {
+		p.MaxBackoff = 30 * time.Second
+	}
+	if p.Multiplier <= 0 {
+		p.Multiplier = 2
+	}
+	if p.Jitter == 0 {
+		p.Jitter = 0.2
+	}
+	if p.FallbackAfter <= 0 {
+		p.FallbackAfter = 2
+	}
+	return p
+}
+
+// backoff returns the delay before the given retry (1-based). The delay
+// requested by the server takes precedence when it is longer, up to
+// MaxBackoff.
+func (p RetryPolicy) backoff(retry int, requested time.Duration) time.Duration {
+	delay := float64(p.InitialBackoff) * math.Pow(p.Multiplier, float64(retry-1))
+	delay = min(delay, float64(p.MaxBackoff))
+	if p.Jitter > 0 {
+		delay *= 1 + p.Jitter*(2*rand.Float64()-1)
+	}
+
+	return max(time.Duration(delay), min(requested, p.MaxBackoff))
+}
+
+// isRetryable reports whether a failed model call may succeed if repeated:
+// rate limits, server errors, timeouts and dropped connections.
+// Errors caused by the caller's context ending are never retried.
+func isRetryable(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+
+	var apiErr genai.APIError
+	if errors.As(err, &apiErr) {
+		switch apiErr.Code {
+		case 408, 429, 500, 502, 503, 504:
+			return true
+		}
+		return false
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+	return false
+}
+
+// retryAfter returns the delay the server asked for in the RetryInfo detail
+// of a Gemini API error. Returns 0 if there is none.
+func retryAfter(err error) time.Duration {
+	var apiErr genai.APIError
+	if !errors.As(err, &apiErr) {
+		return 0
+	}
+	for _, detail := range apiErr.Details {
+		if typ, _ := detail["@type"].(string); !strings.HasSuffix(typ, "google.rpc.RetryInfo") {
+			continue
+		}
+		if delay, ok := detail["retryDelay"].(string); ok {
+			if d, err := time.ParseDuration(delay); err == nil {
+				return d
+			}
+		}
+	}
+	return 0
+}
+
+// parseRetryAfter parses a Retry-After header, either in seconds or as an
+// HTTP date. Returns 0 if the header is missing or invalid.
+func parseRetryAfter(header string, now time.Time) time.Duration {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(header); err == nil {
+		return max(time.Duration(seconds)*time.Second, 0)
+	}
+	if at, err := http.ParseTime(header); err == nil {
+		return max(at.Sub(now), 0)
+	}
+	return 0
+}
+
+// retryAfterKey is the context key of the retryAfterHint of a model call.
+type retryAfterKey struct{}
+
+// retryAfterHint receives the Retry-After header of a failed model call,
+// since genai.APIError doesn't carry the response headers.
+type retryAfterHint struct {
+	delay atomic.Int64
+}
+
+// retryAfterTransport records the Retry-After header of failed responses
+// in the retryAfterHint of the request's context.
+type retryAfterTransport struct {
+	base http.RoundTripper
+}
+
+// RoundTrip sends the request and records its Retry-After header.
+func (t retryAfterTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || resp.StatusCode < 400 {
+		return resp, err
+	}
+	if hint, ok := req.Context().Value(retryAfterKey{}).(*retryAfterHint); ok {
+		hint.delay.Store(int64(parseRetryAfter(resp.Header.Get("Retry-After"), time.Now())))
+	}
+	return resp, nil
+}
+
+// newModelHTTPClient returns the HTTP client for model API calls, which
+// reports Retry-After headers to the retry policy.
+func newModelHTTPClient() *http.Client {
+	return &http.Client{Transport: retryAfterTransport{base: http.DefaultTransport}}
+}
+
+// retryModel wraps a model so transient errors are retried with backoff,
+// switching to a fallback model once the primary keeps failing.
+type retryModel struct {
+	primary  model.LLM
+	fallback model.LLM // nil = no fallback
+	policy   RetryPolicy
+	retries  *atomic.Int64 // Shared count of retried calls
+	debug    bool
+}
+
+// newRetryModel wraps primary with the retry policy. retries is incremented
+// for every retry.
+func newRetryModel(primary, fallback model.LLM, policy RetryPolicy, retries *atomic.Int64, debug bool) *retryModel {
+	return &retryModel{
+		primary:  primary,
+		fallback: fallback,
+		policy:   policy.withDefaults(),
+		retries:  retries,
+		debug:    debug,
+	}
+}
+
+// Name returns the primary model's name.
+func (m *retryModel) Name() string {
+	return m.primary.Name()
+}
+
+// GenerateContent calls the model, retrying retryable errors. A streamed
+// call is only retried if it failed before yielding anything.
+func (m *retryModel) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		for attempt := 1; ; attempt++ {
+			llm := m.primary
+			if m.fallback != nil && attempt > m.policy.FallbackAfter {
+				llm = m.fallback
+			}
+
+			hint := new(retryAfterHint)
+			callCtx := context.WithValue(ctx, retryAfterKey{}, hint)
+
+			var failure error
+			yielded := false
+			for resp, err := range llm.GenerateContent(callCtx, req, stream) {
+				if err != nil {
+					failure = err
+					break
+				}
+				yielded = true
+				if !yield(resp, nil) {
+					return
+				}
+			}
+			if failure == nil {
+				return
+			}
+			if yielded || attempt >= m.policy.MaxAttempts || !isRetryable(ctx, failure) {
+				yield(nil, failure)
+				return
+			}
+
+			requested := max(retryAfter(failure), time.Duration(hint.delay.Load()))
+			delay := m.policy.backoff(attempt, requested)
+			m.retries.Add(1)
+			if m.debug {
+				fmt.Printf("[Retry] %s attempt %d failed, retrying in %s: %v\n", llm.Name(), attempt, delay.Round(time.Millisecond), failure)
+			}
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				yield(nil, ctx.Err())
+				return
+			case <-timer.C:
+			}
+		}
+	}
+}
@@ -0,0 +1,331 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"google.golang.org/adk/model"
+	"google.golang.org/genai"
+)
+
+func TestIsRetryable(t *testing.T) {
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want bool
+	}{
+		{name: "nil", err: nil, want: false},
+		{name: "408", err: genai.APIError{Code: 408}, want: true},
+		{name: "429", err: genai.APIError{Code: 429}, want: true},
+		{name: "500", err: genai.APIError{Code: 500}, want: true},
+		{name: "502", err: genai.APIError{Code: 502}, want: true},
+		{name: "503", err: genai.APIError{Code: 503}, want: true},
+		{name: "504", err: genai.APIError{Code: 504}, want: true},
+		{name: "400", err: genai.APIError{Code: 400}, want: false},
+		{name: "401", err: genai.APIError{Code: 401}, want: false},
+		{name: "403", err: genai.APIError{Code: 403}, want: false},
+		{name: "404", err: genai.APIError{Code: 404}, want: false},
+		{name: "501", err: genai.APIError{Code: 501}, want: false},
+		{name: "wrapped 429", err: fmt.Errorf("generate: %w", genai.APIError{Code: 429}), want: true},
+		{name: "deadline exceeded", err: context.DeadlineExceeded, want: true},
+		{name: "unexpected EOF", err: io.ErrUnexpectedEOF, want: true},
+		{name: "other", err: errors.New("invalid request"), want: false},
+		{name: "caller canceled", ctx: canceled, err: genai.APIError{Code: 503}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			if got := isRetryable(ctx, tt.err); got != tt.want {
+				t.Errorf("isRetryable(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	retryInfo := func(delay string) error {
+		return genai.APIError{Code: 429, Details: []map[string]any{
+			{"@type": "type.googleapis.com/google.rpc.QuotaFailure"},
+			{"@type": "type.googleapis.com/google.rpc.RetryInfo", "retryDelay": delay},
+		}}
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want time.Duration
+	}{
+		{name: "retry info", err: retryInfo("7s"), want: 7 * time.Second},
+		{name: "fractional", err: retryInfo("1.5s"), want: 1500 * time.Millisecond},
+		{name: "invalid delay", err: retryInfo("soon"), want: 0},
+		{name: "no details", err: genai.APIError{Code: 429}, want: 0},
+		{name: "not an API error", err: errors.New("boom"), want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := retryAfter(tt.err); got != tt.want {
+				t.Errorf("retryAfter() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2025, 1, 2, 15, 4, 5, 0, time.UTC)
+
+	tests := []struct {
+		header string
+		want   time.Duration
+	}{
+		{header: "", want: 0},
+		{header: "0", want: 0},
+		{header: "5", want: 5 * time.Second},
+		{header: " 120 ", want: 2 * time.Minute},
+		{header: "-3", want: 0},
+		{header: "soon", want: 0},
+		{header: now.Add(30 * time.Second).Format(http.TimeFormat), want: 30 * time.Second},
+		{header: now.Add(-time.Minute).Format(http.TimeFormat), want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := parseRetryAfter(tt.header, now); got != tt.want {
+				t.Errorf("parseRetryAfter(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: time.Second, MaxBackoff: 10 * time.Second, Jitter: -1}.withDefaults()
+
+	tests := []struct {
+		name      string
+		retry     int
+		requested time.Duration
+		want      time.Duration
+	}{
+		{name: "first retry", retry: 1, want: time.Second},
+		{name: "exponential", retry: 3, want: 4 * time.Second},
+		{name: "capped", retry: 10, want: 10 * time.Second},
+		{name: "longer requested delay", retry: 1, requested: 5 * time.Second, want: 5 * time.Second},
+		{name: "shorter requested delay", retry: 3, requested: time.Second, want: 4 * time.Second},
+		{name: "requested delay capped", retry: 1, requested: time.Hour, want: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := policy.backoff(tt.retry, tt.requested); got != tt.want {
+				t.Errorf("backoff(%d, %v) = %v, want %v", tt.retry, tt.requested, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBackoffJitter(t *testing.T) {
+	policy := RetryPolicy{InitialBackoff: time.Second, MaxBackoff: time.Minute, Jitter: 0.25}.withDefaults()
+
+	for retry := 1; retry <= 5; retry++ {
+		base := time.Second << (retry - 1)
+		low := time.Duration(float64(base) * 0.75)
+		high := time.Duration(float64(base) * 1.25)
+		for range 200 {
+			if got := policy.backoff(retry, 0); got < low || got > high {
+				t.Fatalf("backoff(%d) = %v, want within [%v, %v]", retry, got, low, high)
+			}
+		}
+	}
+}
+
+func TestRetryAfterTransport(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		header string
+		want   time.Duration
+	}{
+		{name: "seconds", status: http.StatusTooManyRequests, header: "3", want: 3 * time.Second},
+		{name: "no header", status: http.StatusServiceUnavailable, want: 0},
+		{name: "success ignored", status: http.StatusOK, header: "3", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.header != "" {
+					w.Header().Set("Retry-After", tt.header)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			hint := new(retryAfterHint)
+			ctx := context.WithValue(context.Background(), retryAfterKey{}, hint)
+			req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := newModelHTTPClient().Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+
+			if got := time.Duration(hint.delay.Load()); got != tt.want {
+				t.Errorf("recorded delay = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// fakeLLM is a model whose calls fail with the queued errors, then succeed.
+type fakeLLM struct {
+	name  string
+	fails []error
+	calls int
+
+	// yieldFirst yields a response before failing.
+	yieldFirst bool
+}
+
+func (m *fakeLLM) Name() string { return m.name }
+
+func (m *fakeLLM) GenerateContent(ctx context.Context, req *model.LLMRequest, stream bool) iter.Seq2[*model.LLMResponse, error] {
+	return func(yield func(*model.LLMResponse, error) bool) {
+		m.calls++
+		if len(m.fails) > 0 {
+			err := m.fails[0]
+			m.fails = m.fails[1:]
+			if m.yieldFirst && !yield(&model.LLMResponse{Partial: true}, nil) {
+				return
+			}
+			yield(nil, err)
+			return
+		}
+		yield(&model.LLMResponse{Content: genai.NewContentFromText(m.name, "model")}, nil)
+	}
+}
+
+func TestRetryModel(t *testing.T) {
+	unavailable := genai.APIError{Code: 503}
+	policy := RetryPolicy{MaxAttempts: 4, InitialBackoff: time.Millisecond, MaxBackoff: time.Millisecond, FallbackAfter: 2}
+
+	tests := []struct {
+		name          string
+		primary       *fakeLLM
+		fallback      *fakeLLM
+		wantErr       bool
+		wantFrom      string
+		wantPrimary   int
+		wantFallback  int
+		wantRetries   int64
+		wantResponses int
+	}{
+		{
+			name:          "succeeds first time",
+			primary:       &fakeLLM{name: "primary"},
+			wantFrom:      "primary",
+			wantPrimary:   1,
+			wantResponses: 1,
+		},
+		{
+			name:          "retries transient error",
+			primary:       &fakeLLM{name: "primary", fails: []error{unavailable}},
+			wantFrom:      "primary",
+			wantPrimary:   2,
+			wantRetries:   1,
+			wantResponses: 1,
+		},
+		{
+			name:          "switches to fallback",
+			primary:       &fakeLLM{name: "primary", fails: []error{unavailable, unavailable, unavailable}},
+			fallback:      &fakeLLM{name: "fallback"},
+			wantFrom:      "fallback",
+			wantPrimary:   2,
+			wantFallback:  1,
+			wantRetries:   2,
+			wantResponses: 1,
+		},
+		{
+			name:        "gives up after max attempts",
+			primary:     &fakeLLM{name: "primary", fails: []error{unavailable, unavailable, unavailable, unavailable}},
+			wantErr:     true,
+			wantPrimary: 4,
+			wantRetries: 3,
+		},
+		{
+			name:        "permanent error",
+			primary:     &fakeLLM{name: "primary", fails: []error{genai.APIError{Code: 400}}},
+			wantErr:     true,
+			wantPrimary: 1,
+		},
+		{
+			name:          "no retry after a response",
+			primary:       &fakeLLM{name: "primary", fails: []error{unavailable}, yieldFirst: true},
+			wantErr:       true,
+			wantPrimary:   1,
+			wantResponses: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fallback model.LLM
+			if tt.fallback != nil {
+				fallback = tt.fallback
+			}
+			retries := new(atomic.Int64)
+			m := newRetryModel(tt.primary, fallback, policy, retries, false)
+
+			var responses int
+			var from string
+			var gotErr error
+			for resp, err := range m.GenerateContent(context.Background(), &model.LLMRequest{}, false) {
+				if err != nil {
+					gotErr = err
+					break
+				}
+				responses++
+				if resp.Content != nil {
+					from = resp.Content.Parts[0].Text
+				}
+			}
+
+			if (gotErr != nil) != tt.wantErr {
+				t.Fatalf("error = %v, wantErr %v", gotErr, tt.wantErr)
+			}
+			if !tt.wantErr && from != tt.wantFrom {
+				t.Errorf("response from %q, want %q", from, tt.wantFrom)
+			}
+			if responses != tt.wantResponses {
+				t.Errorf("responses = %d, want %d", responses, tt.wantResponses)
+			}
+			if tt.primary.calls != tt.wantPrimary {
+				t.Errorf("primary calls = %d, want %d", tt.primary.calls, tt.wantPrimary)
+			}
+			if tt.fallback != nil && tt.fallback.calls != tt.wantFallback {
+				t.Errorf("fallback calls = %d, want %d", tt.fallback.calls, tt.wantFallback)
+			}
+			if got := retries.Load(); got != tt.wantRetries {
+				t.Errorf("retries = %d, want %d", got, tt.wantRetries)
+			}
+		})
+	}
+}
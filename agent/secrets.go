@@ -0,0 +1,72 @@
+package agent
+
+import (
+	"encoding/json"
+
+	"github.com/anxuanzi/bua/browser"
+	"google.golang.org/adk/tool"
+)
+
+// secretScrubber hides secret values in tool results before the model sees
+// them, e.g. a password read back by evaluate_js or returned by a custom
+// tool. The browser already scrubs the page state and extracted content.
+type secretScrubber struct {
+	browser *browser.Browser
+}
+
+// newSecretScrubber creates a scrubber for the browser's secrets.
+func newSecretScrubber(b *browser.Browser) *secretScrubber {
+	return &secretScrubber{browser: b}
+}
+
+// after is an ADK AfterToolCallback. It scrubs the result in place and
+// returns nil so later callbacks still run, except for tool errors, which
+// it replaces with a scrubbed error result.
+func (s *secretScrubber) after(ctx tool.Context, tl tool.Tool, args, result map[string]any, err error) (map[string]any, error) {
+	if err != nil {
+		return map[string]any{"error": s.browser.ScrubSecrets(err.Error())}, nil
+	}
+	for key, value := range result {
+		result[key] = s.scrub(value)
+	}
+	return nil, nil
+}
+
+// scrub returns a value with the secrets in all its strings scrubbed.
+func (s *secretScrubber) scrub(value any) any {
+	switch v := value.(type) {
+	case string:
+		return s.browser.ScrubSecrets(v)
+	case map[string]any:
+		for key, item := range v {
+			v[key] = s.scrub(item)
+		}
+		return v
+	case []any:
+		for i, item := range v {
+			v[i] = s.scrub(item)
+		}
+		return v
+	case []string:
+		for i, item := range v {
+			v[i] = s.browser.ScrubSecrets(item)
+		}
+		return v
+	default:
+		// Other types, e.g. structs returned by custom tools, are scrubbed
+		// in their JSON form
+		data, err := json.Marshal(value)
+		if err != nil {
+			return value
+		}
+		scrubbed := s.browser.ScrubSecrets(string(data))
+		if scrubbed == string(data) {
+			return value
+		}
+		var decoded any
+		if err := json.Unmarshal([]byte(scrubbed), &decoded); err != nil {
+			return nil
+		}
+		return decoded
+	}
+}
@@ -0,0 +1,90 @@
+package agent
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/anxuanzi/bua/browser"
+)
+
+func TestSecretScrubberScrub(t *testing.T) {
+	b, err := browser.New(browser.Config{Secrets: map[string]string{"password": "hunter2"}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	s := newSecretScrubber(b)
+
+	type result struct {
+		Output string `json:"output"`
+	}
+
+	tests := []struct {
+		name  string
+		value any
+		want  any
+	}{
+		{name: "string", value: "pw hunter2", want: "pw <secret>password</secret>"},
+		{name: "string without secret", value: "nothing", want: "nothing"},
+		{name: "number", value: 42, want: 42},
+		{name: "nil", value: nil, want: nil},
+		{
+			name:  "nested map",
+			value: map[string]any{"a": map[string]any{"b": "hunter2"}, "n": 1},
+			want:  map[string]any{"a": map[string]any{"b": "<secret>password</secret>"}, "n": 1},
+		},
+		{
+			name:  "slice of any",
+			value: []any{"hunter2", 3, []any{"x hunter2"}},
+			want:  []any{"<secret>password</secret>", 3, []any{"x <secret>password</secret>"}},
+		},
+		{
+			name:  "slice of strings",
+			value: []string{"hunter2", "ok"},
+			want:  []string{"<secret>password</secret>", "ok"},
+		},
+		{
+			name:  "struct with secret",
+			value: result{Output: "hunter2"},
+			want:  map[string]any{"output": "<secret>password</secret>"},
+		},
+		{
+			name:  "struct without secret",
+			value: result{Output: "ok"},
+			want:  result{Output: "ok"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.scrub(tt.value); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("scrub(%#v) = %#v, want %#v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecretScrubberAfter(t *testing.T) {
+	b, err := browser.New(browser.Config{Secrets: map[string]string{"password": "hunter2"}})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	s := newSecretScrubber(b)
+
+	result := map[string]any{"success": true, "message": "typed hunter2"}
+	replaced, err := s.after(nil, nil, nil, result, nil)
+	if replaced != nil || err != nil {
+		t.Fatalf("after() = %v, %v, want nil, nil", replaced, err)
+	}
+	if got := result["message"]; got != "typed <secret>password</secret>" {
+		t.Errorf("message = %q, want it scrubbed", got)
+	}
+
+	replaced, err = s.after(nil, nil, nil, nil, errors.New("bad password hunter2"))
+	if err != nil {
+		t.Fatalf("after() error = %v", err)
+	}
+	if got := replaced["error"]; got != "bad password <secret>password</secret>" {
+		t.Errorf("error = %q, want it scrubbed", got)
+	}
+}
@@ -0,0 +1,75 @@
+package agent
+
+import (
+	"sync"
+	"time"
+
+	"google.golang.org/adk/tool"
+)
+
+// toolTimer measures how long each tool call runs using ADK tool callbacks,
+// since the runner reports all responses of a turn in a single event.
+// Calls are keyed by function call ID, or by tool name if the ID is empty.
+type toolTimer struct {
+	mu        sync.Mutex
+	starts    map[string]time.Time
+	durations map[string][]time.Duration
+}
+
+// newToolTimer creates an empty tool timer.
+func newToolTimer() *toolTimer {
+	return &toolTimer{
+		starts:    make(map[string]time.Time),
+		durations: make(map[string][]time.Duration),
+	}
+}
+
+// timerKey identifies a tool call.
+func timerKey(callID, name string) string {
+	if callID != "" {
+		return callID
+	}
+	return name
+}
+
+// before is an ADK BeforeToolCallback that starts the clock. It must be the
+// first callback so skipped calls are timed too.
+func (t *toolTimer) before(ctx tool.Context, tl tool.Tool, args map[string]any) (map[string]any, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.starts[timerKey(ctx.FunctionCallID(), tl.Name())] = time.Now()
+	return nil, nil
+}
+
+// after is an ADK AfterToolCallback that stops the clock. It leaves the
+// tool's result unchanged.
+func (t *toolTimer) after(ctx tool.Context, tl tool.Tool, args, result map[string]any, err error) (map[string]any, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := timerKey(ctx.FunctionCallID(), tl.Name())
+	if start, ok := t.starts[key]; ok {
+		t.durations[key] = append(t.durations[key], time.Since(start))
+		delete(t.starts, key)
+	}
+	return nil, nil
+}
+
+// take returns and forgets the duration of a finished tool call.
+func (t *toolTimer) take(callID, name string) time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	key := timerKey(callID, name)
+	durations := t.durations[key]
+	if len(durations) == 0 {
+		return 0
+	}
+	if len(durations) == 1 {
+		delete(t.durations, key)
+	} else {
+		t.durations[key] = durations[1:]
+	}
+	return durations[0]
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"time"
 
@@ -40,12 +41,39 @@ type Config struct {
 	// Debug enables verbose logging.
 	Debug bool
 
+	// IncludeOffscreenElements extracts interactive elements from the whole page
+	// instead of only the viewport. Offscreen elements are marked as not visible.
+	IncludeOffscreenElements bool
+
+	// URLPolicy restricts which pages may be loaded. Default: no restrictions.
+	URLPolicy URLPolicy
+
+	// Secrets are values typed in place of <secret>name</secret>
+	// placeholders by TypeText and ClearAndType, keyed by name. They are
+	// replaced by their placeholders in element maps and extracted content.
+	Secrets map[string]string
+
 	// ShowAnnotations enables element annotations on screenshots.
 	// When true, screenshots include bounding boxes and index labels.
 	ShowAnnotations bool
 
 	// Stealth configures anti-detection measures.
 	Stealth StealthConfig
+
+	// ActionTimeout bounds a single action such as a click, typing, a
+	// script evaluation, an extraction or a screenshot. Default: 30s.
+	// Negative disables the limit.
+	ActionTimeout time.Duration
+
+	// NavigationTimeout bounds navigating, going back or forward, reloading
+	// and opening a tab, including waiting for the page to load.
+	// Default: 60s. Negative disables the limit.
+	NavigationTimeout time.Duration
+
+	// SettleTimeout bounds how long actions wait afterwards for the page to
+	// load and for network and DOM to go quiet. A page that keeps changing
+	// is used as is once it expires. Default: 5s.
+	SettleTimeout time.Duration
 }
 
 // DefaultConfig returns a default browser configuration.
@@ -57,6 +85,9 @@ func DefaultConfig() Config {
 		ShowHighlight:     true,
 		HighlightDuration: 300 * time.Millisecond,
 		Stealth:           DefaultStealthConfig(),
+		ActionTimeout:     defaultActionTimeout,
+		NavigationTimeout: defaultNavigationTimeout,
+		SettleTimeout:     defaultSettleTimeout,
 	}
 }
 
@@ -84,6 +115,20 @@ type Browser struct {
 	// Temporary profile path for cleanup
 	tempProfilePath string
 
+	// Last mouse position, the start of the next linear move
+	mouse proto.Point
+
+	// Replaces secret values by their placeholders, nil without secrets
+	secretScrubber *strings.Replacer
+
+	// Last page load blocked by the URL policy, see TakeBlocked
+	blocked   error
+	blockedMu sync.Mutex
+
+	// Crash detection and recovery
+	lost     string    // Why the browser became unusable, empty while healthy
+	snapshot *Snapshot // State restored by Relaunch
+
 	mu sync.RWMutex
 }
 
@@ -104,6 +149,16 @@ func New(cfg Config) (*Browser, error) {
 	if cfg.HighlightDuration == 0 {
 		b.config.HighlightDuration = 300 * time.Millisecond
 	}
+	if cfg.ActionTimeout == 0 {
+		b.config.ActionTimeout = defaultActionTimeout
+	}
+	if cfg.NavigationTimeout == 0 {
+		b.config.NavigationTimeout = defaultNavigationTimeout
+	}
+	if cfg.SettleTimeout <= 0 {
+		b.config.SettleTimeout = defaultSettleTimeout
+	}
+	b.secretScrubber = newSecretScrubber(cfg.Secrets)
 
 	return b, nil
 }
@@ -191,6 +246,11 @@ func (b *Browser) Start(ctx context.Context) error {
 		return fmt.Errorf("failed to connect to browser: %w", err)
 	}
 	b.rod = browser
+	go b.watch(browser)
+
+	if err := b.enforcePolicyOnPopups(browser); err != nil {
+		return err
+	}
 
 	// Set browser window size to match viewport (ensures consistency)
 	if !b.config.Headless {
@@ -235,6 +295,10 @@ func (b *Browser) Start(ctx context.Context) error {
 		return fmt.Errorf("failed to set viewport: %w", err)
 	}
 
+	if err := b.enforcePolicy(page); err != nil {
+		return err
+	}
+
 	// Register initial tab
 	tabID := generateTabID()
 	b.pages[tabID] = page
@@ -242,6 +306,7 @@ func (b *Browser) Start(ctx context.Context) error {
 
 	// Create extractor
 	b.extractor = dom.NewExtractor(100)
+	b.extractor.SetIncludeOffscreen(b.config.IncludeOffscreenElements)
 
 	return nil
 }
@@ -338,21 +403,28 @@ func (b *Browser) ListTabs() []TabInfo {
 	return tabs
 }
 
-// NewTab creates a new tab and optionally navigates to a URL.
+// NewTab creates a new tab and optionally navigates to a URL. The browser
+// lock is not held while the tab loads, so other tabs stay usable.
 func (b *Browser) NewTab(ctx context.Context, url string) (string, error) {
-	b.mu.Lock()
-	defer b.mu.Unlock()
+	b.mu.RLock()
+	rodBrowser := b.rod
+	err := b.errLocked()
+	b.mu.RUnlock()
 
-	if b.rod == nil {
-		return "", fmt.Errorf("browser not started")
+	if rodBrowser == nil {
+		return "", fmt.Errorf("%w: browser not started", ErrBrowserClosed)
+	}
+	if err != nil {
+		return "", err
 	}
 
-	targetURL := "about:blank"
-	if url != "" {
-		targetURL = url
+	if err := b.config.URLPolicy.Check(url); err != nil {
+		return "", err
 	}
 
-	page, err := b.rod.Page(proto.TargetCreateTarget{URL: targetURL})
+	// Open the tab blank so stealth scripts and the URL policy are in place
+	// before the page loads
+	page, err := rodBrowser.Page(proto.TargetCreateTarget{URL: "about:blank"})
 	if err != nil {
 		return "", fmt.Errorf("failed to create new tab: %w", err)
 	}
@@ -371,11 +443,38 @@ func (b *Browser) NewTab(ctx context.Context, url string) (string, error) {
 		Width:  b.config.ViewportWidth,
 		Height: b.config.ViewportHeight,
 	}); err != nil {
+		_ = page.Close()
 		return "", fmt.Errorf("failed to set viewport: %w", err)
 	}
 
+	if err := b.enforcePolicy(page); err != nil {
+		_ = page.Close()
+		return "", err
+	}
+
 	if url != "" {
-		_ = page.WaitStable(500 * time.Millisecond)
+		ctx, cancel := limit(ctx, b.config.NavigationTimeout)
+		err := page.Context(ctx).Navigate(url)
+		if err == nil {
+			b.settle(ctx, page)
+		}
+		cancel()
+		if err != nil {
+			_ = page.Close()
+			if blocked := b.TakeBlocked(); blocked != nil {
+				return "", blocked
+			}
+			return "", navigationFailed(url, nil, err)
+		}
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// The browser may have been closed or relaunched while the tab loaded
+	if b.rod != rodBrowser {
+		_ = page.Close()
+		return "", fmt.Errorf("browser was closed while opening the tab: %w", ErrBrowserClosed)
 	}
 
 	tabID := generateTabID()
@@ -392,7 +491,7 @@ func (b *Browser) SwitchTab(tabID string) error {
 
 	page, ok := b.pages[tabID]
 	if !ok {
-		return fmt.Errorf("tab not found: %s", tabID)
+		return fmt.Errorf("%w: %s", ErrTabNotFound, tabID)
 	}
 
 	// Bring tab to front
@@ -411,12 +510,12 @@ func (b *Browser) CloseTab(tabID string) error {
 
 	page, ok := b.pages[tabID]
 	if !ok {
-		return fmt.Errorf("tab not found: %s", tabID)
+		return fmt.Errorf("%w: %s", ErrTabNotFound, tabID)
 	}
 
 	// Can't close the last tab
 	if len(b.pages) <= 1 {
-		return fmt.Errorf("cannot close the last tab")
+		return fmt.Errorf("%w: cannot close the last tab", ErrInvalidArgument)
 	}
 
 	if err := page.Close(); err != nil {
@@ -438,27 +537,43 @@ func (b *Browser) CloseTab(tabID string) error {
 
 // GetElementMap extracts interactive elements from the current page.
 func (b *Browser) GetElementMap(ctx context.Context) (*dom.ElementMap, error) {
-	page := b.ActivePage()
-	if page == nil {
-		return nil, fmt.Errorf("no active page")
+	ctx, cancel := limit(ctx, b.config.ActionTimeout)
+	defer cancel()
+
+	page, err := b.boundPage(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	return b.extractor.Extract(ctx, page)
+	em, err := b.extractor.Extract(ctx, page)
+	if err != nil {
+		return nil, err
+	}
+	if b.secretScrubber != nil {
+		em.Redact(b.scrubPageText)
+	}
+	return em, nil
 }
 
 // SetMaxElements sets the maximum number of elements to extract.
 func (b *Browser) SetMaxElements(max int) {
 	b.extractor = dom.NewExtractor(max)
+	b.extractor.SetIncludeOffscreen(b.config.IncludeOffscreenElements)
 }
 
-// WaitStable waits for the page to become stable.
+// WaitStable waits up to SettleTimeout for the page to load and for network
+// and DOM to go quiet. A page still busy after that is not an error; only
+// ctx ending is.
 func (b *Browser) WaitStable(ctx context.Context) error {
-	page := b.ActivePage()
-	if page == nil {
-		return fmt.Errorf("no active page")
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	page, err := b.boundPage(ctx)
+	if err != nil {
+		return err
 	}
-	_ = ctx // Context available for future use
-	return page.WaitStable(500 * time.Millisecond)
+	b.settle(ctx, page)
+	return ctx.Err()
 }
 
 // generateTabID creates a unique 4-character tab ID.
@@ -0,0 +1,61 @@
+package browser
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-rod/rod"
+)
+
+// Default per-call limits, see Config.
+const (
+	defaultActionTimeout     = 30 * time.Second
+	defaultNavigationTimeout = 60 * time.Second
+	defaultSettleTimeout     = 5 * time.Second
+)
+
+// stableQuiet is how long network and DOM must be quiet for the page to
+// count as settled.
+const stableQuiet = 500 * time.Millisecond
+
+// limit derives the context for one browser call from the caller's ctx,
+// which may be nil, bounded by timeout unless it is negative.
+func limit(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if timeout < 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// boundPage returns the active page bound to ctx, so rod calls on it stop
+// once ctx is done.
+func (b *Browser) boundPage(ctx context.Context) (*rod.Page, error) {
+	page, err := b.page()
+	if err != nil {
+		return nil, err
+	}
+	return page.Context(ctx), nil
+}
+
+// settle waits up to SettleTimeout for the page to load and for network and
+// DOM to go quiet. It gives up silently: a busy page is still usable.
+func (b *Browser) settle(ctx context.Context, page *rod.Page) {
+	ctx, cancel := context.WithTimeout(ctx, b.config.SettleTimeout)
+	defer cancel()
+	_ = page.Context(ctx).WaitStable(stableQuiet)
+}
+
+// sleep pauses for d, returning early with ctx's error if it is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
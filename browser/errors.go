@@ -0,0 +1,164 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-rod/rod"
+
+	"github.com/anxuanzi/bua/dom"
+)
+
+// Errors returned by browser operations. Failures are wrapped, so test for
+// them with errors.Is.
+var (
+	// ErrBrowserClosed is returned when the browser process exits, the CDP
+	// connection drops or a tab crashes while the browser is in use.
+	ErrBrowserClosed = errors.New("bua: browser was closed")
+
+	// ErrElementNotFound is returned when an element index is not in the
+	// current page state.
+	ErrElementNotFound = errors.New("bua: element not found")
+
+	// ErrElementNotVisible is returned when a pointer action targets an
+	// element outside the viewport.
+	ErrElementNotVisible = errors.New("bua: element is not visible")
+
+	// ErrNavigationFailed is returned when page navigation fails.
+	ErrNavigationFailed = errors.New("bua: navigation failed")
+
+	// ErrTimeout is returned when an operation times out.
+	ErrTimeout = errors.New("bua: operation timed out")
+
+	// ErrURLBlocked is returned when the URL policy rejects a page load.
+	ErrURLBlocked = errors.New("bua: URL blocked by policy")
+
+	// ErrUnknownSecret is returned when typed text has a placeholder for a
+	// secret that is not configured.
+	ErrUnknownSecret = errors.New("bua: unknown secret")
+
+	// ErrInvalidArgument is returned when an operation is called with an
+	// argument it can't act on, such as an unknown scroll direction.
+	ErrInvalidArgument = errors.New("bua: invalid argument")
+
+	// ErrTabNotFound is returned when a tab ID does not match an open tab.
+	ErrTabNotFound = errors.New("bua: tab not found")
+)
+
+// ElementError reports an action that failed on an element. It wraps
+// ErrElementNotFound, ErrElementNotVisible or the underlying failure.
+type ElementError struct {
+	Index  int
+	Reason string
+	Err    error
+}
+
+// Error implements error.
+func (e *ElementError) Error() string {
+	msg := fmt.Sprintf("element [%d]: %s", e.Index, e.Reason)
+	if e.Err != nil && e.Err != ErrElementNotFound && e.Err != ErrElementNotVisible {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the cause.
+func (e *ElementError) Unwrap() error {
+	return e.Err
+}
+
+// NavigationError reports a failed navigation. It wraps ErrNavigationFailed
+// and the underlying failure.
+type NavigationError struct {
+	URL        string
+	HTTPStatus int    // Status of the main document response, 0 if none was received
+	NetErr     string // Chrome network error such as "net::ERR_NAME_NOT_RESOLVED"
+	Err        error
+}
+
+// Error implements error.
+func (e *NavigationError) Error() string {
+	msg := fmt.Sprintf("navigation to %s failed", e.URL)
+	switch {
+	case e.NetErr != "":
+		msg += ": " + e.NetErr
+	case e.HTTPStatus != 0:
+		msg += fmt.Sprintf(": HTTP %d", e.HTTPStatus)
+	case e.Err != nil:
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns ErrNavigationFailed and the cause.
+func (e *NavigationError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{ErrNavigationFailed}
+	}
+	return []error{ErrNavigationFailed, e.Err}
+}
+
+// PolicyError reports a page load rejected by the URL policy. It wraps
+// ErrURLBlocked.
+type PolicyError struct {
+	URL    string
+	Reason string
+}
+
+// Error implements error.
+func (e *PolicyError) Error() string {
+	return fmt.Sprintf("%s blocked by URL policy: %s", e.URL, e.Reason)
+}
+
+// Unwrap returns ErrURLBlocked.
+func (e *PolicyError) Unwrap() error {
+	return ErrURLBlocked
+}
+
+// withTimeout marks deadline failures as ErrTimeout.
+func withTimeout(err error) error {
+	if err != nil && errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, ErrTimeout) {
+		return fmt.Errorf("%w: %w", ErrTimeout, err)
+	}
+	return err
+}
+
+// elementFailed wraps a failed action on an element.
+func elementFailed(index int, reason string, err error) error {
+	return &ElementError{Index: index, Reason: reason, Err: withTimeout(err)}
+}
+
+// navigationFailed wraps a failed navigation with what was recorded of it,
+// taking the network error from rod's navigation error if there is one.
+func navigationFailed(url string, result *NavigationResult, err error) error {
+	navErr := &NavigationError{URL: url, Err: withTimeout(err)}
+	if result != nil {
+		navErr.HTTPStatus = result.HTTPStatus
+		navErr.NetErr = result.NetErr
+	}
+	var rodErr *rod.NavigationError
+	if errors.As(err, &rodErr) {
+		navErr.NetErr = rodErr.Reason
+	}
+	if navErr.NetErr == "" && navErr.Err == nil {
+		navErr.NetErr = "Chrome showed an error page"
+	}
+	return navErr
+}
+
+// lookupElement looks up an element by index. Pointer actions act on the
+// element's on-screen coordinates, so they also need it in the viewport.
+func lookupElement(elementMap *dom.ElementMap, index int, pointer bool) (*dom.Element, error) {
+	if elementMap == nil {
+		return nil, &ElementError{Index: index, Reason: "no page state loaded", Err: ErrElementNotFound}
+	}
+	el, ok := elementMap.Get(index)
+	if !ok {
+		return nil, &ElementError{Index: index, Reason: "not in the current page state", Err: ErrElementNotFound}
+	}
+	if pointer && !el.IsVisible {
+		return nil, &ElementError{Index: index, Reason: "outside the viewport, scroll to it first", Err: ErrElementNotVisible}
+	}
+	return el, nil
+}
@@ -0,0 +1,160 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// Snapshot is the browser state restored by Relaunch.
+type Snapshot struct {
+	Tabs []TabInfo
+
+	// Storage is only captured for temporary profiles; named profiles keep
+	// cookies and localStorage on disk.
+	Storage *StorageState
+}
+
+// watch monitors a connected browser until its event stream ends. A crashed
+// tab or a dropped connection marks the browser as lost, unless the browser
+// was closed or replaced on purpose.
+func (b *Browser) watch(rodBrowser *rod.Browser) {
+	wait := rodBrowser.EachEvent(func(e *proto.TargetTargetCrashed) {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+
+		if b.rod != rodBrowser || b.lost != "" {
+			return
+		}
+		for id, page := range b.pages {
+			if page.TargetID == e.TargetID {
+				b.lost = fmt.Sprintf("tab %s crashed (%s)", id, e.Status)
+				break
+			}
+		}
+	})
+	wait()
+
+	// The event stream only ends when the connection is gone
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.rod == rodBrowser && b.lost == "" {
+		b.lost = "the connection to the browser was lost"
+	}
+	if b.config.Debug && b.rod == rodBrowser {
+		fmt.Printf("[Browser] %s\n", b.lost)
+	}
+}
+
+// Err returns an error wrapping ErrBrowserClosed if the browser crashed or
+// disconnected, or nil while it is usable.
+func (b *Browser) Err() error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	return b.errLocked()
+}
+
+// errLocked is Err for callers holding the lock.
+func (b *Browser) errLocked() error {
+	if b.lost == "" {
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrBrowserClosed, b.lost)
+}
+
+// page returns the active page, or an error wrapping ErrBrowserClosed if
+// the browser is gone.
+func (b *Browser) page() (*rod.Page, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if err := b.errLocked(); err != nil {
+		return nil, err
+	}
+	page := b.pages[b.activeTabID]
+	if page == nil {
+		return nil, fmt.Errorf("%w: no active page", ErrBrowserClosed)
+	}
+	return page, nil
+}
+
+// Snapshot records the open tabs, and the storage state for temporary
+// profiles, so Relaunch can restore them. Call it whenever the browser is
+// in a state worth returning to.
+func (b *Browser) Snapshot(ctx context.Context) error {
+	if err := b.Err(); err != nil {
+		return err
+	}
+
+	snapshot := &Snapshot{Tabs: b.ListTabs()}
+
+	b.mu.RLock()
+	temporary := b.tempProfilePath != ""
+	b.mu.RUnlock()
+	if temporary {
+		storage, err := b.StorageState(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to capture storage state: %w", err)
+		}
+		snapshot.Storage = storage
+	}
+
+	b.mu.Lock()
+	b.snapshot = snapshot
+	b.mu.Unlock()
+	return nil
+}
+
+// Relaunch replaces a crashed or disconnected browser with a new one using
+// the same configuration and profile, then restores the tabs and storage of
+// the last Snapshot. Pages are reloaded, so unsaved input is lost.
+func (b *Browser) Relaunch(ctx context.Context) error {
+	b.mu.Lock()
+	old := b.rod
+	oldLauncher := b.launcher
+	tempPath := b.tempProfilePath
+	snapshot := b.snapshot
+	b.rod = nil
+	b.launcher = nil
+	b.pages = make(map[string]*rod.Page)
+	b.activeTabID = ""
+	b.tempProfilePath = ""
+	b.lost = ""
+	b.mu.Unlock()
+
+	// Make sure the old process is gone so the profile is free
+	if old != nil {
+		_ = old.Close()
+	}
+	if oldLauncher != nil {
+		oldLauncher.Kill()
+	}
+	if tempPath != "" {
+		_ = os.RemoveAll(tempPath)
+	}
+
+	if err := b.Start(ctx); err != nil {
+		return fmt.Errorf("failed to relaunch browser: %w", err)
+	}
+	if snapshot == nil {
+		return nil
+	}
+
+	if err := b.SetStorageState(ctx, snapshot.Storage); err != nil {
+		return fmt.Errorf("failed to restore storage state: %w", err)
+	}
+	if len(snapshot.Tabs) > 0 {
+		if err := b.RestoreTabs(ctx, snapshot.Tabs); err != nil {
+			return fmt.Errorf("failed to restore tabs: %w", err)
+		}
+	}
+
+	if b.config.Debug {
+		fmt.Printf("[Browser] Relaunched with %d tabs restored\n", len(snapshot.Tabs))
+	}
+	return nil
+}
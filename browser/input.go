@@ -0,0 +1,87 @@
+package browser
+
+import (
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/input"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// rod's Page.Mouse and Page.Keyboard always use the context of the page they
+// were created for, so a canceled action would keep waiting on them, e.g.
+// behind a JavaScript dialog. Input is dispatched on the context-bound page
+// instead.
+
+// moveMouse moves the mouse to a point in steps linear moves from its last
+// position.
+func (b *Browser) moveMouse(page *rod.Page, to proto.Point, steps int) error {
+	if steps < 1 {
+		steps = 1
+	}
+
+	b.mu.Lock()
+	from := b.mouse
+	b.mu.Unlock()
+
+	for i := 1; i <= steps; i++ {
+		t := float64(i) / float64(steps)
+		point := proto.Point{X: from.X + (to.X-from.X)*t, Y: from.Y + (to.Y-from.Y)*t}
+		err := proto.InputDispatchMouseEvent{
+			Type: proto.InputDispatchMouseEventTypeMouseMoved,
+			X:    point.X,
+			Y:    point.Y,
+		}.Call(page)
+		if err != nil {
+			return err
+		}
+
+		b.mu.Lock()
+		b.mouse = point
+		b.mu.Unlock()
+	}
+	return nil
+}
+
+// clickMouse presses and releases the left button at the mouse position.
+func (b *Browser) clickMouse(page *rod.Page, clickCount int) error {
+	b.mu.RLock()
+	at := b.mouse
+	b.mu.RUnlock()
+
+	pressed, released := 1, 0
+	for _, event := range []proto.InputDispatchMouseEvent{
+		{Type: proto.InputDispatchMouseEventTypeMousePressed, Buttons: &pressed},
+		{Type: proto.InputDispatchMouseEventTypeMouseReleased, Buttons: &released},
+	} {
+		event.Button = proto.InputMouseButtonLeft
+		event.ClickCount = clickCount
+		event.X, event.Y = at.X, at.Y
+		if err := event.Call(page); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scrollMouse turns the mouse wheel at the mouse position.
+func (b *Browser) scrollMouse(page *rod.Page, deltaX, deltaY float64) error {
+	b.mu.RLock()
+	at := b.mouse
+	b.mu.RUnlock()
+
+	return proto.InputDispatchMouseEvent{
+		Type:   proto.InputDispatchMouseEventTypeMouseWheel,
+		X:      at.X,
+		Y:      at.Y,
+		DeltaX: deltaX,
+		DeltaY: deltaY,
+	}.Call(page)
+}
+
+// typeKey presses and releases a key with modifiers held, e.g.
+// input.ModifierControl.
+func typeKey(page *rod.Page, key input.Key, modifiers int) error {
+	if err := key.Encode(proto.InputDispatchKeyEventTypeKeyDown, modifiers).Call(page); err != nil {
+		return err
+	}
+	return key.Encode(proto.InputDispatchKeyEventTypeKeyUp, modifiers).Call(page)
+}
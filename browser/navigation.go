@@ -0,0 +1,119 @@
+package browser
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// NavigationResult describes how a navigation went.
+type NavigationResult struct {
+	// URL is the final URL after redirects.
+	URL string
+
+	// HTTPStatus is the main document's response status. It is 0 when no
+	// response was received, e.g. for about:, data: or cached pages.
+	HTTPStatus int
+	StatusText string
+
+	// Redirects lists the redirect hops in order, starting from the
+	// requested URL.
+	Redirects []Redirect
+
+	// NetErr is Chrome's network error, e.g. "net::ERR_NAME_NOT_RESOLVED".
+	NetErr string
+}
+
+// Redirect is one hop of a redirect chain.
+type Redirect struct {
+	URL        string
+	HTTPStatus int
+}
+
+// OK reports whether the page loaded without an HTTP error status.
+func (r *NavigationResult) OK() bool {
+	return r.NetErr == "" && r.HTTPStatus < 400
+}
+
+// isErrorPage reports whether Chrome is showing its own error page.
+func isErrorPage(url string) bool {
+	return strings.HasPrefix(url, "chrome-error://")
+}
+
+// navigationRecorder follows the main document request of a page through
+// redirects to its response or failure.
+type navigationRecorder struct {
+	mu        sync.Mutex
+	frameID   proto.PageFrameID
+	requestID proto.NetworkRequestID
+	result    NavigationResult
+
+	stop func()
+	done chan struct{}
+}
+
+// recordNavigation starts recording the page's next navigation. Call
+// finish once the navigation is over.
+func recordNavigation(page *rod.Page) *navigationRecorder {
+	r := &navigationRecorder{frameID: page.FrameID, done: make(chan struct{})}
+
+	eventPage, cancel := page.WithCancel()
+	r.stop = cancel
+	wait := eventPage.EachEvent(
+		func(e *proto.NetworkRequestWillBeSent) {
+			if e.Type != proto.NetworkResourceTypeDocument || e.FrameID != r.frameID {
+				return
+			}
+			r.mu.Lock()
+			defer r.mu.Unlock()
+
+			// A redirect reuses the request ID of the request it redirects
+			if e.RedirectResponse != nil && e.RequestID == r.requestID {
+				r.result.Redirects = append(r.result.Redirects, Redirect{
+					URL:        e.RedirectResponse.URL,
+					HTTPStatus: e.RedirectResponse.Status,
+				})
+			}
+			r.requestID = e.RequestID
+			r.result.URL = e.Request.URL
+		},
+		func(e *proto.NetworkResponseReceived) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+
+			if e.RequestID != r.requestID || e.Response == nil {
+				return
+			}
+			r.result.URL = e.Response.URL
+			r.result.HTTPStatus = e.Response.Status
+			r.result.StatusText = e.Response.StatusText
+		},
+		func(e *proto.NetworkLoadingFailed) {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+
+			if e.RequestID == r.requestID && !e.Canceled {
+				r.result.NetErr = e.ErrorText
+			}
+		},
+	)
+	go func() {
+		wait()
+		close(r.done)
+	}()
+
+	return r
+}
+
+// finish stops recording and returns what was recorded.
+func (r *navigationRecorder) finish() *NavigationResult {
+	r.stop()
+	<-r.done
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	result := r.result
+	return &result
+}
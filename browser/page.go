@@ -13,11 +13,20 @@ import (
 	screenshotpkg "github.com/anxuanzi/bua/screenshot"
 )
 
-// Navigate navigates the current page to a URL.
-func (b *Browser) Navigate(ctx context.Context, url string) error {
-	page := b.ActivePage()
-	if page == nil {
-		return fmt.Errorf("no active page")
+// Navigate navigates the current page to a URL and reports the final URL,
+// redirects and HTTP status. Network failures such as DNS errors return a
+// *NavigationError wrapping ErrNavigationFailed; HTTP error statuses do not,
+// since the page still loads. Check NavigationResult.OK for those.
+func (b *Browser) Navigate(ctx context.Context, url string) (*NavigationResult, error) {
+	ctx, cancel := limit(ctx, b.config.NavigationTimeout)
+	defer cancel()
+
+	page, err := b.boundPage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := b.config.URLPolicy.Check(url); err != nil {
+		return nil, err
 	}
 
 	// Add human-like delay before navigation
@@ -25,97 +34,114 @@ func (b *Browser) Navigate(ctx context.Context, url string) error {
 		humanDelay(b.config.Stealth.MinDelay, b.config.Stealth.MaxDelay)
 	}
 
-	// Navigate to URL
+	// Navigate to URL, following the main document request
+	recorder := recordNavigation(page)
 	if err := page.Navigate(url); err != nil {
-		return fmt.Errorf("navigation failed: %w", err)
+		result := recorder.finish()
+		if blocked := b.TakeBlocked(); blocked != nil {
+			return nil, blocked // Redirected to a blocked URL
+		}
+		return nil, navigationFailed(url, result, err)
 	}
 
 	// Wait for page to load
-	if err := page.WaitLoad(); err != nil {
-		// Continue even if wait fails - page might be dynamic
+	if err := page.WaitLoad(); err != nil && ctx.Err() != nil {
+		return nil, navigationFailed(url, recorder.finish(), ctx.Err())
 	}
 
 	// Wait for stability
-	_ = ctx // Context available for future use
-	if err := page.WaitStable(500 * time.Millisecond); err != nil {
-		// Continue even if wait fails
+	b.settle(ctx, page)
+
+	result := recorder.finish()
+	if info, err := page.Info(); err == nil {
+		// Includes client-side redirects after the document loaded
+		result.URL = info.URL
+	}
+	if result.NetErr != "" || isErrorPage(result.URL) {
+		if blocked := b.TakeBlocked(); blocked != nil {
+			return nil, blocked
+		}
+		return nil, navigationFailed(url, result, nil)
 	}
 
-	return nil
+	return result, nil
 }
 
 // GoBack navigates back in history.
 func (b *Browser) GoBack(ctx context.Context) error {
-	page := b.ActivePage()
-	if page == nil {
-		return fmt.Errorf("no active page")
+	ctx, cancel := limit(ctx, b.config.NavigationTimeout)
+	defer cancel()
+
+	page, err := b.boundPage(ctx)
+	if err != nil {
+		return err
 	}
 
 	if err := page.NavigateBack(); err != nil {
-		return fmt.Errorf("go back failed: %w", err)
+		return fmt.Errorf("%w: go back failed: %w", ErrNavigationFailed, withTimeout(err))
 	}
 
-	_ = ctx
-	if err := page.WaitStable(500 * time.Millisecond); err != nil {
-		// Continue even if wait fails
-	}
+	b.settle(ctx, page)
 
 	return nil
 }
 
 // GoForward navigates forward in history.
 func (b *Browser) GoForward(ctx context.Context) error {
-	page := b.ActivePage()
-	if page == nil {
-		return fmt.Errorf("no active page")
+	ctx, cancel := limit(ctx, b.config.NavigationTimeout)
+	defer cancel()
+
+	page, err := b.boundPage(ctx)
+	if err != nil {
+		return err
 	}
 
 	if err := page.NavigateForward(); err != nil {
-		return fmt.Errorf("go forward failed: %w", err)
+		return fmt.Errorf("%w: go forward failed: %w", ErrNavigationFailed, withTimeout(err))
 	}
 
-	_ = ctx
-	if err := page.WaitStable(500 * time.Millisecond); err != nil {
-		// Continue even if wait fails
-	}
+	b.settle(ctx, page)
 
 	return nil
 }
 
 // Reload reloads the current page.
 func (b *Browser) Reload(ctx context.Context) error {
-	page := b.ActivePage()
-	if page == nil {
-		return fmt.Errorf("no active page")
+	ctx, cancel := limit(ctx, b.config.NavigationTimeout)
+	defer cancel()
+
+	page, err := b.boundPage(ctx)
+	if err != nil {
+		return err
 	}
 
 	if err := page.Reload(); err != nil {
-		return fmt.Errorf("reload failed: %w", err)
+		return fmt.Errorf("%w: reload failed: %w", ErrNavigationFailed, withTimeout(err))
 	}
 
-	_ = ctx
-	if err := page.WaitStable(500 * time.Millisecond); err != nil {
-		// Continue even if wait fails
-	}
+	b.settle(ctx, page)
 
 	return nil
 }
 
 // Click clicks on an element by index.
 func (b *Browser) Click(ctx context.Context, elementIndex int, elementMap *dom.ElementMap) error {
-	page := b.ActivePage()
-	if page == nil {
-		return fmt.Errorf("no active page")
+	ctx, cancel := limit(ctx, b.config.ActionTimeout)
+	defer cancel()
+
+	page, err := b.boundPage(ctx)
+	if err != nil {
+		return err
 	}
 
-	element, ok := elementMap.Get(elementIndex)
-	if !ok {
-		return fmt.Errorf("element not found: index %d", elementIndex)
+	element, err := lookupElement(elementMap, elementIndex, true)
+	if err != nil {
+		return err
 	}
 
 	// Show highlight if enabled
 	if b.config.ShowHighlight {
-		b.highlightElement(ctx, element)
+		b.highlightElement(ctx, page, element)
 	}
 
 	// Add human-like delay before click
@@ -132,11 +158,8 @@ func (b *Browser) Click(ctx context.Context, elementIndex int, elementMap *dom.E
 	}
 
 	// Move mouse with human-like motion (linear interpolation)
-	if err := page.Mouse.MoveLinear(proto.Point{X: centerX, Y: centerY}, 5); err != nil {
-		// Fallback to direct move if linear fails
-		if err := page.Mouse.MoveTo(proto.Point{X: centerX, Y: centerY}); err != nil {
-			return fmt.Errorf("failed to move mouse: %w", err)
-		}
+	if err := b.moveMouse(page, proto.Point{X: centerX, Y: centerY}, 5); err != nil {
+		return elementFailed(elementIndex, "failed to move mouse", err)
 	}
 
 	// Small delay before click (like human reaction time)
@@ -144,93 +167,102 @@ func (b *Browser) Click(ctx context.Context, elementIndex int, elementMap *dom.E
 		humanDelay(20, 50)
 	}
 
-	if err := page.Mouse.Click(proto.InputMouseButtonLeft, 1); err != nil {
-		return fmt.Errorf("click failed: %w", err)
+	if err := b.clickMouse(page, 1); err != nil {
+		return elementFailed(elementIndex, "click failed", err)
 	}
 
 	// Wait for stability after click
 	time.Sleep(100 * time.Millisecond)
-	_ = ctx
-	if err := page.WaitStable(500 * time.Millisecond); err != nil {
-		// Continue even if wait fails
-	}
+	b.settle(ctx, page)
 
 	return nil
 }
 
 // ClickAt clicks at specific coordinates.
 func (b *Browser) ClickAt(ctx context.Context, x, y float64) error {
-	page := b.ActivePage()
-	if page == nil {
-		return fmt.Errorf("no active page")
+	ctx, cancel := limit(ctx, b.config.ActionTimeout)
+	defer cancel()
+
+	page, err := b.boundPage(ctx)
+	if err != nil {
+		return err
 	}
 
 	// Move mouse and click
-	if err := page.Mouse.MoveTo(proto.Point{X: x, Y: y}); err != nil {
-		return fmt.Errorf("failed to move mouse: %w", err)
+	if err := b.moveMouse(page, proto.Point{X: x, Y: y}, 1); err != nil {
+		return fmt.Errorf("failed to move mouse: %w", withTimeout(err))
 	}
 
-	if err := page.Mouse.Click(proto.InputMouseButtonLeft, 1); err != nil {
-		return fmt.Errorf("click failed: %w", err)
+	if err := b.clickMouse(page, 1); err != nil {
+		return fmt.Errorf("click failed: %w", withTimeout(err))
 	}
 
 	// Wait for stability after click
 	time.Sleep(100 * time.Millisecond)
-	_ = ctx
-	if err := page.WaitStable(500 * time.Millisecond); err != nil {
-		// Continue even if wait fails
-	}
+	b.settle(ctx, page)
 
 	return nil
 }
 
 // DoubleClick double-clicks on an element by index.
 func (b *Browser) DoubleClick(ctx context.Context, elementIndex int, elementMap *dom.ElementMap) error {
-	page := b.ActivePage()
-	if page == nil {
-		return fmt.Errorf("no active page")
+	ctx, cancel := limit(ctx, b.config.ActionTimeout)
+	defer cancel()
+
+	page, err := b.boundPage(ctx)
+	if err != nil {
+		return err
 	}
 
-	element, ok := elementMap.Get(elementIndex)
-	if !ok {
-		return fmt.Errorf("element not found: index %d", elementIndex)
+	element, err := lookupElement(elementMap, elementIndex, true)
+	if err != nil {
+		return err
 	}
 
 	// Show highlight if enabled
 	if b.config.ShowHighlight {
-		b.highlightElement(ctx, element)
+		b.highlightElement(ctx, page, element)
 	}
 
 	centerX, centerY := element.BoundingBox.Center()
 
-	if err := page.Mouse.MoveTo(proto.Point{X: centerX, Y: centerY}); err != nil {
-		return fmt.Errorf("failed to move mouse: %w", err)
+	if err := b.moveMouse(page, proto.Point{X: centerX, Y: centerY}, 1); err != nil {
+		return elementFailed(elementIndex, "failed to move mouse", err)
 	}
 
 	// Double click
-	if err := page.Mouse.Click(proto.InputMouseButtonLeft, 2); err != nil {
-		return fmt.Errorf("double click failed: %w", err)
+	if err := b.clickMouse(page, 2); err != nil {
+		return elementFailed(elementIndex, "double click failed", err)
 	}
 
 	time.Sleep(100 * time.Millisecond)
 	return nil
 }
 
-// TypeText types text into an element by index.
+// TypeText types text into an element by index. <secret>name</secret>
+// placeholders are typed as the value of the secret.
 func (b *Browser) TypeText(ctx context.Context, elementIndex int, text string, elementMap *dom.ElementMap) error {
-	page := b.ActivePage()
-	if page == nil {
-		return fmt.Errorf("no active page")
+	ctx, cancel := limit(ctx, b.config.ActionTimeout)
+	defer cancel()
+
+	text, err := b.resolveSecrets(text)
+	if err != nil {
+		return err
+	}
+
+	page, err := b.boundPage(ctx)
+	if err != nil {
+		return err
 	}
 
-	element, ok := elementMap.Get(elementIndex)
-	if !ok {
-		return fmt.Errorf("element not found: index %d", elementIndex)
+	element, err := lookupElement(elementMap, elementIndex, true)
+	if err != nil {
+		return err
 	}
 
 	// Show highlight if enabled
 	if b.config.ShowHighlight {
-		b.highlightElement(ctx, element)
+		b.highlightElement(ctx, page, element)
 	}
 
 	// Add human-like delay before typing
@@ -246,13 +278,11 @@ func (b *Browser) TypeText(ctx context.Context, elementIndex int, text string, e
 		centerY += offsetY
 	}
 
-	if err := page.Mouse.MoveLinear(proto.Point{X: centerX, Y: centerY}, 5); err != nil {
-		if err := page.Mouse.MoveTo(proto.Point{X: centerX, Y: centerY}); err != nil {
-			return fmt.Errorf("failed to move mouse: %w", err)
-		}
+	if err := b.moveMouse(page, proto.Point{X: centerX, Y: centerY}, 5); err != nil {
+		return elementFailed(elementIndex, "failed to move mouse", err)
 	}
-	if err := page.Mouse.Click(proto.InputMouseButtonLeft, 1); err != nil {
-		return fmt.Errorf("click to focus failed: %w", err)
+	if err := b.clickMouse(page, 1); err != nil {
+		return elementFailed(elementIndex, "click to focus failed", err)
 	}
 
 	time.Sleep(50 * time.Millisecond)
@@ -267,44 +297,56 @@ func (b *Browser) TypeText(ctx context.Context, elementIndex int, text string, e
 		// Type character by character with small random delays
 		for _, char := range text {
 			if err := page.InsertText(string(char)); err != nil {
-				return fmt.Errorf("type failed: %w", err)
+				return elementFailed(elementIndex, "type failed", err)
 			}
 			humanDelay(30, 80) // Random delay between keystrokes
+			if err := ctx.Err(); err != nil {
+				return elementFailed(elementIndex, "type failed", err)
+			}
 		}
 	} else {
 		// Fast insert for longer text
 		if err := page.InsertText(text); err != nil {
-			return fmt.Errorf("type failed: %w", err)
+			return elementFailed(elementIndex, "type failed", err)
 		}
 	}
 
 	return nil
 }
 
-// ClearAndType clears an input and types new text.
+// ClearAndType clears an input and types new text, with secret
+// placeholders resolved as by TypeText.
 func (b *Browser) ClearAndType(ctx context.Context, elementIndex int, text string, elementMap *dom.ElementMap) error {
-	page := b.ActivePage()
-	if page == nil {
-		return fmt.Errorf("no active page")
+	ctx, cancel := limit(ctx, b.config.ActionTimeout)
+	defer cancel()
+
+	text, err := b.resolveSecrets(text)
+	if err != nil {
+		return err
 	}
 
-	element, ok := elementMap.Get(elementIndex)
-	if !ok {
-		return fmt.Errorf("element not found: index %d", elementIndex)
+	page, err := b.boundPage(ctx)
+	if err != nil {
+		return err
+	}
+
+	element, err := lookupElement(elementMap, elementIndex, true)
+	if err != nil {
+		return err
 	}
 
 	// Show highlight if enabled
 	if b.config.ShowHighlight {
-		b.highlightElement(ctx, element)
+		b.highlightElement(ctx, page, element)
 	}
 
 	// Click to focus
 	centerX, centerY := element.BoundingBox.Center()
-	if err := page.Mouse.MoveTo(proto.Point{X: centerX, Y: centerY}); err != nil {
-		return fmt.Errorf("failed to move mouse: %w", err)
+	if err := b.moveMouse(page, proto.Point{X: centerX, Y: centerY}, 1); err != nil {
+		return elementFailed(elementIndex, "failed to move mouse", err)
 	}
-	if err := page.Mouse.Click(proto.InputMouseButtonLeft, 1); err != nil {
-		return fmt.Errorf("click to focus failed: %w", err)
+	if err := b.clickMouse(page, 1); err != nil {
+		return elementFailed(elementIndex, "click to focus failed", err)
 	}
 
 	time.Sleep(50 * time.Millisecond)
@@ -312,15 +354,15 @@ func (b *Browser) ClearAndType(ctx context.Context, elementIndex int, text strin
 	// Select all and delete
 	if err := b.clearInput(page); err != nil {
 		// Try triple-click to select all as fallback
-		if err := page.Mouse.Click(proto.InputMouseButtonLeft, 3); err == nil {
+		if err := b.clickMouse(page, 3); err == nil {
 			time.Sleep(50 * time.Millisecond)
-			page.Keyboard.Type(input.Backspace)
+			_ = typeKey(page, input.Backspace, 0)
 		}
 	}
 
 	// Type new text using InsertText for string input
 	if err := page.InsertText(text); err != nil {
-		return fmt.Errorf("type failed: %w", err)
+		return elementFailed(elementIndex, "type failed", err)
 	}
 
 	return nil
@@ -328,30 +370,29 @@ func (b *Browser) ClearAndType(ctx context.Context, elementIndex int, text strin
 
 // clearInput clears the currently focused input.
 func (b *Browser) clearInput(page *rod.Page) error {
-	// Select all with Ctrl+A / Cmd+A
-	if err := page.Keyboard.Press(input.ControlLeft); err != nil {
+	// Select all with Ctrl+A
+	if err := input.ControlLeft.Encode(proto.InputDispatchKeyEventTypeKeyDown, 0).Call(page); err != nil {
 		return err
 	}
-	if err := page.Keyboard.Type(input.KeyA); err != nil {
+	if err := typeKey(page, input.KeyA, input.ModifierControl); err != nil {
 		return err
 	}
-	if err := page.Keyboard.Release(input.ControlLeft); err != nil {
+	if err := input.ControlLeft.Encode(proto.InputDispatchKeyEventTypeKeyUp, 0).Call(page); err != nil {
 		return err
 	}
 
 	// Delete selected text
-	if err := page.Keyboard.Type(input.Backspace); err != nil {
-		return err
-	}
-
-	return nil
+	return typeKey(page, input.Backspace, 0)
 }
 
 // SendKeys sends keyboard keys to the page.
 func (b *Browser) SendKeys(ctx context.Context, keys string) error {
-	page := b.ActivePage()
-	if page == nil {
-		return fmt.Errorf("no active page")
+	ctx, cancel := limit(ctx, b.config.ActionTimeout)
+	defer cancel()
+
+	page, err := b.boundPage(ctx)
+	if err != nil {
+		return err
 	}
 
 	// Parse and send keys
@@ -374,13 +415,13 @@ func (b *Browser) SendKeys(ctx context.Context, keys string) error {
 	}
 
 	if key, ok := keyMap[keys]; ok {
-		if err := page.Keyboard.Type(key); err != nil {
-			return fmt.Errorf("send keys failed: %w", err)
+		if err := typeKey(page, key, 0); err != nil {
+			return fmt.Errorf("send keys failed: %w", withTimeout(err))
 		}
 	} else {
 		// Type as regular text using InsertText for string input
 		if err := page.InsertText(keys); err != nil {
-			return fmt.Errorf("send keys failed: %w", err)
+			return fmt.Errorf("send keys failed: %w", withTimeout(err))
 		}
 	}
 
@@ -389,9 +430,12 @@ func (b *Browser) SendKeys(ctx context.Context, keys string) error {
 
 // Scroll scrolls the page or an element.
 func (b *Browser) Scroll(ctx context.Context, direction string, amount float64, elementIndex *int, elementMap *dom.ElementMap) error {
-	page := b.ActivePage()
-	if page == nil {
-		return fmt.Errorf("no active page")
+	ctx, cancel := limit(ctx, b.config.ActionTimeout)
+	defer cancel()
+
+	page, err := b.boundPage(ctx)
+	if err != nil {
+		return err
 	}
 
 	var scrollX, scrollY float64
@@ -405,19 +449,19 @@ func (b *Browser) Scroll(ctx context.Context, direction string, amount float64,
 	case "right":
 		scrollX = amount
 	default:
-		return fmt.Errorf("invalid scroll direction: %s", direction)
+		return fmt.Errorf("%w: invalid scroll direction %q", ErrInvalidArgument, direction)
 	}
 
 	// If element index is specified, scroll within that element
 	if elementIndex != nil && elementMap != nil {
-		element, ok := elementMap.Get(*elementIndex)
-		if !ok {
-			return fmt.Errorf("element not found: index %d", *elementIndex)
+		element, err := lookupElement(elementMap, *elementIndex, true)
+		if err != nil {
+			return err
 		}
 
 		// Show highlight if enabled
 		if b.config.ShowHighlight {
-			b.highlightElement(ctx, element)
+			b.highlightElement(ctx, page, element)
 		}
 
 		// Scroll within element using JavaScript
@@ -430,14 +474,13 @@ func (b *Browser) Scroll(ctx context.Context, direction string, amount float64,
 			return false;
 		}`, element.BoundingBox.X+10, element.BoundingBox.Y+10)
 
-		_, err := page.Eval(scrollJS, scrollX, scrollY)
-		if err != nil {
-			return fmt.Errorf("scroll element failed: %w", err)
+		if _, err := page.Eval(scrollJS, scrollX, scrollY); err != nil {
+			return elementFailed(*elementIndex, "scroll element failed", err)
 		}
 	} else {
 		// Scroll the page
-		if err := page.Mouse.Scroll(scrollX, scrollY, 1); err != nil {
-			return fmt.Errorf("scroll page failed: %w", err)
+		if err := b.scrollMouse(page, scrollX, scrollY); err != nil {
+			return fmt.Errorf("scroll page failed: %w", withTimeout(err))
 		}
 	}
 
@@ -449,29 +492,36 @@ func (b *Browser) Scroll(ctx context.Context, direction string, amount float64,
 
 // ScrollToElement scrolls an element into view.
 func (b *Browser) ScrollToElement(ctx context.Context, elementIndex int, elementMap *dom.ElementMap) error {
-	page := b.ActivePage()
-	if page == nil {
-		return fmt.Errorf("no active page")
+	ctx, cancel := limit(ctx, b.config.ActionTimeout)
+	defer cancel()
+
+	page, err := b.boundPage(ctx)
+	if err != nil {
+		return err
 	}
 
-	element, ok := elementMap.Get(elementIndex)
-	if !ok {
-		return fmt.Errorf("element not found: index %d", elementIndex)
+	element, err := lookupElement(elementMap, elementIndex, false)
+	if err != nil {
+		return err
 	}
 
-	// Use JavaScript to scroll element into view
+	// Use JavaScript to scroll element into view.
+	// Offscreen elements can't be hit-tested, so fall back to scrolling the
+	// window by the element's recorded position.
 	scrollJS := fmt.Sprintf(`() => {
-		const el = document.elementFromPoint(%f, %f);
+		const x = %f, y = %f;
+		const inView = y >= 0 && y <= window.innerHeight && x >= 0 && x <= window.innerWidth;
+		const el = inView ? document.elementFromPoint(x, y) : null;
 		if (el) {
 			el.scrollIntoView({behavior: 'smooth', block: 'center'});
 			return true;
 		}
-		return false;
+		window.scrollBy({left: 0, top: y - window.innerHeight / 2, behavior: 'smooth'});
+		return true;
 	}`, element.BoundingBox.X+10, element.BoundingBox.Y+10)
 
-	_, err := page.Eval(scrollJS)
-	if err != nil {
-		return fmt.Errorf("scroll to element failed: %w", err)
+	if _, err := page.Eval(scrollJS); err != nil {
+		return elementFailed(elementIndex, "scroll to element failed", err)
 	}
 
 	time.Sleep(300 * time.Millisecond)
@@ -479,12 +529,7 @@ func (b *Browser) ScrollToElement(ctx context.Context, elementIndex int, element
 }
 
 // highlightElement shows a visual highlight on an element.
-func (b *Browser) highlightElement(ctx context.Context, element *dom.Element) {
-	page := b.ActivePage()
-	if page == nil {
-		return
-	}
-
+func (b *Browser) highlightElement(ctx context.Context, page *rod.Page, element *dom.Element) {
 	highlightJS := fmt.Sprintf(`() => {
 		const overlay = document.createElement('div');
 		overlay.id = 'bua-highlight';
@@ -505,25 +550,28 @@ func (b *Browser) highlightElement(ctx context.Context, element *dom.Element) {
 	)
 
 	page.Eval(highlightJS)
-	time.Sleep(b.config.HighlightDuration)
+	_ = sleep(ctx, b.config.HighlightDuration)
 }
 
 // Hover moves the mouse to hover over an element.
 func (b *Browser) Hover(ctx context.Context, elementIndex int, elementMap *dom.ElementMap) error {
-	page := b.ActivePage()
-	if page == nil {
-		return fmt.Errorf("no active page")
+	ctx, cancel := limit(ctx, b.config.ActionTimeout)
+	defer cancel()
+
+	page, err := b.boundPage(ctx)
+	if err != nil {
+		return err
 	}
 
-	element, ok := elementMap.Get(elementIndex)
-	if !ok {
-		return fmt.Errorf("element not found: index %d", elementIndex)
+	element, err := lookupElement(elementMap, elementIndex, true)
+	if err != nil {
+		return err
 	}
 
 	centerX, centerY := element.BoundingBox.Center()
 
-	if err := page.Mouse.MoveLinear(proto.Point{X: centerX, Y: centerY}, 10); err != nil {
-		return fmt.Errorf("hover failed: %w", err)
+	if err := b.moveMouse(page, proto.Point{X: centerX, Y: centerY}, 10); err != nil {
+		return elementFailed(elementIndex, "hover failed", err)
 	}
 
 	time.Sleep(100 * time.Millisecond)
@@ -532,23 +580,26 @@ func (b *Browser) Hover(ctx context.Context, elementIndex int, elementMap *dom.E
 
 // Focus focuses on an element.
 func (b *Browser) Focus(ctx context.Context, elementIndex int, elementMap *dom.ElementMap) error {
-	page := b.ActivePage()
-	if page == nil {
-		return fmt.Errorf("no active page")
+	ctx, cancel := limit(ctx, b.config.ActionTimeout)
+	defer cancel()
+
+	page, err := b.boundPage(ctx)
+	if err != nil {
+		return err
 	}
 
-	element, ok := elementMap.Get(elementIndex)
-	if !ok {
-		return fmt.Errorf("element not found: index %d", elementIndex)
+	element, err := lookupElement(elementMap, elementIndex, true)
+	if err != nil {
+		return err
 	}
 
 	// Click to focus
 	centerX, centerY := element.BoundingBox.Center()
-	if err := page.Mouse.MoveTo(proto.Point{X: centerX, Y: centerY}); err != nil {
-		return fmt.Errorf("failed to move mouse: %w", err)
+	if err := b.moveMouse(page, proto.Point{X: centerX, Y: centerY}, 1); err != nil {
+		return elementFailed(elementIndex, "failed to move mouse", err)
 	}
-	if err := page.Mouse.Click(proto.InputMouseButtonLeft, 1); err != nil {
-		return fmt.Errorf("click to focus failed: %w", err)
+	if err := b.clickMouse(page, 1); err != nil {
+		return elementFailed(elementIndex, "click to focus failed", err)
 	}
 
 	return nil
@@ -557,9 +608,12 @@ func (b *Browser) Focus(ctx context.Context, elementIndex int, elementMap *dom.E
 // Screenshot takes a screenshot of the current page.
 // Uses the enhanced screenshot package with proper page readiness checks.
 func (b *Browser) Screenshot(ctx context.Context, fullPage bool) ([]byte, error) {
-	page := b.ActivePage()
-	if page == nil {
-		return nil, fmt.Errorf("no active page")
+	ctx, cancel := limit(ctx, b.config.ActionTimeout)
+	defer cancel()
+
+	page, err := b.boundPage(ctx)
+	if err != nil {
+		return nil, err
 	}
 
 	// Use the screenshot package with LLM-optimized options
@@ -572,8 +626,11 @@ func (b *Browser) Screenshot(ctx context.Context, fullPage bool) ([]byte, error)
 // ScreenshotSafe takes a screenshot, returning nil (not error) for blank pages.
 // This is useful for agent loops where blank screenshots should be skipped.
 func (b *Browser) ScreenshotSafe(ctx context.Context, fullPage bool) ([]byte, error) {
-	page := b.ActivePage()
-	if page == nil {
+	ctx, cancel := limit(ctx, b.config.ActionTimeout)
+	defer cancel()
+
+	page, err := b.boundPage(ctx)
+	if err != nil {
 		return nil, nil // No page, return nil safely
 	}
 
@@ -583,9 +640,12 @@ func (b *Browser) ScreenshotSafe(ctx context.Context, fullPage bool) ([]byte, er
 // ScreenshotAfterAction captures a screenshot after an action completes.
 // Waits for page stability before capturing.
 func (b *Browser) ScreenshotAfterAction(ctx context.Context) ([]byte, error) {
-	page := b.ActivePage()
-	if page == nil {
-		return nil, fmt.Errorf("no active page")
+	ctx, cancel := limit(ctx, b.config.ActionTimeout)
+	defer cancel()
+
+	page, err := b.boundPage(ctx)
+	if err != nil {
+		return nil, err
 	}
 
 	return screenshotpkg.CaptureAfterAction(ctx, page, b.config.ViewportWidth)
@@ -603,9 +663,12 @@ func (b *Browser) IsPageReady() bool {
 
 // WaitForPageReady waits until the page is ready, with timeout.
 func (b *Browser) WaitForPageReady(ctx context.Context, timeout time.Duration) error {
-	page := b.ActivePage()
-	if page == nil {
-		return fmt.Errorf("no active page")
+	ctx, cancel := limit(ctx, timeout)
+	defer cancel()
+
+	page, err := b.boundPage(ctx)
+	if err != nil {
+		return err
 	}
 
 	return screenshotpkg.WaitUntilReady(ctx, page, timeout)
@@ -613,9 +676,12 @@ func (b *Browser) WaitForPageReady(ctx context.Context, timeout time.Duration) e
 
 // ExtractContent extracts text content from the page.
 func (b *Browser) ExtractContent(ctx context.Context) (string, error) {
-	page := b.ActivePage()
-	if page == nil {
-		return "", fmt.Errorf("no active page")
+	ctx, cancel := limit(ctx, b.config.ActionTimeout)
+	defer cancel()
+
+	page, err := b.boundPage(ctx)
+	if err != nil {
+		return "", err
 	}
 
 	// Extract main text content using JavaScript
@@ -629,17 +695,81 @@ func (b *Browser) ExtractContent(ctx context.Context) (string, error) {
 		return document.body.innerText;
 	}`)
 	if err != nil {
-		return "", fmt.Errorf("content extraction failed: %w", err)
+		return "", fmt.Errorf("content extraction failed: %w", withTimeout(err))
+	}
+
+	return b.ScrubSecrets(result.Value.String()), nil
+}
+
+// ContentScope limits content extraction to part of the page.
+// The zero value extracts the main content area.
+type ContentScope struct {
+	// Selector is a CSS selector for the root element to extract.
+	Selector string
+
+	// Element scopes extraction to the container (table, form, section, list)
+	// around an element from the element map. Takes precedence over Selector.
+	Element *dom.Element
+}
+
+// extractScope converts the scope to the dom package representation.
+func (s ContentScope) extractScope() dom.ExtractScope {
+	scope := dom.ExtractScope{Selector: s.Selector}
+	if s.Element != nil {
+		scope.X, scope.Y = s.Element.BoundingBox.Center()
+		scope.HasPoint = true
+		if scope.Selector == "" {
+			scope.Selector = s.Element.Selector
+		}
+	}
+	return scope
+}
+
+// ExtractMarkdown extracts page content as Markdown, keeping tables,
+// lists, link targets and image alt text.
+func (b *Browser) ExtractMarkdown(ctx context.Context, scope ContentScope) (string, error) {
+	ctx, cancel := limit(ctx, b.config.ActionTimeout)
+	defer cancel()
+
+	page, err := b.boundPage(ctx)
+	if err != nil {
+		return "", err
 	}
 
-	return result.Value.String(), nil
+	markdown, err := dom.ExtractMarkdown(ctx, page, scope.extractScope())
+	if err != nil {
+		return "", err
+	}
+	return b.ScrubSecrets(markdown), nil
+}
+
+// ExtractTables extracts HTML tables and ARIA grids as structured rows.
+// Spanned cells are repeated so every row has one value per column.
+func (b *Browser) ExtractTables(ctx context.Context, scope ContentScope) ([]dom.Table, error) {
+	ctx, cancel := limit(ctx, b.config.ActionTimeout)
+	defer cancel()
+
+	page, err := b.boundPage(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	tables, err := dom.ExtractTables(ctx, page, scope.extractScope())
+	if err != nil {
+		return nil, err
+	}
+	b.scrubTables(tables)
+	return tables, nil
 }
 
 // EvaluateJS evaluates JavaScript code on the page.
 func (b *Browser) EvaluateJS(ctx context.Context, script string) (string, error) {
-	page := b.ActivePage()
-	if page == nil {
-		return "", fmt.Errorf("no active page")
+	ctx, cancel := limit(ctx, b.config.ActionTimeout)
+	defer cancel()
+
+	page, err := b.boundPage(ctx)
+	if err != nil {
+		return "", err
 	}
 
 	// Wrap script in arrow function if not already
@@ -650,10 +780,10 @@ func (b *Browser) EvaluateJS(ctx context.Context, script string) (string, error)
 
 	result, err := page.Eval(wrappedScript)
 	if err != nil {
-		return "", fmt.Errorf("JS evaluation failed: %w", err)
+		return "", fmt.Errorf("JS evaluation failed: %w", withTimeout(err))
 	}
 
-	return result.Value.String(), nil
+	return b.ScrubSecrets(result.Value.String()), nil
 }
 
 // ElementMapAdapter adapts dom.ElementMap to screenshot.ElementMapInterface.
@@ -704,9 +834,12 @@ func (a *elementInfoAdapter) GetBoundingBox() screenshotpkg.BoundingBoxInfo {
 // ScreenshotWithAnnotations takes a screenshot with element annotations.
 // This is the main entry point for annotated screenshots.
 func (b *Browser) ScreenshotWithAnnotations(ctx context.Context, elementMap *dom.ElementMap, fullPage bool) ([]byte, error) {
-	page := b.ActivePage()
-	if page == nil {
-		return nil, fmt.Errorf("no active page")
+	ctx, cancel := limit(ctx, b.config.ActionTimeout)
+	defer cancel()
+
+	page, err := b.boundPage(ctx)
+	if err != nil {
+		return nil, err
 	}
 
 	adapter := NewElementMapAdapter(elementMap)
@@ -715,8 +848,11 @@ func (b *Browser) ScreenshotWithAnnotations(ctx context.Context, elementMap *dom
 
 // ScreenshotSafeWithAnnotations takes an annotated screenshot, returning nil for blank pages.
 func (b *Browser) ScreenshotSafeWithAnnotations(ctx context.Context, elementMap *dom.ElementMap) ([]byte, error) {
-	page := b.ActivePage()
-	if page == nil {
+	ctx, cancel := limit(ctx, b.config.ActionTimeout)
+	defer cancel()
+
+	page, err := b.boundPage(ctx)
+	if err != nil {
 		return nil, nil
 	}
 
@@ -726,9 +862,12 @@ func (b *Browser) ScreenshotSafeWithAnnotations(ctx context.Context, elementMap
 
 // ScreenshotAfterActionWithAnnotations captures an annotated screenshot after an action.
 func (b *Browser) ScreenshotAfterActionWithAnnotations(ctx context.Context, elementMap *dom.ElementMap) ([]byte, error) {
-	page := b.ActivePage()
-	if page == nil {
-		return nil, fmt.Errorf("no active page")
+	ctx, cancel := limit(ctx, b.config.ActionTimeout)
+	defer cancel()
+
+	page, err := b.boundPage(ctx)
+	if err != nil {
+		return nil, err
 	}
 
 	adapter := NewElementMapAdapter(elementMap)
@@ -0,0 +1,200 @@
+package browser
+
+import (
+	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/go-rod/rod"
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// URLPolicy restricts which pages the browser may load. The zero value
+// allows everything.
+//
+// Domain patterns match a host and its subdomains, so "example.com" matches
+// "example.com" and "shop.example.com"; "*.example.com" matches only the
+// subdomains. Matching ignores case and ports. IP addresses match only
+// themselves.
+type URLPolicy struct {
+	// AllowedDomains, if set, are the only domains pages may be loaded from.
+	AllowedDomains []string
+
+	// BlockedDomains are never loaded, even if they are allowed.
+	BlockedDomains []string
+
+	// AllowedSchemes are the URL schemes pages may use. Defaults to http
+	// and https once any other field is set, which blocks file:, chrome:,
+	// javascript: and data: URLs. about:blank is always allowed.
+	AllowedSchemes []string
+}
+
+// defaultSchemes are the schemes allowed when AllowedSchemes is empty.
+var defaultSchemes = []string{"http", "https"}
+
+// Enabled reports whether the policy restricts anything.
+func (p URLPolicy) Enabled() bool {
+	return len(p.AllowedDomains) > 0 || len(p.BlockedDomains) > 0 || len(p.AllowedSchemes) > 0
+}
+
+// Check returns a *PolicyError if the policy rejects the URL.
+func (p URLPolicy) Check(rawURL string) error {
+	if !p.Enabled() || rawURL == "about:blank" {
+		return nil
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return &PolicyError{URL: rawURL, Reason: "the URL could not be parsed"}
+	}
+
+	schemes := p.AllowedSchemes
+	if len(schemes) == 0 {
+		schemes = defaultSchemes
+	}
+	scheme := strings.ToLower(u.Scheme)
+	if !slices.ContainsFunc(schemes, func(s string) bool { return strings.EqualFold(s, scheme) }) {
+		return &PolicyError{URL: rawURL, Reason: fmt.Sprintf("scheme %q is not allowed", scheme)}
+	}
+
+	// Domain patterns only apply to URLs with a host; data: URLs and the
+	// like only need their scheme allowed
+	host := strings.ToLower(u.Hostname())
+	if host == "" {
+		return nil
+	}
+	for _, pattern := range p.BlockedDomains {
+		if matchDomain(pattern, host) {
+			return &PolicyError{URL: rawURL, Reason: fmt.Sprintf("domain %s is blocked", host)}
+		}
+	}
+	if len(p.AllowedDomains) == 0 {
+		return nil
+	}
+	for _, pattern := range p.AllowedDomains {
+		if matchDomain(pattern, host) {
+			return nil
+		}
+	}
+	return &PolicyError{URL: rawURL, Reason: fmt.Sprintf("domain %s is not in the allowed domains", host)}
+}
+
+// matchDomain reports whether a host matches a domain pattern. IP
+// addresses have no subdomains, so they only match exactly.
+func matchDomain(pattern, host string) bool {
+	pattern = strings.ToLower(strings.TrimSpace(pattern))
+	pattern = strings.TrimSuffix(strings.TrimPrefix(pattern, "["), "]")
+	host = strings.TrimSuffix(host, ".")
+	if net.ParseIP(host) != nil {
+		return host == pattern
+	}
+	if sub, ok := strings.CutPrefix(pattern, "*."); ok {
+		return strings.HasSuffix(host, "."+sub)
+	}
+	return host == pattern || strings.HasSuffix(host, "."+pattern)
+}
+
+// enforcePolicy stops the page loading documents the URL policy rejects in
+// its main frame, such as pages opened by link clicks, form submissions,
+// scripts and redirects. Frames within a page are not checked. Blocked
+// loads are recorded for TakeBlocked.
+func (b *Browser) enforcePolicy(page *rod.Page) error {
+	policy := b.config.URLPolicy
+	if !policy.Enabled() {
+		return nil
+	}
+
+	// Enable interception before subscribing, so the subscription doesn't
+	// enable it for every request
+	err := proto.FetchEnable{Patterns: []*proto.FetchRequestPattern{{
+		URLPattern:   "*",
+		ResourceType: proto.NetworkResourceTypeDocument,
+	}}}.Call(page)
+	if err != nil {
+		return fmt.Errorf("failed to enable URL policy: %w", err)
+	}
+
+	wait := page.EachEvent(func(e *proto.FetchRequestPaused) {
+		go func() {
+			if e.FrameID == page.FrameID {
+				if err := policy.Check(e.Request.URL); err != nil {
+					b.recordBlocked(err)
+					_ = proto.FetchFailRequest{
+						RequestID:   e.RequestID,
+						ErrorReason: proto.NetworkErrorReasonBlockedByClient,
+					}.Call(page)
+					return
+				}
+			}
+			_ = proto.FetchContinueRequest{RequestID: e.RequestID}.Call(page)
+		}()
+	})
+	go wait()
+	return nil
+}
+
+// enforcePolicyOnPopups installs the URL policy on pages the browser opens
+// by itself, e.g. for target=_blank links or window.open. New pages are
+// attached paused, so nothing loads before interception is enabled. Pages
+// created by NewTab and Start have no opener and are set up by them.
+func (b *Browser) enforcePolicyOnPopups(rodBrowser *rod.Browser) error {
+	if !b.config.URLPolicy.Enabled() {
+		return nil
+	}
+
+	wait := rodBrowser.EachEvent(func(e *proto.TargetAttachedToTarget) {
+		go func() {
+			page := rodBrowser.PageFromSession(e.SessionID)
+			page.TargetID = e.TargetInfo.TargetID
+			page.FrameID = proto.PageFrameID(e.TargetInfo.TargetID)
+
+			if e.TargetInfo.OpenerID != "" {
+				if err := b.enforcePolicy(page); err != nil {
+					// A popup that can't be checked must not load
+					_, _ = proto.TargetCloseTarget{TargetID: e.TargetInfo.TargetID}.Call(rodBrowser)
+					return
+				}
+			}
+			_ = proto.RuntimeRunIfWaitingForDebugger{}.Call(page)
+			if e.TargetInfo.OpenerID == "" {
+				_ = proto.TargetDetachFromTarget{SessionID: e.SessionID}.Call(rodBrowser)
+			}
+		}()
+	})
+	go wait()
+
+	err := proto.TargetSetAutoAttach{
+		AutoAttach:             true,
+		WaitForDebuggerOnStart: true,
+		Flatten:                true,
+		Filter:                 proto.TargetTargetFilter{{Type: "page"}, {Exclude: true}},
+	}.Call(rodBrowser)
+	if err != nil {
+		return fmt.Errorf("failed to enable URL policy for new pages: %w", err)
+	}
+	return nil
+}
+
+// recordBlocked remembers a page load blocked by the URL policy.
+func (b *Browser) recordBlocked(err error) {
+	b.blockedMu.Lock()
+	defer b.blockedMu.Unlock()
+
+	b.blocked = err
+	if b.config.Debug {
+		fmt.Printf("[Browser] %v\n", err)
+	}
+}
+
+// TakeBlocked returns the last page load the URL policy blocked since the
+// previous call, as a *PolicyError, or nil if there was none.
+func (b *Browser) TakeBlocked() error {
+	b.blockedMu.Lock()
+	defer b.blockedMu.Unlock()
+
+	err := b.blocked
+	b.blocked = nil
+	return err
+}
@@ -0,0 +1,121 @@
+package browser
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestMatchDomain(t *testing.T) {
+	tests := []struct {
+		pattern string
+		host    string
+		want    bool
+	}{
+		{"example.com", "example.com", true},
+		{"example.com", "shop.example.com", true},
+		{"example.com", "a.b.example.com", true},
+		{"example.com", "evil-example.com", false},
+		{"example.com", "example.com.evil.net", false},
+		{"example.com", "example.org", false},
+		{"*.example.com", "shop.example.com", true},
+		{"*.example.com", "example.com", false},
+		{"*.example.com", "evil-example.com", false},
+		{"Example.COM", "example.com", true},
+		{" example.com ", "example.com", true},
+		{"example.com", "example.com.", true},
+		{"127.0.0.1", "127.0.0.1", true},
+		{"0.0.1", "127.0.0.1", false},
+		{"0.1", "10.0.0.1", false},
+		{"::1", "::1", true},
+		{"[::1]", "::1", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+"/"+tt.host, func(t *testing.T) {
+			if got := matchDomain(tt.pattern, tt.host); got != tt.want {
+				t.Errorf("matchDomain(%q, %q) = %v, want %v", tt.pattern, tt.host, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestURLPolicyCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  URLPolicy
+		url     string
+		blocked bool
+	}{
+		{name: "zero policy allows everything", url: "file:///etc/passwd"},
+		{name: "zero policy allows any domain", url: "https://anything.test"},
+
+		{name: "allowed domain", policy: URLPolicy{AllowedDomains: []string{"example.com"}}, url: "https://example.com/a"},
+		{name: "allowed subdomain", policy: URLPolicy{AllowedDomains: []string{"example.com"}}, url: "https://shop.example.com"},
+		{name: "lookalike domain", policy: URLPolicy{AllowedDomains: []string{"example.com"}}, url: "https://evil-example.com", blocked: true},
+		{name: "other domain", policy: URLPolicy{AllowedDomains: []string{"example.com"}}, url: "https://example.org", blocked: true},
+		{name: "wildcard excludes apex", policy: URLPolicy{AllowedDomains: []string{"*.example.com"}}, url: "https://example.com", blocked: true},
+		{name: "mixed case host", policy: URLPolicy{AllowedDomains: []string{"example.com"}}, url: "https://WWW.Example.COM/"},
+		{name: "mixed case pattern", policy: URLPolicy{AllowedDomains: []string{"EXAMPLE.com"}}, url: "https://example.com"},
+		{name: "port ignored", policy: URLPolicy{AllowedDomains: []string{"example.com"}}, url: "https://example.com:8443/x"},
+		{name: "userinfo is not the host", policy: URLPolicy{AllowedDomains: []string{"example.com"}}, url: "https://example.com@evil.net/", blocked: true},
+
+		{name: "IPv4 literal allowed", policy: URLPolicy{AllowedDomains: []string{"127.0.0.1"}}, url: "http://127.0.0.1:8080/"},
+		{name: "IPv4 literal not allowed", policy: URLPolicy{AllowedDomains: []string{"example.com"}}, url: "http://10.0.0.1/", blocked: true},
+		{name: "IPv4 suffix does not match", policy: URLPolicy{AllowedDomains: []string{"0.0.1"}}, url: "http://127.0.0.1/", blocked: true},
+		{name: "IPv6 literal allowed", policy: URLPolicy{AllowedDomains: []string{"[::1]"}}, url: "http://[::1]:3000/"},
+		{name: "IPv6 literal blocked", policy: URLPolicy{BlockedDomains: []string{"::1"}}, url: "http://[::1]/", blocked: true},
+
+		{name: "blocked domain", policy: URLPolicy{BlockedDomains: []string{"evil.net"}}, url: "https://evil.net", blocked: true},
+		{name: "blocked subdomain", policy: URLPolicy{BlockedDomains: []string{"evil.net"}}, url: "https://a.evil.net", blocked: true},
+		{name: "not blocked", policy: URLPolicy{BlockedDomains: []string{"evil.net"}}, url: "https://good.net"},
+		{
+			name:    "block wins over allow",
+			policy:  URLPolicy{AllowedDomains: []string{"example.com"}, BlockedDomains: []string{"admin.example.com"}},
+			url:     "https://admin.example.com",
+			blocked: true,
+		},
+
+		{name: "default schemes allow https", policy: URLPolicy{BlockedDomains: []string{"evil.net"}}, url: "https://example.com"},
+		{name: "default schemes allow http", policy: URLPolicy{BlockedDomains: []string{"evil.net"}}, url: "http://example.com"},
+		{name: "default schemes block file", policy: URLPolicy{BlockedDomains: []string{"evil.net"}}, url: "file:///etc/passwd", blocked: true},
+		{name: "default schemes block javascript", policy: URLPolicy{BlockedDomains: []string{"evil.net"}}, url: "javascript:alert(1)", blocked: true},
+		{name: "default schemes block data", policy: URLPolicy{BlockedDomains: []string{"evil.net"}}, url: "data:text/html,hi", blocked: true},
+		{name: "default schemes block chrome", policy: URLPolicy{BlockedDomains: []string{"evil.net"}}, url: "chrome://settings", blocked: true},
+		{name: "scheme case ignored", policy: URLPolicy{BlockedDomains: []string{"evil.net"}}, url: "HTTPS://example.com"},
+		{name: "explicit scheme allowed", policy: URLPolicy{AllowedSchemes: []string{"https", "data"}}, url: "data:text/html,hi"},
+		{name: "explicit scheme replaces defaults", policy: URLPolicy{AllowedSchemes: []string{"https"}}, url: "http://example.com", blocked: true},
+		{
+			name:    "allowed scheme does not bypass domains",
+			policy:  URLPolicy{AllowedSchemes: []string{"http"}, AllowedDomains: []string{"example.com"}},
+			url:     "http://evil.net",
+			blocked: true,
+		},
+		{
+			name:    "allowed domain does not bypass schemes",
+			policy:  URLPolicy{AllowedSchemes: []string{"https"}, AllowedDomains: []string{"example.com"}},
+			url:     "http://example.com",
+			blocked: true,
+		},
+		{name: "about:blank always allowed", policy: URLPolicy{AllowedSchemes: []string{"https"}}, url: "about:blank"},
+		{name: "unparsable URL", policy: URLPolicy{AllowedDomains: []string{"example.com"}}, url: "https://exa mple.com/%zz", blocked: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check(tt.url)
+			if !tt.blocked {
+				if err != nil {
+					t.Fatalf("Check(%q) = %v, want allowed", tt.url, err)
+				}
+				return
+			}
+			var policyErr *PolicyError
+			if !errors.As(err, &policyErr) {
+				t.Fatalf("Check(%q) = %v, want *PolicyError", tt.url, err)
+			}
+			if !errors.Is(err, ErrURLBlocked) {
+				t.Errorf("Check(%q) = %v, want it to wrap ErrURLBlocked", tt.url, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package browser
+
+import (
+	"cmp"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/anxuanzi/bua/dom"
+)
+
+// secretPlaceholder matches a <secret>name</secret> placeholder.
+var secretPlaceholder = regexp.MustCompile(`<secret>\s*([^<>\s]+)\s*</secret>`)
+
+// SecretPlaceholder returns the placeholder that stands for a secret in
+// text typed by TypeText and ClearAndType.
+func SecretPlaceholder(name string) string {
+	return "<secret>" + name + "</secret>"
+}
+
+// FindSecretPlaceholders returns the positions of the secret placeholders
+// in text, as pairs of indices of the whole placeholder and of the name,
+// like regexp.FindAllStringSubmatchIndex.
+func FindSecretPlaceholders(text string) [][]int {
+	return secretPlaceholder.FindAllStringSubmatchIndex(text, -1)
+}
+
+// newSecretScrubber returns a replacer of secret values by their
+// placeholders, or nil if there are no secrets. Longer values are replaced
+// first, so a value containing another is not split.
+func newSecretScrubber(secrets map[string]string) *strings.Replacer {
+	names := slices.Collect(maps.Keys(secrets))
+	slices.SortFunc(names, func(a, b string) int {
+		return cmp.Or(cmp.Compare(len(secrets[b]), len(secrets[a])), cmp.Compare(a, b))
+	})
+
+	var pairs []string
+	for _, name := range names {
+		if secrets[name] != "" {
+			pairs = append(pairs, secrets[name], SecretPlaceholder(name))
+		}
+	}
+	if len(pairs) == 0 {
+		return nil
+	}
+	return strings.NewReplacer(pairs...)
+}
+
+// SecretNames returns the sorted names of the configured secrets.
+func (b *Browser) SecretNames() []string {
+	return slices.Sorted(maps.Keys(b.config.Secrets))
+}
+
+// resolveSecrets replaces the placeholders in text with the secret values.
+func (b *Browser) resolveSecrets(text string) (string, error) {
+	var unknown string
+	resolved := secretPlaceholder.ReplaceAllStringFunc(text, func(match string) string {
+		name := secretPlaceholder.FindStringSubmatch(match)[1]
+		value, ok := b.config.Secrets[name]
+		if !ok {
+			unknown = name
+			return match
+		}
+		return value
+	})
+	if unknown != "" {
+		return "", fmt.Errorf("%w %q", ErrUnknownSecret, unknown)
+	}
+	return resolved, nil
+}
+
+// ScrubSecrets replaces the secret values in text with their placeholders.
+func (b *Browser) ScrubSecrets(text string) string {
+	if b.secretScrubber == nil {
+		return text
+	}
+	return b.secretScrubber.Replace(text)
+}
+
+// minSecretPrefix is the shortest secret prefix scrubbed from the end of
+// truncated page text; shorter prefixes are too likely to be ordinary text.
+const minSecretPrefix = 4
+
+// truncationMarker ends text the extraction script cut short.
+const truncationMarker = "..."
+
+// scrubPageText replaces the secret values in extracted page text. The
+// extraction script truncates long labels and text, so a secret at the cut
+// is left as a prefix the replacer cannot match; that prefix is replaced
+// by the secret's placeholder as well.
+func (b *Browser) scrubPageText(text string) string {
+	text = b.ScrubSecrets(text)
+	body, ok := strings.CutSuffix(text, truncationMarker)
+	if !ok {
+		return text
+	}
+	return b.scrubSecretPrefix(body) + truncationMarker
+}
+
+// scrubSecretPrefix replaces the longest prefix of a secret value that
+// text ends with, if it is at least minSecretPrefix bytes long.
+func (b *Browser) scrubSecretPrefix(text string) string {
+	best, bestName := 0, ""
+	for _, name := range b.SecretNames() {
+		value := b.config.Secrets[name]
+		for n := min(len(value)-1, len(text)); n >= minSecretPrefix && n > best; n-- {
+			if utf8.RuneStart(value[n]) && strings.HasSuffix(text, value[:n]) {
+				best, bestName = n, name
+				break
+			}
+		}
+	}
+	if best == 0 {
+		return text
+	}
+	return text[:len(text)-best] + SecretPlaceholder(bestName)
+}
+
+// scrubTables replaces the secret values in extracted tables.
+func (b *Browser) scrubTables(tables []dom.Table) {
+	if b.secretScrubber == nil {
+		return
+	}
+	for i := range tables {
+		table := &tables[i]
+		table.Caption = b.ScrubSecrets(table.Caption)
+		for j := range table.Headers {
+			table.Headers[j] = b.ScrubSecrets(table.Headers[j])
+		}
+		for _, row := range table.Rows {
+			for j := range row {
+				row[j] = b.ScrubSecrets(row[j])
+			}
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package browser
+
+import (
+	"errors"
+	"testing"
+)
+
+func newSecretsBrowser(t *testing.T, secrets map[string]string) *Browser {
+	t.Helper()
+	b, err := New(Config{Secrets: secrets})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	return b
+}
+
+func TestScrubSecrets(t *testing.T) {
+	tests := []struct {
+		name    string
+		secrets map[string]string
+		text    string
+		want    string
+	}{
+		{
+			name: "no secrets",
+			text: "password is hunter2",
+			want: "password is hunter2",
+		},
+		{
+			name:    "single value",
+			secrets: map[string]string{"password": "hunter2"},
+			text:    "password is hunter2",
+			want:    "password is <secret>password</secret>",
+		},
+		{
+			name:    "every occurrence",
+			secrets: map[string]string{"password": "hunter2"},
+			text:    "hunter2/hunter2",
+			want:    "<secret>password</secret>/<secret>password</secret>",
+		},
+		{
+			name:    "longer value first",
+			secrets: map[string]string{"pin": "1234", "card": "4111123456"},
+			text:    "card 4111123456, pin 1234",
+			want:    "card <secret>card</secret>, pin <secret>pin</secret>",
+		},
+		{
+			name:    "empty value ignored",
+			secrets: map[string]string{"empty": "", "user": "alice"},
+			text:    "alice logged in",
+			want:    "<secret>user</secret> logged in",
+		},
+		{
+			name:    "only empty values",
+			secrets: map[string]string{"empty": ""},
+			text:    "nothing to hide",
+			want:    "nothing to hide",
+		},
+		{
+			name:    "case sensitive",
+			secrets: map[string]string{"user": "alice"},
+			text:    "Alice",
+			want:    "Alice",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := newSecretsBrowser(t, tt.secrets)
+			if got := b.ScrubSecrets(tt.text); got != tt.want {
+				t.Errorf("ScrubSecrets(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveSecrets(t *testing.T) {
+	b := newSecretsBrowser(t, map[string]string{"user": "alice", "password": "hunter2"})
+
+	tests := []struct {
+		name    string
+		text    string
+		want    string
+		wantErr bool
+	}{
+		{name: "plain text", text: "hello", want: "hello"},
+		{name: "placeholder", text: "<secret>password</secret>", want: "hunter2"},
+		{name: "several placeholders", text: "<secret>user</secret>:<secret>password</secret>", want: "alice:hunter2"},
+		{name: "whitespace inside tags", text: "<secret> user </secret>", want: "alice"},
+		{name: "unknown name", text: "<secret>token</secret>", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := b.resolveSecrets(tt.text)
+			if tt.wantErr {
+				if !errors.Is(err, ErrUnknownSecret) {
+					t.Fatalf("resolveSecrets(%q) error = %v, want ErrUnknownSecret", tt.text, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("resolveSecrets(%q): %v", tt.text, err)
+			}
+			if got != tt.want {
+				t.Errorf("resolveSecrets(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScrubPageText(t *testing.T) {
+	b := newSecretsBrowser(t, map[string]string{"token": "sk-live-abcdef", "pin": "12345"})
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "whole value", text: "key sk-live-abcdef here", want: "key <secret>token</secret> here"},
+		{name: "cut inside value", text: "key sk-live-ab...", want: "key <secret>token</secret>..."},
+		{name: "whole value before marker", text: "key sk-live-abcdef...", want: "key <secret>token</secret>..."},
+		{name: "short prefix kept", text: "total 123...", want: "total 123..."},
+		{name: "minimum prefix", text: "pin 1234...", want: "pin <secret>pin</secret>..."},
+		{name: "not truncated", text: "key sk-live-ab", want: "key sk-live-ab"},
+		{name: "prefix not at end", text: "sk-live-ab and more...", want: "sk-live-ab and more..."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := b.scrubPageText(tt.text); got != tt.want {
+				t.Errorf("scrubPageText(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
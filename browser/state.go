@@ -0,0 +1,154 @@
+package browser
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// StorageState is a snapshot of cookies and web storage that can be
+// restored into a fresh browser to continue a session.
+type StorageState struct {
+	Cookies []*proto.NetworkCookie `json:"cookies,omitempty"`
+	Origins []OriginStorage        `json:"origins,omitempty"`
+}
+
+// OriginStorage holds the localStorage entries of a single origin.
+type OriginStorage struct {
+	Origin       string            `json:"origin"`
+	LocalStorage map[string]string `json:"local_storage"`
+}
+
+// readLocalStorageJS returns the page origin and its localStorage entries.
+const readLocalStorageJS = `() => {
+    const items = {};
+    try {
+        for (let i = 0; i < localStorage.length; i++) {
+            const key = localStorage.key(i);
+            items[key] = localStorage.getItem(key);
+        }
+    } catch (e) {}
+    return { origin: location.origin, items };
+}`
+
+// writeLocalStorageJS writes entries into the page's localStorage.
+const writeLocalStorageJS = `(items) => {
+    for (const [key, value] of Object.entries(items)) localStorage.setItem(key, value);
+}`
+
+// StorageState captures cookies and the localStorage of every open tab's origin.
+func (b *Browser) StorageState(ctx context.Context) (*StorageState, error) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.rod == nil {
+		return nil, fmt.Errorf("%w: browser not started", ErrBrowserClosed)
+	}
+
+	ctx, cancel := limit(ctx, b.config.ActionTimeout)
+	defer cancel()
+
+	cookies, err := b.rod.Context(ctx).GetCookies()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cookies: %w", err)
+	}
+
+	state := &StorageState{Cookies: cookies}
+	seen := make(map[string]bool)
+	for _, page := range b.pages {
+		result, err := page.Context(ctx).Eval(readLocalStorageJS)
+		if err != nil {
+			continue
+		}
+		origin := result.Value.Get("origin").String()
+		if !strings.HasPrefix(origin, "http") || seen[origin] {
+			continue
+		}
+		seen[origin] = true
+
+		items := make(map[string]string)
+		for key, value := range result.Value.Get("items").Map() {
+			items[key] = value.String()
+		}
+		if len(items) > 0 {
+			state.Origins = append(state.Origins, OriginStorage{Origin: origin, LocalStorage: items})
+		}
+	}
+
+	return state, nil
+}
+
+// SetStorageState restores cookies and localStorage from a snapshot.
+// Restoring localStorage navigates the active tab to each origin, so call
+// RestoreTabs afterwards to reopen the pages.
+func (b *Browser) SetStorageState(ctx context.Context, state *StorageState) error {
+	if state == nil {
+		return nil
+	}
+
+	b.mu.RLock()
+	rodBrowser := b.rod
+	b.mu.RUnlock()
+
+	if rodBrowser == nil {
+		return fmt.Errorf("%w: browser not started", ErrBrowserClosed)
+	}
+
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	if len(state.Cookies) > 0 {
+		if err := rodBrowser.Context(ctx).SetCookies(proto.CookiesToParams(state.Cookies)); err != nil {
+			return fmt.Errorf("failed to set cookies: %w", err)
+		}
+	}
+
+	for _, origin := range state.Origins {
+		if _, err := b.Navigate(ctx, origin.Origin); err != nil {
+			return fmt.Errorf("failed to open %s: %w", origin.Origin, err)
+		}
+		page, err := b.boundPage(ctx)
+		if err != nil {
+			return err
+		}
+		if _, err := page.Eval(writeLocalStorageJS, origin.LocalStorage); err != nil {
+			return fmt.Errorf("failed to restore localStorage for %s: %w", origin.Origin, err)
+		}
+	}
+
+	return nil
+}
+
+// RestoreTabs reopens a set of tabs, reusing the active tab for the first one,
+// and activates the tab that was active when the set was captured.
+// Tab IDs are newly assigned.
+func (b *Browser) RestoreTabs(ctx context.Context, tabs []TabInfo) error {
+	var activeID string
+	for i, tab := range tabs {
+		var tabID string
+		if i == 0 {
+			if _, err := b.Navigate(ctx, tab.URL); err != nil {
+				return fmt.Errorf("failed to restore tab %s: %w", tab.URL, err)
+			}
+			b.mu.RLock()
+			tabID = b.activeTabID
+			b.mu.RUnlock()
+		} else {
+			id, err := b.NewTab(ctx, tab.URL)
+			if err != nil {
+				return fmt.Errorf("failed to restore tab %s: %w", tab.URL, err)
+			}
+			tabID = id
+		}
+		if tab.Active {
+			activeID = tabID
+		}
+	}
+
+	if activeID != "" {
+		return b.SwitchTab(activeID)
+	}
+	return nil
+}
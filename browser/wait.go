@@ -0,0 +1,236 @@
+package browser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-rod/rod/lib/proto"
+)
+
+// waitPollInterval is how often polled wait conditions are checked.
+const waitPollInterval = 100 * time.Millisecond
+
+// selectorStateJS reports whether a selector matches a rendered element.
+const selectorStateJS = `(selector) => {
+    const el = document.querySelector(selector);
+    if (!el) return false;
+    const rect = el.getBoundingClientRect();
+    const style = getComputedStyle(el);
+    return rect.width > 0 && rect.height > 0 && style.visibility !== 'hidden' && style.display !== 'none';
+}`
+
+// textStateJS reports whether the page shows the text.
+const textStateJS = `(text) => !!document.body && document.body.innerText.includes(text)`
+
+// domQuietJS resolves true once the DOM has not changed for quietMs, or
+// false after timeoutMs.
+const domQuietJS = `(quietMs, timeoutMs) => new Promise((resolve) => {
+    let timer;
+    const finish = (quiet) => {
+        observer.disconnect();
+        clearTimeout(timer);
+        clearTimeout(deadline);
+        resolve(quiet);
+    };
+    const observer = new MutationObserver(() => {
+        clearTimeout(timer);
+        timer = setTimeout(() => finish(true), quietMs);
+    });
+    observer.observe(document.documentElement || document, {
+        subtree: true, childList: true, attributes: true, characterData: true
+    });
+    timer = setTimeout(() => finish(true), quietMs);
+    const deadline = setTimeout(() => finish(false), timeoutMs);
+})`
+
+// WaitNetworkIdle waits until no more than maxInflight requests have been
+// in flight for the idle duration. Allow a few in-flight requests for pages
+// that poll or keep connections open. Requests started before the call are
+// not tracked. Returns an error wrapping ErrTimeout if ctx expires first.
+func (b *Browser) WaitNetworkIdle(ctx context.Context, idle time.Duration, maxInflight int) error {
+	page, err := b.page()
+	if err != nil {
+		return err
+	}
+
+	var mu sync.Mutex
+	inflight := make(map[proto.NetworkRequestID]bool)
+	changed := make(chan struct{}, 1)
+	update := func(id proto.NetworkRequestID, open bool) {
+		mu.Lock()
+		if open {
+			inflight[id] = true
+		} else {
+			delete(inflight, id)
+		}
+		mu.Unlock()
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	}
+
+	eventPage, cancel := page.Context(ctx).WithCancel()
+	defer cancel()
+	wait := eventPage.EachEvent(
+		func(e *proto.NetworkRequestWillBeSent) { update(e.RequestID, true) },
+		func(e *proto.NetworkLoadingFinished) { update(e.RequestID, false) },
+		func(e *proto.NetworkLoadingFailed) { update(e.RequestID, false) },
+	)
+	go wait()
+
+	busy := func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(inflight) > maxInflight
+	}
+
+	timer := time.NewTimer(idle)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return waitFailed(ctx, "network idle")
+		case <-changed:
+			// Any change restarts the quiet period
+			timer.Reset(idle)
+		case <-timer.C:
+			if !busy() {
+				return nil
+			}
+			timer.Reset(idle)
+		}
+	}
+}
+
+// WaitForSelector waits until a CSS selector matches a rendered element,
+// or with gone set, until it no longer does.
+// Returns an error wrapping ErrTimeout if ctx expires first.
+func (b *Browser) WaitForSelector(ctx context.Context, selector string, gone bool) error {
+	what := fmt.Sprintf("selector %q", selector)
+	if gone {
+		what += " to disappear"
+	}
+	return b.poll(ctx, what, func() (bool, error) {
+		page, err := b.page()
+		if err != nil {
+			return false, err
+		}
+		result, err := page.Context(ctx).Eval(selectorStateJS, selector)
+		if err != nil {
+			// Invalid selectors never match
+			if strings.Contains(err.Error(), "SyntaxError") {
+				return false, fmt.Errorf("invalid selector %q: %w", selector, err)
+			}
+			return false, nil // Page navigating; check again
+		}
+		return result.Value.Bool() != gone, nil
+	})
+}
+
+// WaitForText waits until the page text contains text, or with gone set,
+// until it no longer does.
+// Returns an error wrapping ErrTimeout if ctx expires first.
+func (b *Browser) WaitForText(ctx context.Context, text string, gone bool) error {
+	what := fmt.Sprintf("text %q", text)
+	if gone {
+		what += " to disappear"
+	}
+	return b.poll(ctx, what, func() (bool, error) {
+		page, err := b.page()
+		if err != nil {
+			return false, err
+		}
+		result, err := page.Context(ctx).Eval(textStateJS, text)
+		if err != nil {
+			return false, nil // Page navigating; check again
+		}
+		return result.Value.Bool() != gone, nil
+	})
+}
+
+// WaitForURL waits until the page URL matches pattern: a glob where *
+// matches any characters if the pattern contains *, else a substring.
+// Returns an error wrapping ErrTimeout if ctx expires first.
+func (b *Browser) WaitForURL(ctx context.Context, pattern string) error {
+	match := urlMatcher(pattern)
+	return b.poll(ctx, fmt.Sprintf("URL matching %q", pattern), func() (bool, error) {
+		if err := b.Err(); err != nil {
+			return false, err
+		}
+		return match(b.GetURL()), nil
+	})
+}
+
+// WaitDOMQuiet waits until the DOM has not changed for the quiet duration,
+// e.g. after an animation or a client-side render.
+// Returns an error wrapping ErrTimeout if ctx expires first.
+func (b *Browser) WaitDOMQuiet(ctx context.Context, quiet time.Duration) error {
+	page, err := b.page()
+	if err != nil {
+		return err
+	}
+
+	timeout := 30 * time.Second
+	if deadline, ok := ctx.Deadline(); ok {
+		timeout = time.Until(deadline)
+	}
+	result, err := page.Context(ctx).Eval(domQuietJS, quiet.Milliseconds(), timeout.Milliseconds())
+	if err != nil {
+		if ctx.Err() != nil {
+			return waitFailed(ctx, "DOM to settle")
+		}
+		return fmt.Errorf("waiting for DOM to settle failed: %w", err)
+	}
+	if !result.Value.Bool() {
+		return fmt.Errorf("%w: DOM still changing after %s", ErrTimeout, timeout.Round(time.Millisecond))
+	}
+	return nil
+}
+
+// poll checks a condition until it holds, fails or ctx expires.
+func (b *Browser) poll(ctx context.Context, what string, check func() (bool, error)) error {
+	ticker := time.NewTicker(waitPollInterval)
+	defer ticker.Stop()
+	for {
+		ok, err := check()
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return waitFailed(ctx, what)
+		case <-ticker.C:
+		}
+	}
+}
+
+// waitFailed reports why a wait ended early: ErrTimeout if its deadline
+// passed, else the context's error.
+func waitFailed(ctx context.Context, what string) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: waiting for %s", ErrTimeout, what)
+	}
+	return ctx.Err()
+}
+
+// urlMatcher compiles a URL pattern for WaitForURL.
+func urlMatcher(pattern string) func(string) bool {
+	if !strings.Contains(pattern, "*") {
+		return func(url string) bool { return strings.Contains(url, pattern) }
+	}
+	parts := strings.Split(pattern, "*")
+	for i, part := range parts {
+		parts[i] = regexp.QuoteMeta(part)
+	}
+	re := regexp.MustCompile("^" + strings.Join(parts, ".*") + "$")
+	return re.MatchString
+}
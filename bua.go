@@ -52,6 +52,12 @@ func (a *Agent) Start(ctx context.Context) error {
 		ShowHighlight:     a.config.ShowHighlight,
 		HighlightDuration: time.Duration(a.config.HighlightDurationMs) * time.Millisecond,
 		Debug:             a.config.Debug,
+
+		IncludeOffscreenElements: a.config.IncludeOffscreenElements,
+		ActionTimeout:            a.config.ActionTimeout,
+		NavigationTimeout:        a.config.NavigationTimeout,
+		URLPolicy:                a.config.URLPolicy,
+		Secrets:                  a.config.Secrets,
 	}
 
 	// Create browser
@@ -71,11 +77,29 @@ func (a *Agent) Start(ctx context.Context) error {
 		APIKey:          a.config.APIKey,
 		Model:           a.config.Model,
 		MaxSteps:        a.config.MaxSteps,
+		MaxTextChars:    a.config.MaxTextChars,
 		TextOnly:        a.config.TextOnly,
 		MaxWidth:        a.config.ScreenshotMaxWidth,
 		Debug:           a.config.Debug,
 		ScreenshotDir:   a.config.ScreenshotDir,
 		ShowAnnotations: a.config.ShowAnnotations,
+		CheckpointStore: a.config.CheckpointStore,
+		ActionCache:     a.config.ActionCache,
+		Planner:         a.config.Planner,
+		PlannerModel:    a.config.PlannerModel,
+		MultiAction:     a.config.MultiAction,
+		Retry:           a.config.Retry,
+		FallbackModel:   a.config.FallbackModel,
+		RelaunchBrowser: a.config.RelaunchBrowser,
+		MaxDuration:     a.config.MaxDuration,
+		StepTimeout:     a.config.StepTimeout,
+		ToolPermissions: a.config.ToolPermissions,
+		CustomTools:     a.config.CustomTools,
+	}
+	if onStep := a.config.OnStep; onStep != nil {
+		agentCfg.OnStep = func(s agent.Step) {
+			onStep(convertStep(s))
+		}
 	}
 
 	browserAgent, err := agent.NewBrowserAgent(ctx, agentCfg, b)
@@ -106,8 +130,33 @@ func (a *Agent) Run(ctx context.Context, task string) (*Result, error) {
 		return nil, err
 	}
 
-	// Convert agent result to public Result type
+	return convertResult(agentResult), nil
+}
+
+// Resume continues an interrupted run from its last checkpoint.
+// Requires Config.CheckpointStore. The browser is restored to the saved
+// tabs, cookies and localStorage before the agent continues.
+func (a *Agent) Resume(ctx context.Context, runID string) (*Result, error) {
+	a.mu.RLock()
+	started := a.started
+	a.mu.RUnlock()
+
+	if !started {
+		return nil, ErrNotStarted
+	}
+
+	agentResult, err := a.agent.Resume(ctx, runID)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertResult(agentResult), nil
+}
+
+// convertResult converts an agent result to the public Result type.
+func convertResult(agentResult *agent.Result) *Result {
 	result := &Result{
+		RunID:           agentResult.RunID,
 		Success:         agentResult.Success,
 		Data:            agentResult.Data,
 		Error:           agentResult.Error,
@@ -115,23 +164,45 @@ func (a *Agent) Run(ctx context.Context, task string) (*Result, error) {
 		TokensUsed:      agentResult.TokensUsed,
 		Steps:           make([]Step, len(agentResult.Steps)),
 		ScreenshotPaths: agentResult.ScreenshotPaths,
+		CacheHits:       agentResult.CacheHits,
+		CacheMisses:     agentResult.CacheMisses,
+		Plan:            agentResult.Plan,
+		Retries:         agentResult.Retries,
+		Partial:         agentResult.Partial,
+		script:          agent.NewScript(agentResult.Task, agentResult.Steps),
 	}
 
 	for i, s := range agentResult.Steps {
-		result.Steps[i] = Step{
-			Number:         s.Number,
-			Action:         s.Action,
-			Target:         s.Target,
-			Thinking:       s.Thinking,
-			Evaluation:     s.Evaluation,
-			NextGoal:       s.NextGoal,
-			Memory:         s.Memory,
-			Duration:       time.Duration(s.DurationMs) * time.Millisecond,
-			ScreenshotPath: s.ScreenshotPath,
-		}
+		result.Steps[i] = convertStep(s)
 	}
 
-	return result, nil
+	return result
+}
+
+// convertStep converts an agent step to the public Step type.
+func convertStep(s agent.Step) Step {
+	return Step{
+		Number:             s.Number,
+		Action:             s.Action,
+		Target:             s.Target,
+		URL:                s.URL,
+		Locator:            s.Locator,
+		Thinking:           s.Thinking,
+		Evaluation:         s.Evaluation,
+		NextGoal:           s.NextGoal,
+		Memory:             s.Memory,
+		Title:              s.Title,
+		Duration:           time.Duration(s.DurationMs) * time.Millisecond,
+		ModelDuration:      time.Duration(s.ModelMs) * time.Millisecond,
+		ToolDuration:       time.Duration(s.ToolMs) * time.Millisecond,
+		ScreenshotDuration: time.Duration(s.ScreenshotMs) * time.Millisecond,
+		Error:              s.Error,
+		ErrorCode:          s.ErrorCode,
+		ScreenshotPath:     s.ScreenshotPath,
+		Cached:             s.Cached,
+		PlanItem:           s.PlanItem,
+		Plan:               s.Plan,
+	}
 }
 
 // Navigate opens a URL in the browser.
@@ -145,7 +216,8 @@ func (a *Agent) Navigate(ctx context.Context, url string) error {
 		return ErrNotStarted
 	}
 
-	return a.browser.Navigate(ctx, url)
+	_, err := a.browser.Navigate(ctx, url)
+	return err
 }
 
 // Close shuts down the browser and cleans up resources.
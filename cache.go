@@ -0,0 +1,42 @@
+package bua
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/anxuanzi/bua/agent"
+)
+
+// ActionCache stores the action the model chose for a page fingerprint
+// (URL pattern, task, previous action and element structure) so repeat
+// visits can skip the model. Implement it to use your own storage.
+type ActionCache = agent.ActionCache
+
+// CachedAction is a cached action and the page state it led to.
+type CachedAction = agent.CachedAction
+
+// MemoryActionCache keeps cached actions in memory.
+type MemoryActionCache = agent.MemoryActionCache
+
+// FileActionCache stores each cached action as a JSON file in a directory.
+type FileActionCache = agent.FileActionCache
+
+// SQLiteActionCache stores cached actions in a SQLite table.
+type SQLiteActionCache = agent.SQLiteActionCache
+
+// NewMemoryActionCache creates an in-memory action cache, shared by every
+// run of the agents it is configured on.
+func NewMemoryActionCache() *MemoryActionCache {
+	return agent.NewMemoryActionCache()
+}
+
+// NewFileActionCache creates an action cache that writes one JSON file per entry to dir.
+func NewFileActionCache(dir string) (*FileActionCache, error) {
+	return agent.NewFileActionCache(dir)
+}
+
+// NewSQLiteActionCache creates an action cache backed by a SQLite database.
+// Open db with any SQLite driver (e.g. modernc.org/sqlite or github.com/mattn/go-sqlite3).
+func NewSQLiteActionCache(ctx context.Context, db *sql.DB) (*SQLiteActionCache, error) {
+	return agent.NewSQLiteActionCache(ctx, db)
+}
@@ -0,0 +1,33 @@
+package bua
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/anxuanzi/bua/agent"
+)
+
+// CheckpointStore persists run state after every step so interrupted runs
+// can be continued with Agent.Resume. Implement it to use your own storage.
+type CheckpointStore = agent.CheckpointStore
+
+// Checkpoint is a snapshot of a run: the model conversation, step history,
+// open tabs and browser storage.
+type Checkpoint = agent.Checkpoint
+
+// FileCheckpointStore stores each run as a JSON file in a directory.
+type FileCheckpointStore = agent.FileCheckpointStore
+
+// SQLiteCheckpointStore stores checkpoints in a SQLite table.
+type SQLiteCheckpointStore = agent.SQLiteCheckpointStore
+
+// NewFileCheckpointStore creates a checkpoint store that writes one JSON file per run to dir.
+func NewFileCheckpointStore(dir string) (*FileCheckpointStore, error) {
+	return agent.NewFileCheckpointStore(dir)
+}
+
+// NewSQLiteCheckpointStore creates a checkpoint store backed by a SQLite database.
+// Open db with any SQLite driver (e.g. modernc.org/sqlite or github.com/mattn/go-sqlite3).
+func NewSQLiteCheckpointStore(ctx context.Context, db *sql.DB) (*SQLiteCheckpointStore, error) {
+	return agent.NewSQLiteCheckpointStore(ctx, db)
+}
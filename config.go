@@ -3,6 +3,7 @@ package bua
 import (
 	"os"
 	"path/filepath"
+	"time"
 )
 
 // Preset defines token/quality tradeoffs for different use cases.
@@ -78,6 +79,11 @@ type Config struct {
 	// Set automatically based on Preset if not specified.
 	MaxElements int
 
+	// MaxTextChars is the character budget for non-interactive page text
+	// (headings, paragraphs, alerts, labels) included in the page state.
+	// Set automatically based on Preset if not specified. Negative disables it.
+	MaxTextChars int
+
 	// ScreenshotMaxWidth is the maximum width for screenshots.
 	// Set automatically based on Preset if not specified.
 	ScreenshotMaxWidth int
@@ -90,6 +96,11 @@ type Config struct {
 	// Set automatically based on Preset if not specified.
 	TextOnly bool
 
+	// IncludeOffscreenElements includes interactive elements from the whole page
+	// in the page state, not only those in the viewport. Offscreen elements are
+	// always summarized in the scroll context. Default: false.
+	IncludeOffscreenElements bool
+
 	// ShowAnnotations displays element indices on the page during execution.
 	// Useful for debugging. Default: false.
 	ShowAnnotations bool
@@ -105,12 +116,101 @@ type Config struct {
 	// ScreenshotDir is the directory to save screenshots.
 	// Default: system temp directory.
 	ScreenshotDir string
+
+	// CheckpointStore saves run state after every step so an interrupted
+	// run can be continued with Resume. Default: nil (no persistence).
+	CheckpointStore CheckpointStore
+
+	// ActionCache remembers the action chosen on each page and replays it
+	// without a model call when the same task reaches the same page again.
+	// Default: nil (no caching).
+	ActionCache ActionCache
+
+	// Planner adds a planner sub-agent that breaks the task into a
+	// checklist, tracks progress and replans when actions fail, while the
+	// main agent executes the current item. Default: false.
+	Planner bool
+
+	// PlannerModel is the Gemini model for the planner, e.g. a stronger
+	// model than Model. Default: same as Model.
+	PlannerModel string
+
+	// OnStep is called after every step with the completed step, for
+	// progress reporting. It runs on the agent's goroutine, so keep it fast.
+	OnStep func(Step)
+
+	// MultiAction lets the model issue several actions in one turn, such as
+	// filling a whole form, instead of one model call per action. Actions
+	// run in order and each is recorded as its own Step; the rest of a batch
+	// is skipped if the URL changes or elements appear or disappear.
+	// Default: false.
+	MultiAction bool
+
+	// Retry controls retries of model calls that fail with transient errors
+	// such as rate limits or server overload. Default: up to 5 attempts,
+	// backing off from 1s to 30s with 20% jitter.
+	Retry RetryPolicy
+
+	// FallbackModel is the Gemini model to switch to once Model has failed
+	// Retry.FallbackAfter attempts of the same call. Default: none.
+	FallbackModel string
+
+	// RelaunchBrowser relaunches the browser with the same profile if it
+	// crashes or disconnects mid-run, reopens the tabs (and restores cookies
+	// and localStorage for temporary profiles) and continues from the last
+	// step. Otherwise the run stops with ErrBrowserClosed. Default: false.
+	RelaunchBrowser bool
+
+	// ActionTimeout bounds a single browser action such as a click, typing,
+	// a script evaluation or a screenshot. Default: 30s. Negative disables it.
+	ActionTimeout time.Duration
+
+	// NavigationTimeout bounds navigating, going back or forward, reloading
+	// and opening a tab, including waiting for the page to load.
+	// Default: 60s. Negative disables it.
+	NavigationTimeout time.Duration
+
+	// MaxDuration is the time budget of a run. When little time is left the
+	// agent is told to wrap up and call done with what it has; when it runs
+	// out the run stops with Result.Partial set. Resume continues the
+	// original budget. Default: 0 (no limit).
+	MaxDuration time.Duration
+
+	// StepTimeout bounds each turn: the model call and the actions it
+	// issues. A turn that runs over fails its step and the agent carries on.
+	// Default: 0 (no limit).
+	StepTimeout time.Duration
+
+	// URLPolicy restricts the domains and URL schemes the agent may load.
+	// Blocked navigations, including ones caused by clicking links, fail
+	// with error code url_blocked and leave the page unchanged.
+	// Default: no restrictions.
+	URLPolicy URLPolicy
+
+	// ToolPermissions restricts the tools the model may use, by profile
+	// and allow/deny lists, and validates their arguments. Use
+	// ToolProfileReadOnly or ToolProfileFormFilling to keep evaluate_js
+	// away from less trusted users. Default: all tools.
+	ToolPermissions ToolPermissions
+
+	// CustomTools are user-defined tools offered to the model alongside the
+	// browser tools. Each is created at Start with access to the browser
+	// and the current element map. Default: none.
+	CustomTools []CustomTool
+
+	// Secrets are values the model can type without ever seeing them, keyed
+	// by name. The model writes <secret>name</secret> in type_text and
+	// clear_and_type, and the real value is substituted when typing. Values
+	// are replaced by their placeholders in page state, extracted content,
+	// tool results and steps. Default: none.
+	Secrets map[string]string
 }
 
 // presetConfig defines the configuration for each preset.
 type presetConfig struct {
 	MaxTokens          int
 	MaxElements        int
+	MaxTextChars       int
 	ScreenshotMaxWidth int
 	ScreenshotQuality  int
 	TextOnly           bool
@@ -120,6 +220,7 @@ var presetConfigs = map[Preset]presetConfig{
 	PresetFast: {
 		MaxTokens:          8000,
 		MaxElements:        30,
+		MaxTextChars:       1000,
 		ScreenshotMaxWidth: 0,
 		ScreenshotQuality:  0,
 		TextOnly:           true,
@@ -127,6 +228,7 @@ var presetConfigs = map[Preset]presetConfig{
 	PresetEfficient: {
 		MaxTokens:          16000,
 		MaxElements:        50,
+		MaxTextChars:       1500,
 		ScreenshotMaxWidth: 800,
 		ScreenshotQuality:  60,
 		TextOnly:           false,
@@ -134,6 +236,7 @@ var presetConfigs = map[Preset]presetConfig{
 	PresetBalanced: {
 		MaxTokens:          32000,
 		MaxElements:        100,
+		MaxTextChars:       2000,
 		ScreenshotMaxWidth: 1280,
 		ScreenshotQuality:  75,
 		TextOnly:           false,
@@ -141,6 +244,7 @@ var presetConfigs = map[Preset]presetConfig{
 	PresetQuality: {
 		MaxTokens:          64000,
 		MaxElements:        200,
+		MaxTextChars:       4000,
 		ScreenshotMaxWidth: 1920,
 		ScreenshotQuality:  85,
 		TextOnly:           false,
@@ -148,6 +252,7 @@ var presetConfigs = map[Preset]presetConfig{
 	PresetMax: {
 		MaxTokens:          128000,
 		MaxElements:        500,
+		MaxTextChars:       8000,
 		ScreenshotMaxWidth: 2560,
 		ScreenshotQuality:  95,
 		TextOnly:           false,
@@ -190,6 +295,9 @@ func (c *Config) applyDefaults() {
 	if c.MaxElements == 0 {
 		c.MaxElements = preset.MaxElements
 	}
+	if c.MaxTextChars == 0 {
+		c.MaxTextChars = preset.MaxTextChars
+	}
 	if c.ScreenshotMaxWidth == 0 {
 		c.ScreenshotMaxWidth = preset.ScreenshotMaxWidth
 	}
@@ -0,0 +1,305 @@
+package dom
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// ElementChange records an element whose text or value changed between extractions.
+type ElementChange struct {
+	Before *Element
+	After  *Element
+}
+
+// ElementDiff describes how the interactive elements changed between two extractions.
+// Elements are matched by identity (tag, role, selector, href, labels) since
+// indices are reassigned on every extraction.
+type ElementDiff struct {
+	// Added contains elements present now but not in the previous map.
+	Added []*Element
+
+	// Removed contains elements from the previous map that are gone.
+	Removed []*Element
+
+	// Changed contains elements whose text or value changed.
+	Changed []ElementChange
+
+	// AddedText contains non-interactive text blocks that appeared,
+	// such as toasts and validation messages.
+	AddedText []*TextBlock
+
+	// RemovedText contains text blocks that disappeared.
+	RemovedText []*TextBlock
+
+	// added provides O(1) lookup of added elements by current index.
+	added map[int]bool
+
+	// addedText provides O(1) lookup of added text blocks.
+	addedText map[*TextBlock]bool
+}
+
+// Diff compares two element maps. Returns nil if either map is nil or the
+// page URL changed, since a diff across navigations is just noise.
+func Diff(prev, curr *ElementMap) *ElementDiff {
+	if prev == nil || curr == nil {
+		return nil
+	}
+	if prev == curr {
+		return &ElementDiff{}
+	}
+
+	prev.mu.RLock()
+	defer prev.mu.RUnlock()
+	curr.mu.RLock()
+	defer curr.mu.RUnlock()
+
+	if prev.PageURL != curr.PageURL {
+		return nil
+	}
+
+	diff := &ElementDiff{
+		added:     make(map[int]bool),
+		addedText: make(map[*TextBlock]bool),
+	}
+
+	// First pass: exact matches (identity + content) are unchanged
+	exact := make(map[string][]*Element)
+	for _, el := range prev.Elements {
+		key := identityKey(el) + "\x00" + contentKey(el)
+		exact[key] = append(exact[key], el)
+	}
+	matchedPrev := make(map[*Element]bool)
+	var unmatched []*Element
+	for _, el := range curr.Elements {
+		key := identityKey(el) + "\x00" + contentKey(el)
+		if candidates := exact[key]; len(candidates) > 0 {
+			matchedPrev[candidates[0]] = true
+			exact[key] = candidates[1:]
+			continue
+		}
+		unmatched = append(unmatched, el)
+	}
+
+	// Second pass: same identity with different content is a change
+	byIdentity := make(map[string][]*Element)
+	for _, el := range prev.Elements {
+		if matchedPrev[el] {
+			continue
+		}
+		key := identityKey(el)
+		byIdentity[key] = append(byIdentity[key], el)
+	}
+	for _, el := range unmatched {
+		key := identityKey(el)
+		if candidates := byIdentity[key]; len(candidates) > 0 {
+			matchedPrev[candidates[0]] = true
+			diff.Changed = append(diff.Changed, ElementChange{Before: candidates[0], After: el})
+			byIdentity[key] = candidates[1:]
+			continue
+		}
+		diff.Added = append(diff.Added, el)
+		diff.added[el.Index] = true
+	}
+
+	for _, el := range prev.Elements {
+		if !matchedPrev[el] {
+			diff.Removed = append(diff.Removed, el)
+		}
+	}
+
+	// Text blocks have no identity beyond their content
+	prevText := make(map[string]int)
+	for _, tb := range prev.TextBlocks {
+		prevText[textKey(tb)]++
+	}
+	for _, tb := range curr.TextBlocks {
+		key := textKey(tb)
+		if prevText[key] > 0 {
+			prevText[key]--
+			continue
+		}
+		diff.AddedText = append(diff.AddedText, tb)
+		diff.addedText[tb] = true
+	}
+	for _, tb := range prev.TextBlocks {
+		key := textKey(tb)
+		if prevText[key] > 0 {
+			prevText[key]--
+			diff.RemovedText = append(diff.RemovedText, tb)
+		}
+	}
+
+	return diff
+}
+
+// layoutTolerance is how far, in CSS pixels, an element may move and still
+// count as in the same place.
+const layoutTolerance = 1.0
+
+// SameIndices reports whether every index refers to the same element, by
+// identity, in both maps. Diff ignores indices, so a reorder can leave the
+// diff empty while indices point at different elements.
+func SameIndices(prev, curr *ElementMap) bool {
+	return sameElements(prev, curr, false)
+}
+
+// SameLayout reports whether every index refers to the same element at the
+// same position in both maps, so coordinates taken from prev still hit it.
+func SameLayout(prev, curr *ElementMap) bool {
+	return sameElements(prev, curr, true)
+}
+
+// sameElements compares the maps index by index.
+func sameElements(prev, curr *ElementMap, layout bool) bool {
+	if prev == nil || curr == nil {
+		return false
+	}
+	if prev == curr {
+		return true
+	}
+
+	prev.mu.RLock()
+	defer prev.mu.RUnlock()
+	curr.mu.RLock()
+	defer curr.mu.RUnlock()
+
+	if len(prev.Elements) != len(curr.Elements) {
+		return false
+	}
+	for i, before := range prev.Elements {
+		after := curr.Elements[i]
+		if before.Index != after.Index || identityKey(before) != identityKey(after) {
+			return false
+		}
+		if layout && !sameBox(before.BoundingBox, after.BoundingBox) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameBox reports whether two boxes match within layoutTolerance.
+func sameBox(a, b BoundingBox) bool {
+	return math.Abs(a.X-b.X) <= layoutTolerance && math.Abs(a.Y-b.Y) <= layoutTolerance &&
+		math.Abs(a.Width-b.Width) <= layoutTolerance && math.Abs(a.Height-b.Height) <= layoutTolerance
+}
+
+// textKey identifies a text block by kind and content.
+func textKey(tb *TextBlock) string {
+	return tb.Kind + "\x00" + tb.Text
+}
+
+// identityKey identifies an element independently of its index and content.
+func identityKey(el *Element) string {
+	return strings.Join([]string{
+		el.TagName, el.Type, el.Role, el.Selector, el.Href,
+		el.Name, el.AriaLabel, el.Placeholder,
+	}, "\x00")
+}
+
+// contentKey captures the parts of an element that change as the user interacts.
+func contentKey(el *Element) string {
+	return el.Text + "\x00" + el.Value
+}
+
+// IsEmpty returns true if nothing changed.
+func (d *ElementDiff) IsEmpty() bool {
+	return d == nil || (len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0 &&
+		len(d.AddedText) == 0 && len(d.RemovedText) == 0)
+}
+
+// IsNew returns true if the element with the given current index was added.
+func (d *ElementDiff) IsNew(index int) bool {
+	if d == nil {
+		return false
+	}
+	return d.added[index]
+}
+
+// IsNewText returns true if the text block appeared since the previous extraction.
+func (d *ElementDiff) IsNewText(tb *TextBlock) bool {
+	if d == nil {
+		return false
+	}
+	return d.addedText[tb]
+}
+
+// ToTokenString summarizes the diff for LLM consumption.
+// At most maxItems entries are listed per category (0 = no limit).
+func (d *ElementDiff) ToTokenString(maxItems int) string {
+	if d.IsEmpty() {
+		return ""
+	}
+
+	var sb strings.Builder
+
+	if len(d.Added) > 0 {
+		sb.WriteString(fmt.Sprintf("New since last step (%d):\n", len(d.Added)))
+		for i, el := range d.Added {
+			if maxItems > 0 && i >= maxItems {
+				sb.WriteString(fmt.Sprintf("  ... and %d more\n", len(d.Added)-maxItems))
+				break
+			}
+			sb.WriteString(fmt.Sprintf("  [%d] %s %q\n", el.Index, el.TagName, truncateLabel(el.Description())))
+		}
+	}
+
+	if len(d.AddedText) > 0 {
+		sb.WriteString(fmt.Sprintf("New text since last step (%d):\n", len(d.AddedText)))
+		for i, tb := range d.AddedText {
+			if maxItems > 0 && i >= maxItems {
+				sb.WriteString(fmt.Sprintf("  ... and %d more\n", len(d.AddedText)-maxItems))
+				break
+			}
+			sb.WriteString(fmt.Sprintf("  %s %q\n", tb.Kind, truncateLabel(tb.Text)))
+		}
+	}
+
+	if len(d.Changed) > 0 {
+		sb.WriteString(fmt.Sprintf("Changed since last step (%d):\n", len(d.Changed)))
+		for i, c := range d.Changed {
+			if maxItems > 0 && i >= maxItems {
+				sb.WriteString(fmt.Sprintf("  ... and %d more\n", len(d.Changed)-maxItems))
+				break
+			}
+			sb.WriteString(fmt.Sprintf("  [%d] %s\n", c.After.Index, describeChange(c)))
+		}
+	}
+
+	if len(d.Removed) > 0 {
+		sb.WriteString(fmt.Sprintf("Removed since last step (%d):\n", len(d.Removed)))
+		for i, el := range d.Removed {
+			if maxItems > 0 && i >= maxItems {
+				sb.WriteString(fmt.Sprintf("  ... and %d more\n", len(d.Removed)-maxItems))
+				break
+			}
+			sb.WriteString(fmt.Sprintf("  %s %q\n", el.TagName, truncateLabel(el.Description())))
+		}
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// describeChange formats the text/value change of a single element.
+func describeChange(c ElementChange) string {
+	var parts []string
+	if c.Before.Value != c.After.Value {
+		parts = append(parts, fmt.Sprintf("value %q -> %q", truncateLabel(c.Before.Value), truncateLabel(c.After.Value)))
+	}
+	if c.Before.Text != c.After.Text && c.Before.Text != c.Before.Value {
+		parts = append(parts, fmt.Sprintf("text %q -> %q", truncateLabel(c.Before.Text), truncateLabel(c.After.Text)))
+	}
+	if len(parts) == 0 {
+		return c.After.TagName
+	}
+	return c.After.TagName + " " + strings.Join(parts, ", ")
+}
+
+// truncateLabel shortens a label for diff output.
+func truncateLabel(s string) string {
+	if len(s) > 40 {
+		return s[:40] + "..."
+	}
+	return s
+}
@@ -0,0 +1,204 @@
+package dom
+
+import "testing"
+
+// newMap builds an element map for a page, indexing elements in order.
+func newMap(url string, elements []*Element, text ...*TextBlock) *ElementMap {
+	m := NewElementMap()
+	m.PageURL = url
+	for i, el := range elements {
+		el.Index = i
+		m.Add(el)
+	}
+	m.TextBlocks = text
+	return m
+}
+
+func button(label string) *Element {
+	return &Element{TagName: "BUTTON", Text: label, Selector: "button", BoundingBox: BoundingBox{Width: 80, Height: 20}}
+}
+
+func TestDiff(t *testing.T) {
+	const page = "https://example.com"
+
+	tests := []struct {
+		name        string
+		prev, curr  *ElementMap
+		nilDiff     bool
+		added       int
+		removed     int
+		changed     int
+		addedText   int
+		removedText int
+		sameIndices bool
+	}{
+		{
+			name:    "nil map",
+			prev:    nil,
+			curr:    newMap(page, nil),
+			nilDiff: true,
+		},
+		{
+			name:    "navigation",
+			prev:    newMap(page, []*Element{button("Go")}),
+			curr:    newMap(page+"/next", []*Element{button("Go")}),
+			nilDiff: true,
+		},
+		{
+			name:        "unchanged",
+			prev:        newMap(page, []*Element{button("A"), button("B")}),
+			curr:        newMap(page, []*Element{button("A"), button("B")}),
+			sameIndices: true,
+		},
+		{
+			name:        "duplicate identical elements",
+			prev:        newMap(page, []*Element{button("Add"), button("Add"), button("Add")}),
+			curr:        newMap(page, []*Element{button("Add"), button("Add"), button("Add")}),
+			sameIndices: true,
+		},
+		{
+			name:  "one more duplicate",
+			prev:  newMap(page, []*Element{button("Add"), button("Add")}),
+			curr:  newMap(page, []*Element{button("Add"), button("Add"), button("Add")}),
+			added: 1,
+		},
+		{
+			name:    "one fewer duplicate",
+			prev:    newMap(page, []*Element{button("Add"), button("Add"), button("Add")}),
+			curr:    newMap(page, []*Element{button("Add"), button("Add")}),
+			removed: 1,
+		},
+		{
+			name: "reorder",
+			prev: newMap(page, []*Element{
+				{TagName: "A", Href: "/one", Text: "One"},
+				{TagName: "A", Href: "/two", Text: "Two"},
+			}),
+			curr: newMap(page, []*Element{
+				{TagName: "A", Href: "/two", Text: "Two"},
+				{TagName: "A", Href: "/one", Text: "One"},
+			}),
+		},
+		{
+			name:        "value changed",
+			prev:        newMap(page, []*Element{{TagName: "INPUT", Name: "q", Value: ""}}),
+			curr:        newMap(page, []*Element{{TagName: "INPUT", Name: "q", Value: "shoes"}}),
+			changed:     1,
+			sameIndices: true,
+		},
+		{
+			name:    "element replaced",
+			prev:    newMap(page, []*Element{{TagName: "A", Href: "/old"}}),
+			curr:    newMap(page, []*Element{{TagName: "A", Href: "/new"}}),
+			added:   1,
+			removed: 1,
+		},
+		{
+			name:        "text block added",
+			prev:        newMap(page, []*Element{button("Save")}),
+			curr:        newMap(page, []*Element{button("Save")}, &TextBlock{Kind: "alert", Text: "Saved"}),
+			addedText:   1,
+			sameIndices: true,
+		},
+		{
+			name:        "text block removed",
+			prev:        newMap(page, []*Element{button("Save")}, &TextBlock{Kind: "alert", Text: "Saving"}),
+			curr:        newMap(page, []*Element{button("Save")}),
+			removedText: 1,
+			sameIndices: true,
+		},
+		{
+			name:        "text block changed",
+			prev:        newMap(page, nil, &TextBlock{Kind: "text", Text: "1 item"}),
+			curr:        newMap(page, nil, &TextBlock{Kind: "text", Text: "2 items"}),
+			addedText:   1,
+			removedText: 1,
+			sameIndices: true,
+		},
+		{
+			name:        "duplicate text blocks",
+			prev:        newMap(page, nil, &TextBlock{Kind: "text", Text: "Row"}),
+			curr:        newMap(page, nil, &TextBlock{Kind: "text", Text: "Row"}, &TextBlock{Kind: "text", Text: "Row"}),
+			addedText:   1,
+			sameIndices: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := Diff(tt.prev, tt.curr)
+			if tt.nilDiff {
+				if diff != nil {
+					t.Fatalf("Diff() = %+v, want nil", diff)
+				}
+				return
+			}
+			if diff == nil {
+				t.Fatal("Diff() = nil")
+			}
+			if len(diff.Added) != tt.added || len(diff.Removed) != tt.removed || len(diff.Changed) != tt.changed {
+				t.Errorf("elements: added %d, removed %d, changed %d; want %d, %d, %d",
+					len(diff.Added), len(diff.Removed), len(diff.Changed), tt.added, tt.removed, tt.changed)
+			}
+			if len(diff.AddedText) != tt.addedText || len(diff.RemovedText) != tt.removedText {
+				t.Errorf("text: added %d, removed %d; want %d, %d",
+					len(diff.AddedText), len(diff.RemovedText), tt.addedText, tt.removedText)
+			}
+			for _, el := range diff.Added {
+				if !diff.IsNew(el.Index) {
+					t.Errorf("IsNew(%d) = false for an added element", el.Index)
+				}
+			}
+			for _, tb := range diff.AddedText {
+				if !diff.IsNewText(tb) {
+					t.Errorf("IsNewText(%q) = false for an added text block", tb.Text)
+				}
+			}
+			if got := SameIndices(tt.prev, tt.curr); got != tt.sameIndices {
+				t.Errorf("SameIndices() = %v, want %v", got, tt.sameIndices)
+			}
+		})
+	}
+}
+
+func TestDiffReorderIsEmpty(t *testing.T) {
+	prev := newMap("https://example.com", []*Element{button("A"), {TagName: "A", Href: "/b"}})
+	curr := newMap("https://example.com", []*Element{{TagName: "A", Href: "/b"}, button("A")})
+
+	if diff := Diff(prev, curr); !diff.IsEmpty() {
+		t.Fatalf("Diff() = %+v, want empty for a reorder", diff)
+	}
+	if SameIndices(prev, curr) {
+		t.Error("SameIndices() = true for a reorder")
+	}
+}
+
+func TestSameLayout(t *testing.T) {
+	moved := func(dy float64) *ElementMap {
+		el := button("A")
+		el.BoundingBox.Y += dy
+		return newMap("https://example.com", []*Element{el})
+	}
+	base := moved(0)
+
+	tests := []struct {
+		name string
+		curr *ElementMap
+		want bool
+	}{
+		{name: "same map", curr: base, want: true},
+		{name: "same position", curr: moved(0), want: true},
+		{name: "within tolerance", curr: moved(layoutTolerance / 2), want: true},
+		{name: "moved", curr: moved(50), want: false},
+		{name: "element added", curr: newMap("https://example.com", []*Element{button("A"), button("B")}), want: false},
+		{name: "nil", curr: nil, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := SameLayout(base, tt.curr); got != tt.want {
+				t.Errorf("SameLayout() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
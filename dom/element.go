@@ -92,6 +92,10 @@ type Element struct {
 
 	// BackendNodeID is the CDP backend node ID.
 	BackendNodeID int `json:"backendNodeId,omitempty"`
+
+	// Order is the element's position in document (reading) order,
+	// shared with TextBlock.Order for interleaving.
+	Order int `json:"order"`
 }
 
 // Description returns a human-readable description of the element.
@@ -138,6 +142,93 @@ func (e *Element) GetBoundingBox() BoundingBoxInfoInterface {
 // GetIsVisible implements ElementInfo interface for screenshot annotations.
 func (e *Element) GetIsVisible() bool { return e.IsVisible }
 
+// Text block kinds.
+const (
+	TextKindHeading = "heading"
+	TextKindText    = "text"
+	TextKindAlert   = "alert"
+	TextKindLabel   = "label"
+	TextKindRow     = "row"
+)
+
+// TextBlock is a visible piece of non-interactive page content,
+// such as a heading, paragraph, alert, form label or table row.
+type TextBlock struct {
+	// Kind is one of the TextKind constants.
+	Kind string `json:"kind"`
+
+	// Level is the heading level (1-6) for headings.
+	Level int `json:"level,omitempty"`
+
+	// Text is the visible text (whitespace collapsed, truncated).
+	// Table rows are joined with " | ".
+	Text string `json:"text"`
+
+	// Order is the block's position in document (reading) order.
+	Order int `json:"order"`
+
+	// BoundingBox is the block's position and size.
+	BoundingBox BoundingBox `json:"boundingBox"`
+
+	// IsVisible indicates if the block is in the viewport.
+	IsVisible bool `json:"isVisible"`
+}
+
+// ScrollInfo describes the page scroll position and dimensions.
+type ScrollInfo struct {
+	ScrollX        float64 `json:"scrollX"`
+	ScrollY        float64 `json:"scrollY"`
+	ViewportWidth  float64 `json:"viewportWidth"`
+	ViewportHeight float64 `json:"viewportHeight"`
+	PageWidth      float64 `json:"pageWidth"`
+	PageHeight     float64 `json:"pageHeight"`
+}
+
+// PixelsAbove returns how many pixels of the page are above the viewport.
+func (s ScrollInfo) PixelsAbove() float64 {
+	if s.ScrollY < 0 {
+		return 0
+	}
+	return s.ScrollY
+}
+
+// PixelsBelow returns how many pixels of the page are below the viewport.
+func (s ScrollInfo) PixelsBelow() float64 {
+	below := s.PageHeight - s.ScrollY - s.ViewportHeight
+	if below < 0 {
+		return 0
+	}
+	return below
+}
+
+// PercentScrolled returns the vertical scroll progress (0-100).
+// Pages that fit in the viewport report 100.
+func (s ScrollInfo) PercentScrolled() float64 {
+	scrollable := s.PageHeight - s.ViewportHeight
+	if scrollable <= 0 {
+		return 100
+	}
+	percent := s.PixelsAbove() / scrollable * 100
+	if percent > 100 {
+		percent = 100
+	}
+	return percent
+}
+
+// IsScrollable returns true if the page is taller than the viewport.
+func (s ScrollInfo) IsScrollable() bool {
+	return s.PageHeight > s.ViewportHeight
+}
+
+// OffscreenSummary summarizes interactive elements above and below the viewport.
+// Labels are ordered nearest-to-viewport first.
+type OffscreenSummary struct {
+	AboveCount  int      `json:"aboveCount"`
+	BelowCount  int      `json:"belowCount"`
+	AboveLabels []string `json:"aboveLabels,omitempty"`
+	BelowLabels []string `json:"belowLabels,omitempty"`
+}
+
 // ElementMap holds all interactive elements on a page.
 type ElementMap struct {
 	// Elements is the list of interactive elements.
@@ -149,6 +240,15 @@ type ElementMap struct {
 	// PageTitle is the current page title.
 	PageTitle string
 
+	// Scroll is the page scroll position and size.
+	Scroll ScrollInfo
+
+	// Offscreen summarizes interactive elements outside the viewport.
+	Offscreen OffscreenSummary
+
+	// TextBlocks is the visible non-interactive content in reading order.
+	TextBlocks []*TextBlock
+
 	// indexMap provides O(1) lookup by index.
 	indexMap map[int]*Element
 
@@ -229,9 +329,37 @@ func (m *ElementMap) Clear() {
 	defer m.mu.Unlock()
 
 	m.Elements = make([]*Element, 0)
+	m.TextBlocks = nil
 	m.indexMap = make(map[int]*Element)
 }
 
+// Redact rewrites the page's text, URL, title, element labels and values and
+// offscreen labels with replace, e.g. to hide secrets before the page state is shown.
+func (m *ElementMap) Redact(replace func(string) string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.PageURL = replace(m.PageURL)
+	m.PageTitle = replace(m.PageTitle)
+	for _, el := range m.Elements {
+		el.Name = replace(el.Name)
+		el.Text = replace(el.Text)
+		el.Href = replace(el.Href)
+		el.Placeholder = replace(el.Placeholder)
+		el.Value = replace(el.Value)
+		el.AriaLabel = replace(el.AriaLabel)
+	}
+	for _, block := range m.TextBlocks {
+		block.Text = replace(block.Text)
+	}
+	for i := range m.Offscreen.AboveLabels {
+		m.Offscreen.AboveLabels[i] = replace(m.Offscreen.AboveLabels[i])
+	}
+	for i := range m.Offscreen.BelowLabels {
+		m.Offscreen.BelowLabels[i] = replace(m.Offscreen.BelowLabels[i])
+	}
+}
+
 // FindBySelector returns the first element matching the selector.
 func (m *ElementMap) FindBySelector(selector string) (*Element, bool) {
 	m.mu.RLock()
@@ -0,0 +1,89 @@
+package dom
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestElementMapRedact(t *testing.T) {
+	redact := func(s string) string {
+		return strings.ReplaceAll(s, "hunter2", "***")
+	}
+
+	tests := []struct {
+		name  string
+		build func(m *ElementMap)
+		check func(t *testing.T, m *ElementMap)
+	}{
+		{
+			name: "page URL and title",
+			build: func(m *ElementMap) {
+				m.PageURL = "https://example.com/?pw=hunter2"
+				m.PageTitle = "hunter2"
+			},
+			check: func(t *testing.T, m *ElementMap) {
+				if m.PageURL != "https://example.com/?pw=***" || m.PageTitle != "***" {
+					t.Errorf("got URL %q, title %q", m.PageURL, m.PageTitle)
+				}
+			},
+		},
+		{
+			name: "element fields",
+			build: func(m *ElementMap) {
+				m.Add(&Element{
+					Index: 0, TagName: "INPUT",
+					Name: "hunter2", Text: "hunter2", Href: "/hunter2",
+					Placeholder: "hunter2", Value: "hunter2", AriaLabel: "hunter2",
+				})
+			},
+			check: func(t *testing.T, m *ElementMap) {
+				el, _ := m.Get(0)
+				for field, got := range map[string]string{
+					"Name": el.Name, "Text": el.Text, "Href": el.Href,
+					"Placeholder": el.Placeholder, "Value": el.Value, "AriaLabel": el.AriaLabel,
+				} {
+					if strings.Contains(got, "hunter2") {
+						t.Errorf("%s = %q, want it redacted", field, got)
+					}
+				}
+			},
+		},
+		{
+			name: "text blocks",
+			build: func(m *ElementMap) {
+				m.TextBlocks = []*TextBlock{{Kind: "text", Text: "your password is hunter2"}}
+			},
+			check: func(t *testing.T, m *ElementMap) {
+				if got := m.TextBlocks[0].Text; got != "your password is ***" {
+					t.Errorf("text block = %q", got)
+				}
+			},
+		},
+		{
+			name: "offscreen labels",
+			build: func(m *ElementMap) {
+				m.Offscreen = OffscreenSummary{
+					AboveCount: 1, AboveLabels: []string{"hunter2"},
+					BelowCount: 2, BelowLabels: []string{"Submit", "hunter2 field"},
+				}
+			},
+			check: func(t *testing.T, m *ElementMap) {
+				if got := m.Offscreen.AboveLabels; got[0] != "***" {
+					t.Errorf("above labels = %q", got)
+				}
+				if got := m.Offscreen.BelowLabels; got[0] != "Submit" || got[1] != "*** field" {
+					t.Errorf("below labels = %q", got)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewElementMap()
+			tt.build(m)
+			m.Redact(redact)
+			tt.check(t, m)
+		})
+	}
+}
@@ -11,10 +11,30 @@ import (
 
 // extractionJS is the JavaScript code injected to extract interactive elements.
 // IMPORTANT: Must use arrow function syntax for rod.Eval()
-const extractionJS = `() => {
+// The includeOffscreen argument keeps elements outside the viewport in the
+// element list instead of only counting them in the offscreen summary.
+const extractionJS = `(includeOffscreen) => {
     const elements = [];
     let index = 0;
 
+    // Offscreen summary (labels closest to the viewport are kept)
+    const maxLabels = 8;
+    const above = { count: 0, labels: [] };
+    const below = { count: 0, labels: [] };
+    // Field values are never used: they may hold typed passwords or
+    // secrets, and the cut label could no longer be redacted
+    const labelFor = (node) => {
+        let label = node.getAttribute('aria-label') || '';
+        const isField = node.tagName === 'INPUT' || node.tagName === 'TEXTAREA';
+        if (!label && isField) {
+            label = node.placeholder || node.getAttribute('name') || '';
+        }
+        if (!label && !isField) label = (node.textContent || '').trim().replace(/\s+/g, ' ');
+        if (!label) label = node.getAttribute('title') || node.getAttribute('name') || '';
+        if (label.length > 30) label = label.slice(0, 30) + '...';
+        return label;
+    };
+
     // Selectors for interactive elements
     const interactiveSelectors = [
         'a[href]',
@@ -38,7 +58,27 @@ const extractionJS = `() => {
         'label[for]'
     ];
 
-    const allElements = document.querySelectorAll(interactiveSelectors.join(','));
+    // Selectors for non-interactive content included as page text
+    const textSelectors = [
+        'h1', 'h2', 'h3', 'h4', 'h5', 'h6',
+        '[role="heading"]',
+        'p', 'li', 'dt', 'dd', 'tr', 'caption',
+        'blockquote', 'figcaption',
+        'label:not([for])',
+        '[role="alert"]',
+        '[role="status"]',
+        '[aria-live]:not([aria-live="off"])'
+    ];
+    const alertSelector = '[role="alert"],[role="status"],[aria-live]:not([aria-live="off"])';
+
+    // Reading order shared by interactive elements and text blocks
+    const interactiveSelector = interactiveSelectors.join(',');
+    const textSelector = textSelectors.join(',');
+    const orderOf = new Map();
+    document.querySelectorAll(interactiveSelector + ',' + textSelector)
+        .forEach((n, i) => orderOf.set(n, i));
+
+    const allElements = document.querySelectorAll(interactiveSelector);
     const viewportHeight = window.innerHeight;
     const viewportWidth = window.innerWidth;
 
@@ -48,11 +88,6 @@ const extractionJS = `() => {
         // Skip elements with no size
         if (rect.width <= 0 || rect.height <= 0) continue;
 
-        // Skip elements completely outside viewport (with buffer)
-        const buffer = 100;
-        if (rect.bottom < -buffer || rect.top > viewportHeight + buffer) continue;
-        if (rect.right < -buffer || rect.left > viewportWidth + buffer) continue;
-
         // Check computed styles
         const style = window.getComputedStyle(node);
         if (style.display === 'none') continue;
@@ -60,6 +95,27 @@ const extractionJS = `() => {
         if (parseFloat(style.opacity) < 0.1) continue;
         if (style.pointerEvents === 'none') continue;
 
+        // Classify elements outside viewport (with buffer)
+        const buffer = 100;
+        const isAbove = rect.bottom < -buffer;
+        const isBelow = rect.top > viewportHeight + buffer;
+        const isBeside = rect.right < -buffer || rect.left > viewportWidth + buffer;
+        if (isAbove || isBelow) {
+            const label = labelFor(node);
+            if (isAbove) {
+                above.count++;
+                if (label) {
+                    above.labels.push(label);
+                    if (above.labels.length > maxLabels) above.labels.shift();
+                }
+            } else {
+                below.count++;
+                if (label && below.labels.length < maxLabels) below.labels.push(label);
+            }
+        }
+        const inViewport = !isAbove && !isBelow && !isBeside;
+        if (!inViewport && !includeOffscreen) continue;
+
         // Get text content (truncated)
         let text = '';
         if (node.tagName === 'INPUT' || node.tagName === 'TEXTAREA') {
@@ -125,33 +181,145 @@ const extractionJS = `() => {
                 width: rect.width,
                 height: rect.height
             },
-            isVisible: true,
+            isVisible: inViewport,
             isEnabled: !node.disabled,
             isFocusable: node.tabIndex >= 0,
             isInteractive: true,
-            selector: selector
+            selector: selector,
+            order: orderOf.get(node) || 0
         });
 
         index++;
     }
 
+    // ownText returns a node's visible text, excluding text that belongs to
+    // interactive descendants (e.g. links in a nav list) already listed as elements
+    const ownText = (root) => {
+        if (!root.querySelector(interactiveSelector)) {
+            return (root.innerText || '').trim().replace(/\s+/g, ' ');
+        }
+        const parts = [];
+        const walker = document.createTreeWalker(root, NodeFilter.SHOW_TEXT);
+        while (walker.nextNode()) {
+            const parent = walker.currentNode.parentElement;
+            if (!parent) continue;
+            if (['SCRIPT', 'STYLE', 'NOSCRIPT'].includes(parent.tagName)) continue;
+            const owner = parent.closest(interactiveSelector);
+            if (owner && root.contains(owner)) continue;
+            parts.push(walker.currentNode.nodeValue);
+        }
+        return parts.join(' ').trim().replace(/\s+/g, ' ');
+    };
+
+    // Collect visible non-interactive text
+    const textBlocks = [];
+    const maxTextBlocks = 300;
+    for (const node of document.querySelectorAll(textSelector)) {
+        if (textBlocks.length >= maxTextBlocks) break;
+
+        const isAlert = node.matches(alertSelector);
+
+        // Nested blocks are covered by their outermost block (alerts always kept)
+        if (!isAlert && node.parentElement && node.parentElement.closest(textSelector)) continue;
+
+        // Text inside interactive elements is already part of the element
+        if (node.closest(interactiveSelector)) continue;
+
+        const rect = node.getBoundingClientRect();
+        if (rect.width <= 0 || rect.height <= 0) continue;
+
+        const style = window.getComputedStyle(node);
+        if (style.display === 'none' || style.visibility === 'hidden') continue;
+        if (parseFloat(style.opacity) < 0.1) continue;
+
+        const buffer = 100;
+        const inViewport = !(rect.bottom < -buffer || rect.top > viewportHeight + buffer ||
+            rect.right < -buffer || rect.left > viewportWidth + buffer);
+        if (!inViewport && !includeOffscreen) continue;
+
+        let kind = 'text';
+        let level = 0;
+        let text = '';
+        const tag = node.tagName;
+        if (isAlert) {
+            kind = 'alert';
+        } else if (/^H[1-6]$/.test(tag)) {
+            kind = 'heading';
+            level = parseInt(tag[1], 10);
+        } else if (node.getAttribute('role') === 'heading') {
+            kind = 'heading';
+            level = parseInt(node.getAttribute('aria-level') || '2', 10);
+        } else if (tag === 'LABEL') {
+            kind = 'label';
+        } else if (tag === 'TR') {
+            kind = 'row';
+            text = Array.from(node.cells)
+                .map(c => (c.innerText || '').trim().replace(/\s+/g, ' '))
+                .join(' | ');
+        }
+        if (!text) text = ownText(node);
+        if (!text) continue;
+        if (text.length > 200) text = text.slice(0, 200) + '...';
+
+        textBlocks.push({
+            kind: kind,
+            level: level,
+            text: text,
+            order: orderOf.get(node) || 0,
+            boundingBox: {
+                x: rect.x,
+                y: rect.y,
+                width: rect.width,
+                height: rect.height
+            },
+            isVisible: inViewport
+        });
+    }
+
+    // Nearest labels first: above is collected top-down, so reverse it
+    above.labels.reverse();
+
+    const doc = document.documentElement;
+    const body = document.body;
     return {
         elements: elements,
+        textBlocks: textBlocks,
         pageUrl: window.location.href,
-        pageTitle: document.title
+        pageTitle: document.title,
+        scroll: {
+            scrollX: window.scrollX,
+            scrollY: window.scrollY,
+            viewportWidth: viewportWidth,
+            viewportHeight: viewportHeight,
+            pageWidth: Math.max(doc.scrollWidth, body ? body.scrollWidth : 0),
+            pageHeight: Math.max(doc.scrollHeight, body ? body.scrollHeight : 0)
+        },
+        offscreen: {
+            aboveCount: above.count,
+            belowCount: below.count,
+            aboveLabels: above.labels,
+            belowLabels: below.labels
+        }
     };
 }`
 
+// stableTimeout bounds the wait for the page to settle before extraction.
+const stableTimeout = 5 * time.Second
+
 // extractionResult is the structure returned by the extraction JavaScript.
 type extractionResult struct {
-	Elements  []*Element `json:"elements"`
-	PageURL   string     `json:"pageUrl"`
-	PageTitle string     `json:"pageTitle"`
+	Elements   []*Element       `json:"elements"`
+	TextBlocks []*TextBlock     `json:"textBlocks"`
+	PageURL    string           `json:"pageUrl"`
+	PageTitle  string           `json:"pageTitle"`
+	Scroll     ScrollInfo       `json:"scroll"`
+	Offscreen  OffscreenSummary `json:"offscreen"`
 }
 
 // Extractor handles DOM element extraction from a page.
 type Extractor struct {
-	maxElements int
+	maxElements      int
+	includeOffscreen bool
 }
 
 // NewExtractor creates a new DOM extractor.
@@ -162,16 +330,28 @@ func NewExtractor(maxElements int) *Extractor {
 	return &Extractor{maxElements: maxElements}
 }
 
+// SetIncludeOffscreen controls whether elements outside the viewport are
+// included in the element map. They are always counted in the offscreen summary.
+func (e *Extractor) SetIncludeOffscreen(include bool) {
+	e.includeOffscreen = include
+}
+
 // Extract extracts interactive elements from the page.
 func (e *Extractor) Extract(ctx context.Context, page *rod.Page) (*ElementMap, error) {
-	// Wait for page to be ready (500ms stability window)
-	_ = ctx // Context available for future use
-	if err := page.WaitStable(500 * time.Millisecond); err != nil {
+	if ctx != nil {
+		page = page.Context(ctx)
+	}
+
+	// Wait for page to be ready (500ms stability window), but not forever
+	// on pages that keep changing
+	waitCtx, cancel := context.WithTimeout(page.GetContext(), stableTimeout)
+	if err := page.Context(waitCtx).WaitStable(500 * time.Millisecond); err != nil {
 		// Continue even if wait fails - page might be dynamic
 	}
+	cancel()
 
 	// Execute extraction JavaScript
-	result, err := page.Eval(extractionJS)
+	result, err := page.Eval(extractionJS, e.includeOffscreen)
 	if err != nil {
 		return nil, fmt.Errorf("dom extraction failed: %w", err)
 	}
@@ -191,17 +371,52 @@ func (e *Extractor) Extract(ctx context.Context, page *rod.Page) (*ElementMap, e
 	elementMap := NewElementMap()
 	elementMap.PageURL = data.PageURL
 	elementMap.PageTitle = data.PageTitle
+	elementMap.Scroll = data.Scroll
+	elementMap.Offscreen = data.Offscreen
+	elementMap.TextBlocks = data.TextBlocks
 
-	for i, el := range data.Elements {
-		if i >= e.maxElements {
-			break
-		}
+	for _, el := range limitElements(data.Elements, e.maxElements) {
 		elementMap.Add(el)
 	}
 
 	return elementMap, nil
 }
 
+// limitElements caps the element list at max, preferring elements inside the
+// viewport so offscreen elements never crowd out what the user can see.
+// Document order is preserved.
+func limitElements(elements []*Element, max int) []*Element {
+	if len(elements) <= max {
+		return elements
+	}
+
+	visible := 0
+	for _, el := range elements {
+		if el.IsVisible {
+			visible++
+		}
+	}
+	offscreenBudget := max - visible
+	if offscreenBudget < 0 {
+		offscreenBudget = 0
+	}
+
+	result := make([]*Element, 0, max)
+	for _, el := range elements {
+		if len(result) >= max {
+			break
+		}
+		if !el.IsVisible {
+			if offscreenBudget == 0 {
+				continue
+			}
+			offscreenBudget--
+		}
+		result = append(result, el)
+	}
+	return result
+}
+
 // ExtractElementMap is a convenience function for extracting elements.
 func ExtractElementMap(ctx context.Context, page *rod.Page, maxElements int) (*ElementMap, error) {
 	extractor := NewExtractor(maxElements)
@@ -0,0 +1,56 @@
+package dom
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"regexp"
+	"strings"
+)
+
+// maxFingerprintText is how much of an element's text contributes to the
+// fingerprint, so long dynamic text blocks don't defeat matching.
+const maxFingerprintText = 40
+
+// idSegment matches URL path segments that look like generated IDs.
+var idSegment = regexp.MustCompile(`^(?:[0-9]+|[0-9a-fA-F]{8,}|[0-9a-fA-F-]{32,36})$`)
+
+// Fingerprint returns a hash of the page's interactive structure.
+// Two extractions of the same page in the same state produce the same
+// fingerprint even though indices and positions may differ. Element values
+// only contribute whether they are empty, so typed text is not stored.
+func (m *ElementMap) Fingerprint() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	h := sha256.New()
+	for _, el := range m.Elements {
+		text := normalizeLocatorText(el.Text)
+		if len(text) > maxFingerprintText {
+			text = text[:maxFingerprintText]
+		}
+		hasValue := "0"
+		if el.Value != "" {
+			hasValue = "1"
+		}
+		h.Write([]byte(strings.Join([]string{
+			el.TagName, el.Type, el.Role, el.Name, el.AriaLabel, el.Placeholder,
+			NormalizeHref(el.Href), text, hasValue,
+		}, "\x00")))
+		h.Write([]byte{'\n'})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// NormalizeHref replaces ID-like path segments in a link with "*" so links
+// to different records of the same kind compare equal.
+func NormalizeHref(href string) string {
+	path, _, _ := strings.Cut(href, "?")
+	path, _, _ = strings.Cut(path, "#")
+	segments := strings.Split(path, "/")
+	for i, seg := range segments {
+		if idSegment.MatchString(seg) {
+			segments[i] = "*"
+		}
+	}
+	return strings.Join(segments, "/")
+}
@@ -0,0 +1,78 @@
+package dom
+
+import "testing"
+
+func TestNormalizeHref(t *testing.T) {
+	tests := []struct {
+		href string
+		want string
+	}{
+		{href: "/orders", want: "/orders"},
+		{href: "/orders/12345", want: "/orders/*"},
+		{href: "/orders/12345/items/7", want: "/orders/*/items/*"},
+		{href: "/doc/3f2a9c1e7b", want: "/doc/*"},
+		{href: "/u/550e8400-e29b-41d4-a716-446655440000", want: "/u/*"},
+		{href: "/page/cafe", want: "/page/cafe"},
+		{href: "/v2/items", want: "/v2/items"},
+		{href: "/orders/12?sort=asc", want: "/orders/*"},
+		{href: "/orders/12#top", want: "/orders/*"},
+		{href: "https://example.com/orders/12", want: "https://example.com/orders/*"},
+		{href: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.href, func(t *testing.T) {
+			if got := NormalizeHref(tt.href); got != tt.want {
+				t.Errorf("NormalizeHref(%q) = %q, want %q", tt.href, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFingerprint(t *testing.T) {
+	page := func(elements ...*Element) *ElementMap {
+		m := NewElementMap()
+		for i, el := range elements {
+			el.Index = i
+			m.Add(el)
+		}
+		return m
+	}
+	input := func(value string) *Element {
+		return &Element{TagName: "INPUT", Type: "text", Name: "q", Value: value}
+	}
+	link := func(href, text string) *Element {
+		return &Element{TagName: "A", Href: href, Text: text}
+	}
+	base := page(input("shoes"), link("/orders/1", "Order"))
+
+	tests := []struct {
+		name string
+		page *ElementMap
+		same bool
+	}{
+		{name: "same page", page: page(input("shoes"), link("/orders/1", "Order")), same: true},
+		{name: "other typed value", page: page(input("hats"), link("/orders/1", "Order")), same: true},
+		{name: "empty value", page: page(input(""), link("/orders/1", "Order")), same: false},
+		{name: "other record link", page: page(input("shoes"), link("/orders/2", "Order")), same: true},
+		{name: "moved element", page: page(input("shoes"), &Element{TagName: "A", Href: "/orders/1", Text: "Order", BoundingBox: BoundingBox{Y: 200}}), same: true},
+		{name: "other text", page: page(input("shoes"), link("/orders/1", "Invoice")), same: false},
+		{name: "extra element", page: page(input("shoes"), link("/orders/1", "Order"), link("/help", "Help")), same: false},
+		{name: "reordered", page: page(link("/orders/1", "Order"), input("shoes")), same: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.page.Fingerprint() == base.Fingerprint(); got != tt.same {
+				t.Errorf("fingerprint equal to base = %v, want %v", got, tt.same)
+			}
+		})
+	}
+
+	long := func(tail string) *ElementMap {
+		return page(&Element{TagName: "P", Text: "Forty characters of text before the tail " + tail})
+	}
+	if long("one").Fingerprint() != long("two").Fingerprint() {
+		t.Error("text beyond the fingerprint limit changed the fingerprint")
+	}
+}
@@ -0,0 +1,185 @@
+package dom
+
+import (
+	"math"
+	"strings"
+)
+
+// Locator describes an element well enough to find it again on a later
+// extraction of the same page, when indices have been reassigned.
+// Strategies are tried from most to least specific: backend node ID,
+// CSS selector, accessible identity (role + name), visible text, and
+// finally position.
+type Locator struct {
+	Selector      string      `json:"selector,omitempty"`
+	TagName       string      `json:"tag_name"`
+	Role          string      `json:"role,omitempty"`
+	Name          string      `json:"name,omitempty"`
+	AriaLabel     string      `json:"aria_label,omitempty"`
+	Placeholder   string      `json:"placeholder,omitempty"`
+	Text          string      `json:"text,omitempty"`
+	Type          string      `json:"type,omitempty"`
+	Href          string      `json:"href,omitempty"`
+	BoundingBox   BoundingBox `json:"bounding_box"`
+	BackendNodeID int         `json:"backend_node_id,omitempty"`
+}
+
+// Locator strategies reported by Resolve.
+const (
+	LocateByBackendNodeID = "backend_node_id"
+	LocateBySelector      = "selector"
+	LocateByRole          = "role"
+	LocateByText          = "text"
+	LocateByBoundingBox   = "bounding_box"
+)
+
+// maxLocatorDistance is how far (in CSS pixels) an element's center may
+// move for the bounding box strategy to still consider it the same element.
+const maxLocatorDistance = 40.0
+
+// NewLocator captures a locator for an element.
+func NewLocator(el *Element) *Locator {
+	if el == nil {
+		return nil
+	}
+	return &Locator{
+		Selector:      el.Selector,
+		TagName:       el.TagName,
+		Role:          el.Role,
+		Name:          el.Name,
+		AriaLabel:     el.AriaLabel,
+		Placeholder:   el.Placeholder,
+		Text:          el.Text,
+		Type:          el.Type,
+		Href:          el.Href,
+		BoundingBox:   el.BoundingBox,
+		BackendNodeID: el.BackendNodeID,
+	}
+}
+
+// Description returns a short human-readable description of the located element.
+func (l *Locator) Description() string {
+	label := l.AriaLabel
+	for _, s := range []string{l.Name, l.Placeholder, l.Text} {
+		if label == "" {
+			label = s
+		}
+	}
+	if len(label) > 50 {
+		label = label[:50] + "..."
+	}
+	if label == "" {
+		return l.TagName
+	}
+	return l.TagName + " \"" + label + "\""
+}
+
+// Resolve finds the located element in an element map.
+// Returns the element and the strategy that matched, or nil and "" if no
+// strategy yields a confident match.
+func (l *Locator) Resolve(em *ElementMap) (*Element, string) {
+	if l == nil || em == nil {
+		return nil, ""
+	}
+
+	em.mu.RLock()
+	defer em.mu.RUnlock()
+
+	sameTag := make([]*Element, 0, len(em.Elements))
+	for _, el := range em.Elements {
+		if el.TagName == l.TagName {
+			sameTag = append(sameTag, el)
+		}
+	}
+
+	if l.BackendNodeID != 0 {
+		for _, el := range sameTag {
+			if el.BackendNodeID == l.BackendNodeID {
+				return el, LocateByBackendNodeID
+			}
+		}
+	}
+
+	if l.Selector != "" {
+		if el := l.closest(filterElements(sameTag, func(el *Element) bool {
+			return el.Selector == l.Selector && (l.Text == "" || el.Text == l.Text || el.Type != "")
+		})); el != nil {
+			return el, LocateBySelector
+		}
+	}
+
+	if l.hasIdentity() {
+		if el := l.closest(filterElements(sameTag, func(el *Element) bool {
+			return el.Role == l.Role && el.Name == l.Name && el.AriaLabel == l.AriaLabel &&
+				el.Placeholder == l.Placeholder && el.Type == l.Type
+		})); el != nil {
+			return el, LocateByRole
+		}
+	}
+
+	if text := normalizeLocatorText(l.Text); text != "" {
+		if el := l.closest(filterElements(sameTag, func(el *Element) bool {
+			return normalizeLocatorText(el.Text) == text
+		})); el != nil {
+			return el, LocateByText
+		}
+	}
+
+	if el := l.closest(sameTag); el != nil && l.distance(el) <= maxLocatorDistance && l.similarSize(el) {
+		return el, LocateByBoundingBox
+	}
+
+	return nil, ""
+}
+
+// hasIdentity returns true if the locator has accessible identity to match on.
+func (l *Locator) hasIdentity() bool {
+	return l.Name != "" || l.AriaLabel != "" || l.Placeholder != ""
+}
+
+// closest picks the candidate nearest to the recorded position.
+func (l *Locator) closest(candidates []*Element) *Element {
+	var best *Element
+	bestDist := math.MaxFloat64
+	for _, el := range candidates {
+		if d := l.distance(el); d < bestDist {
+			best, bestDist = el, d
+		}
+	}
+	return best
+}
+
+// distance returns the distance between the recorded and the element's center.
+func (l *Locator) distance(el *Element) float64 {
+	x1, y1 := l.BoundingBox.Center()
+	x2, y2 := el.BoundingBox.Center()
+	return math.Hypot(x1-x2, y1-y2)
+}
+
+// similarSize returns true if the element is roughly the recorded size.
+func (l *Locator) similarSize(el *Element) bool {
+	within := func(a, b float64) bool {
+		if a == 0 || b == 0 {
+			return a == b
+		}
+		ratio := a / b
+		return ratio > 0.75 && ratio < 1.33
+	}
+	return within(l.BoundingBox.Width, el.BoundingBox.Width) && within(l.BoundingBox.Height, el.BoundingBox.Height)
+}
+
+// filterElements returns the elements matching keep.
+func filterElements(elements []*Element, keep func(*Element) bool) []*Element {
+	var out []*Element
+	for _, el := range elements {
+		if keep(el) {
+			out = append(out, el)
+		}
+	}
+	return out
+}
+
+// normalizeLocatorText lowercases and collapses whitespace for text matching.
+func normalizeLocatorText(s string) string {
+	return strings.ToLower(strings.Join(strings.Fields(s), " "))
+}
@@ -0,0 +1,365 @@
+package dom
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-rod/rod"
+)
+
+// markdownJS converts the visible DOM (or a scoped subtree) to Markdown.
+// Tables become Markdown tables, links keep their hrefs and images their alt text.
+// The scope argument is {selector, x, y, hasPoint}. A point scopes to the
+// container (table, form, section, list...) around the element at that
+// position, falling back to the selector. An empty scope converts the main
+// content area (falling back to the body).
+// IMPORTANT: Must use arrow function syntax for rod.Eval()
+const markdownJS = `(scope, maxChars) => {
+    let root = null;
+    if (scope.hasPoint) {
+        // Scope to the container around the element, not the element itself
+        const hit = document.elementFromPoint(scope.x, scope.y);
+        if (hit) {
+            root = hit.closest('table, [role="grid"], form, section, article, ul, ol, dl, ' +
+                '[role="region"], [role="dialog"], main') || hit.parentElement || hit;
+        }
+    }
+    if (!root && scope.selector) {
+        root = document.querySelector(scope.selector);
+        if (!root) throw new Error('no element matches selector: ' + scope.selector);
+    }
+    if (!root && scope.hasPoint) throw new Error('no element at scoped position');
+    if (!root) root = document.querySelector('main, article, [role="main"]') || document.body;
+
+    const skipTags = new Set(['SCRIPT', 'STYLE', 'NOSCRIPT', 'SVG', 'TEMPLATE', 'IFRAME', 'CANVAS', 'HEAD']);
+    const blockTags = new Set(['DIV', 'SECTION', 'ARTICLE', 'MAIN', 'HEADER', 'FOOTER', 'NAV',
+        'ASIDE', 'FORM', 'FIELDSET', 'FIGURE', 'DETAILS', 'SUMMARY', 'DL', 'ADDRESS']);
+
+    const isHidden = (el) => {
+        if (el.checkVisibility) return !el.checkVisibility();
+        const style = window.getComputedStyle(el);
+        return style.display === 'none' || style.visibility === 'hidden';
+    };
+    const collapse = (s) => s.replace(/\s+/g, ' ');
+    const escapeCell = (s) => s.replace(/\|/g, '\\|').replace(/\n+/g, ' ').trim();
+
+    const inline = (node) => convert(node, { inTable: true, depth: 0 }).replace(/\s+/g, ' ').trim();
+
+    const table = (el) => {
+        const rows = [];
+        for (const tr of el.rows) {
+            const cells = [];
+            for (const cell of tr.cells) {
+                cells.push(escapeCell(inline(cell)));
+                const span = Math.min(cell.colSpan || 1, 20);
+                for (let i = 1; i < span; i++) cells.push('');
+            }
+            if (cells.every(c => c === '')) continue;
+            rows.push(cells);
+        }
+        if (rows.length === 0) return '';
+        const width = Math.max(...rows.map(r => r.length));
+        rows.forEach(r => { while (r.length < width) r.push(''); });
+        const line = (r) => '| ' + r.join(' | ') + ' |';
+        const out = [line(rows[0]), '|' + ' --- |'.repeat(width)];
+        for (const r of rows.slice(1)) out.push(line(r));
+        return '\n\n' + out.join('\n') + '\n\n';
+    };
+
+    const list = (el, ctx) => {
+        const ordered = el.tagName === 'OL';
+        let n = parseInt(el.getAttribute('start') || '1', 10);
+        const indent = '  '.repeat(ctx.depth);
+        let out = '\n';
+        for (const li of el.children) {
+            if (li.tagName !== 'LI' || isHidden(li)) continue;
+            const body = convert(li, { inTable: ctx.inTable, depth: ctx.depth + 1 }).trim()
+                .replace(/\n{2,}/g, '\n');
+            if (!body) continue;
+            const marker = ordered ? (n++) + '. ' : '- ';
+            out += indent + marker + body + '\n';
+        }
+        return out + '\n';
+    };
+
+    const convert = (node, ctx) => {
+        if (node.nodeType === Node.TEXT_NODE) return collapse(node.nodeValue);
+        if (node.nodeType !== Node.ELEMENT_NODE) return '';
+        const el = node;
+        const tag = el.tagName;
+        if (skipTags.has(tag) || isHidden(el)) return '';
+
+        const children = () => Array.from(el.childNodes).map(c => convert(c, ctx)).join('');
+
+        switch (tag) {
+            case 'H1': case 'H2': case 'H3': case 'H4': case 'H5': case 'H6': {
+                const text = children().trim();
+                return text ? '\n\n' + '#'.repeat(parseInt(tag[1], 10)) + ' ' + text + '\n\n' : '';
+            }
+            case 'P': return '\n\n' + children().trim() + '\n\n';
+            case 'BR': return ctx.inTable ? ' ' : '\n';
+            case 'HR': return '\n\n---\n\n';
+            case 'STRONG': case 'B': {
+                const text = children().trim();
+                return text ? '**' + text + '** ' : '';
+            }
+            case 'EM': case 'I': {
+                const text = children().trim();
+                return text ? '*' + text + '* ' : '';
+            }
+            case 'CODE': return '` + "`" + `' + el.textContent.trim() + '` + "`" + `';
+            case 'PRE': return '\n\n` + "```" + `\n' + el.textContent.replace(/\n+$/, '') + '\n` + "```" + `\n\n';
+            case 'A': {
+                const text = children().trim();
+                const href = el.href || '';
+                if (!href || href.startsWith('javascript:')) return text;
+                return text ? '[' + text + '](' + href + ')' : '';
+            }
+            case 'IMG': {
+                const alt = (el.getAttribute('alt') || '').trim();
+                if (!alt) return '';
+                const src = el.currentSrc || el.src || '';
+                return src && !src.startsWith('data:') ? '![' + alt + '](' + src + ')' : '![' + alt + ']';
+            }
+            case 'TABLE': return ctx.inTable ? children() : table(el);
+            case 'UL': case 'OL': return ctx.inTable ? children() : list(el, ctx);
+            case 'BLOCKQUOTE': {
+                const body = children().trim();
+                return '\n\n' + body.split('\n').map(l => '> ' + l).join('\n') + '\n\n';
+            }
+            case 'INPUT': case 'TEXTAREA': case 'SELECT': {
+                const value = el.tagName === 'SELECT' && el.selectedOptions.length > 0
+                    ? el.selectedOptions[0].textContent : el.value;
+                return value ? ' [' + collapse(value).trim() + '] ' : '';
+            }
+            case 'LI': case 'DT': case 'DD': return children() + '\n';
+        }
+
+        const text = children();
+        return blockTags.has(tag) ? '\n' + text + '\n' : text;
+    };
+
+    let md = convert(root, { inTable: false, depth: 0 });
+    // Trim trailing spaces and stray indentation (keeping nested list indents)
+    md = md.replace(/[ \t]+\n/g, '\n')
+        .replace(/\n[ \t]+(?![ \t])(?!- |\d+\. )/g, '\n')
+        .replace(/\n{3,}/g, '\n\n')
+        .trim();
+    if (md.length > maxChars) md = md.slice(0, maxChars);
+    return md;
+}`
+
+// maxMarkdownChars caps the raw markdown returned from the page.
+const maxMarkdownChars = 500000
+
+// ExtractScope limits content extraction to part of the page.
+type ExtractScope struct {
+	// Selector is a CSS selector for the root element.
+	// Used as a fallback when the point does not resolve.
+	Selector string
+
+	// X and Y, when HasPoint is set, select the container around the
+	// element at these viewport coordinates.
+	X, Y     float64
+	HasPoint bool
+}
+
+// ExtractMarkdown converts the page (or a scoped subtree) to Markdown.
+func ExtractMarkdown(ctx context.Context, page *rod.Page, scope ExtractScope) (string, error) {
+	if ctx != nil {
+		page = page.Context(ctx)
+	}
+	arg := map[string]any{
+		"selector": scope.Selector,
+		"x":        scope.X,
+		"y":        scope.Y,
+		"hasPoint": scope.HasPoint,
+	}
+	result, err := page.Eval(markdownJS, arg, maxMarkdownChars)
+	if err != nil {
+		return "", fmt.Errorf("markdown extraction failed: %w", err)
+	}
+	return result.Value.String(), nil
+}
+
+// ChunkMarkdown splits markdown into chunks of at most maxChars,
+// breaking at blank lines, then line breaks, so tables and paragraphs
+// stay intact where possible.
+func ChunkMarkdown(md string, maxChars int) []string {
+	if md == "" {
+		return nil
+	}
+	if maxChars <= 0 || len(md) <= maxChars {
+		return []string{md}
+	}
+
+	var chunks []string
+	var current strings.Builder
+
+	flush := func() {
+		if s := strings.TrimSpace(current.String()); s != "" {
+			chunks = append(chunks, s)
+		}
+		current.Reset()
+	}
+
+	for _, block := range strings.Split(md, "\n\n") {
+		for _, piece := range splitOversized(block, maxChars) {
+			if current.Len() > 0 && current.Len()+len(piece)+2 > maxChars {
+				flush()
+			}
+			if current.Len() > 0 {
+				current.WriteString("\n\n")
+			}
+			current.WriteString(piece)
+		}
+	}
+	flush()
+
+	return chunks
+}
+
+// splitOversized splits a block longer than maxChars at line breaks,
+// hard-cutting (on rune boundaries) any single line that is still too long.
+// A piece is never shorter than one rune, even if that exceeds maxChars.
+func splitOversized(block string, maxChars int) []string {
+	if len(block) <= maxChars {
+		return []string{block}
+	}
+
+	var pieces []string
+	var current strings.Builder
+	for _, line := range strings.Split(block, "\n") {
+		for len(line) > maxChars {
+			cut := maxChars
+			for cut > 0 && !utf8.RuneStart(line[cut]) {
+				cut--
+			}
+			if cut == 0 {
+				// maxChars is smaller than the first rune; keep it whole
+				_, cut = utf8.DecodeRuneInString(line)
+			}
+			if current.Len() > 0 {
+				pieces = append(pieces, current.String())
+				current.Reset()
+			}
+			pieces = append(pieces, line[:cut])
+			line = line[cut:]
+		}
+		if current.Len() > 0 && current.Len()+len(line)+1 > maxChars {
+			pieces = append(pieces, current.String())
+			current.Reset()
+		}
+		if current.Len() > 0 {
+			current.WriteString("\n")
+		}
+		current.WriteString(line)
+	}
+	if current.Len() > 0 {
+		pieces = append(pieces, current.String())
+	}
+	return pieces
+}
+
+// SplitMarkdownSections splits markdown into sections, each starting at a heading.
+// Content before the first heading forms its own section.
+func SplitMarkdownSections(md string) []string {
+	var sections []string
+	var current strings.Builder
+	inFence := false
+
+	for _, line := range strings.Split(md, "\n") {
+		if strings.HasPrefix(line, "```") {
+			inFence = !inFence
+		}
+		if !inFence && strings.HasPrefix(line, "#") && current.Len() > 0 {
+			sections = append(sections, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+	}
+	if s := strings.TrimSpace(current.String()); s != "" {
+		sections = append(sections, s)
+	}
+	return sections
+}
+
+// FilterMarkdownSections returns the sections most relevant to the query,
+// in document order, within maxChars. Sections are scored by how often the
+// query terms occur, with heading matches weighted higher.
+// Returns an empty string if no section matches.
+func FilterMarkdownSections(md, query string, maxChars int) string {
+	terms := queryTerms(query)
+	if len(terms) == 0 {
+		return md
+	}
+
+	type scored struct {
+		index int
+		score int
+		text  string
+	}
+
+	sections := SplitMarkdownSections(md)
+	var matches []scored
+	for i, section := range sections {
+		heading, _, _ := strings.Cut(section, "\n")
+		headingLower := strings.ToLower(heading)
+		bodyLower := strings.ToLower(section)
+
+		score := 0
+		for _, term := range terms {
+			score += strings.Count(bodyLower, term)
+			if strings.HasPrefix(heading, "#") {
+				score += 3 * strings.Count(headingLower, term)
+			}
+		}
+		if score > 0 {
+			matches = append(matches, scored{index: i, score: score, text: section})
+		}
+	}
+	if len(matches) == 0 {
+		return ""
+	}
+
+	// Pick highest scoring sections within budget, then restore document order
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+	var selected []scored
+	used := 0
+	for _, m := range matches {
+		if maxChars > 0 && used+len(m.text) > maxChars && len(selected) > 0 {
+			continue
+		}
+		selected = append(selected, m)
+		used += len(m.text) + 2
+	}
+	sort.Slice(selected, func(i, j int) bool { return selected[i].index < selected[j].index })
+
+	parts := make([]string, len(selected))
+	for i, s := range selected {
+		parts[i] = s.text
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// queryTerms lowercases and tokenizes a query, dropping very short words.
+func queryTerms(query string) []string {
+	words := strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	terms := make([]string, 0, len(words))
+	seen := make(map[string]bool)
+	for _, w := range words {
+		if len(w) < 3 || seen[w] {
+			continue
+		}
+		seen[w] = true
+		terms = append(terms, w)
+	}
+	return terms
+}
@@ -0,0 +1,118 @@
+package dom
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestChunkMarkdown(t *testing.T) {
+	tests := []struct {
+		name     string
+		md       string
+		maxChars int
+		want     []string
+	}{
+		{name: "empty", md: "", maxChars: 10, want: nil},
+		{name: "fits", md: "short text", maxChars: 100, want: []string{"short text"}},
+		{name: "no limit", md: "a\n\nb", maxChars: 0, want: []string{"a\n\nb"}},
+		{name: "blank lines", md: "first para\n\nsecond para", maxChars: 12, want: []string{"first para", "second para"}},
+		{name: "blocks packed", md: "aa\n\nbb\n\ncc", maxChars: 6, want: []string{"aa\n\nbb", "cc"}},
+		{name: "line breaks", md: "line one\nline two\nline three", maxChars: 18, want: []string{"line one\nline two", "line three"}},
+		{name: "hard cut", md: "abcdefghij", maxChars: 4, want: []string{"abcd", "efgh", "ij"}},
+		{name: "cut on rune boundary", md: "aé" + "é" + "é", maxChars: 4, want: []string{"aé", "éé"}},
+		{name: "limit smaller than a rune", md: "日本語", maxChars: 2, want: []string{"日", "本", "語"}},
+		{name: "limit of one byte", md: "aé", maxChars: 1, want: []string{"a", "é"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ChunkMarkdown(tt.md, tt.maxChars)
+			if strings.Join(got, "|") != strings.Join(tt.want, "|") || len(got) != len(tt.want) {
+				t.Errorf("ChunkMarkdown(%q, %d) = %q, want %q", tt.md, tt.maxChars, got, tt.want)
+			}
+			for _, chunk := range got {
+				if !utf8.ValidString(chunk) {
+					t.Errorf("chunk %q is not valid UTF-8", chunk)
+				}
+			}
+		})
+	}
+}
+
+func TestChunkMarkdownKeepsContent(t *testing.T) {
+	md := strings.Repeat("## Section\n\nSome text with ünïcödé.\n| a | b |\n| 1 | 2 |\n\n", 50)
+	for _, maxChars := range []int{1, 3, 17, 64, 500} {
+		chunks := ChunkMarkdown(md, maxChars)
+		joined := strings.Join(chunks, "")
+		if strip(joined) != strip(md) {
+			t.Errorf("maxChars %d: chunks lost content", maxChars)
+		}
+	}
+}
+
+// strip removes whitespace, which chunking may trim at chunk boundaries.
+func strip(s string) string {
+	return strings.Join(strings.Fields(s), "")
+}
+
+func TestSplitMarkdownSections(t *testing.T) {
+	md := "intro\n# One\ntext\n```\n# not a heading\n```\n## Two\nmore"
+	want := []string{"intro", "# One\ntext\n```\n# not a heading\n```", "## Two\nmore"}
+
+	got := SplitMarkdownSections(md)
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("SplitMarkdownSections() = %q, want %q", got, want)
+	}
+}
+
+func TestFilterMarkdownSections(t *testing.T) {
+	md := strings.Join([]string{
+		"# Shipping\nWe ship worldwide. Shipping takes 3 days.",
+		"# Returns\nReturns are free within 30 days.",
+		"# Pricing\nPrices include tax. Shipping is extra.",
+	}, "\n")
+
+	tests := []struct {
+		name     string
+		query    string
+		maxChars int
+		want     string
+	}{
+		{name: "no terms", query: "a an", maxChars: 0, want: md},
+		{name: "no match", query: "warranty", maxChars: 0, want: ""},
+		{name: "single section", query: "returns", maxChars: 0, want: "# Returns\nReturns are free within 30 days."},
+		{name: "case insensitive", query: "RETURNS", maxChars: 0, want: "# Returns\nReturns are free within 30 days."},
+		{
+			name:  "document order",
+			query: "shipping",
+			want:  "# Shipping\nWe ship worldwide. Shipping takes 3 days.\n\n# Pricing\nPrices include tax. Shipping is extra.",
+		},
+		{
+			name:     "budget keeps best match",
+			query:    "shipping",
+			maxChars: 50,
+			want:     "# Shipping\nWe ship worldwide. Shipping takes 3 days.",
+		},
+		{
+			name:     "tie keeps earlier section",
+			query:    "pricing shipping",
+			maxChars: 50,
+			want:     "# Shipping\nWe ship worldwide. Shipping takes 3 days.",
+		},
+		{
+			name:     "best match kept over budget",
+			query:    "returns",
+			maxChars: 5,
+			want:     "# Returns\nReturns are free within 30 days.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FilterMarkdownSections(md, tt.query, tt.maxChars); got != tt.want {
+				t.Errorf("FilterMarkdownSections(%q, %d) = %q, want %q", tt.query, tt.maxChars, got, tt.want)
+			}
+		})
+	}
+}
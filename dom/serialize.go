@@ -19,6 +19,13 @@ type SerializeOptions struct {
 
 	// Compact uses minimal whitespace.
 	Compact bool
+
+	// Diff marks elements that are new since the previous extraction with "*".
+	Diff *ElementDiff
+
+	// MaxTextChars is the character budget for non-interactive page text
+	// interleaved with the elements. 0 omits page text.
+	MaxTextChars int
 }
 
 // DefaultSerializeOptions returns sensible defaults.
@@ -28,6 +35,7 @@ func DefaultSerializeOptions() SerializeOptions {
 		IncludeBoundingBox: true,
 		IncludeSelector:    false,
 		Compact:            true,
+		MaxTextChars:       2000,
 	}
 }
 
@@ -49,7 +57,36 @@ func (m *ElementMap) ToTokenString(opts SerializeOptions) string {
 		count = opts.MaxElements
 	}
 
-	sb.WriteString(fmt.Sprintf("Interactive Elements (%d):\n", count))
+	if opts.MaxTextChars > 0 && len(m.TextBlocks) > 0 {
+		sb.WriteString(fmt.Sprintf("Interactive Elements (%d) and page text in reading order:\n", count))
+	} else {
+		sb.WriteString(fmt.Sprintf("Interactive Elements (%d):\n", count))
+	}
+
+	textBlocks := m.TextBlocks
+	if opts.MaxTextChars <= 0 {
+		textBlocks = nil
+	}
+	textUsed := 0
+	textOmitted := false
+	nextText := 0
+
+	// writeTextUntil emits text blocks that come before the given reading order.
+	writeTextUntil := func(order int) {
+		for ; nextText < len(textBlocks) && textBlocks[nextText].Order < order; nextText++ {
+			if textOmitted {
+				continue
+			}
+			line := formatTextBlock(textBlocks[nextText], opts)
+			if textUsed+len(line) > opts.MaxTextChars {
+				textOmitted = true
+				continue
+			}
+			textUsed += len(line)
+			sb.WriteString(line)
+			sb.WriteString("\n")
+		}
+	}
 
 	for i, el := range m.Elements {
 		if opts.MaxElements > 0 && i >= opts.MaxElements {
@@ -57,14 +94,93 @@ func (m *ElementMap) ToTokenString(opts SerializeOptions) string {
 			break
 		}
 
+		writeTextUntil(el.Order)
+
 		line := formatElement(el, opts)
 		sb.WriteString(line)
 		sb.WriteString("\n")
 	}
+	writeTextUntil(int(^uint(0) >> 1))
+
+	if textOmitted {
+		sb.WriteString("... (more page text omitted, use extract_content to read it)\n")
+	}
 
 	return sb.String()
 }
 
+// formatTextBlock formats a non-interactive text block as a compact line.
+// Headings use markdown-style "#" prefixes and alerts are prefixed with "!".
+func formatTextBlock(tb *TextBlock, opts SerializeOptions) string {
+	text := tb.Text
+	if len(text) > 150 {
+		text = text[:150] + "..."
+	}
+
+	var line string
+	switch tb.Kind {
+	case TextKindHeading:
+		level := tb.Level
+		if level < 1 || level > 6 {
+			level = 2
+		}
+		line = strings.Repeat("#", level) + " " + text
+	case TextKindAlert:
+		line = "! " + text
+	case TextKindLabel:
+		line = "label: " + text
+	case TextKindRow:
+		line = "| " + text + " |"
+	default:
+		line = text
+	}
+
+	if opts.Diff.IsNewText(tb) {
+		line = "* " + line
+	}
+	return line
+}
+
+// ToScrollContextString describes the scroll position and the interactive
+// elements above and below the viewport, so the LLM knows content exists
+// beyond what it can currently see.
+func (m *ElementMap) ToScrollContextString() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var sb strings.Builder
+
+	s := m.Scroll
+	if s.IsScrollable() {
+		sb.WriteString(fmt.Sprintf("Scrolled %.0f%% (%.0fpx above, %.0fpx below viewport; page height %.0fpx)\n",
+			s.PercentScrolled(), s.PixelsAbove(), s.PixelsBelow(), s.PageHeight))
+	} else {
+		sb.WriteString("Entire page fits in the viewport (no scrolling needed)\n")
+	}
+
+	if m.Offscreen.AboveCount > 0 {
+		sb.WriteString(formatOffscreen("Above", m.Offscreen.AboveCount, m.Offscreen.AboveLabels))
+	}
+	if m.Offscreen.BelowCount > 0 {
+		sb.WriteString(formatOffscreen("Below", m.Offscreen.BelowCount, m.Offscreen.BelowLabels))
+	}
+
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// formatOffscreen formats one line of the offscreen element summary.
+func formatOffscreen(position string, count int, labels []string) string {
+	line := fmt.Sprintf("%s viewport: %d interactive elements", position, count)
+	if len(labels) > 0 {
+		quoted := make([]string, len(labels))
+		for i, l := range labels {
+			quoted[i] = fmt.Sprintf("%q", l)
+		}
+		line += ", e.g. " + strings.Join(quoted, ", ")
+	}
+	return line + "\n"
+}
+
 // ToTokenStringLimited is a convenience method with a max elements limit.
 func (m *ElementMap) ToTokenStringLimited(maxElements int) string {
 	opts := DefaultSerializeOptions()
@@ -76,8 +192,12 @@ func (m *ElementMap) ToTokenStringLimited(maxElements int) string {
 func formatElement(el *Element, opts SerializeOptions) string {
 	var parts []string
 
-	// Index
-	parts = append(parts, fmt.Sprintf("[%d]", el.Index))
+	// Index (new elements are prefixed with "*")
+	if opts.Diff.IsNew(el.Index) {
+		parts = append(parts, fmt.Sprintf("*[%d]", el.Index))
+	} else {
+		parts = append(parts, fmt.Sprintf("[%d]", el.Index))
+	}
 
 	// Tag and type
 	if el.Type != "" && el.TagName == "input" {
@@ -129,6 +249,11 @@ func formatElement(el *Element, opts SerializeOptions) string {
 		parts = append(parts, "[disabled]")
 	}
 
+	// Outside the viewport (only present when offscreen extraction is enabled)
+	if !el.IsVisible {
+		parts = append(parts, "[offscreen]")
+	}
+
 	// Selector
 	if opts.IncludeSelector && el.Selector != "" {
 		parts = append(parts, fmt.Sprintf("sel=%q", el.Selector))
@@ -185,14 +310,18 @@ func (m *ElementMap) ToJSON() ([]byte, error) {
 	defer m.mu.RUnlock()
 
 	type jsonMap struct {
-		PageURL   string     `json:"pageUrl"`
-		PageTitle string     `json:"pageTitle"`
-		Elements  []*Element `json:"elements"`
+		PageURL   string           `json:"pageUrl"`
+		PageTitle string           `json:"pageTitle"`
+		Scroll    ScrollInfo       `json:"scroll"`
+		Offscreen OffscreenSummary `json:"offscreen"`
+		Elements  []*Element       `json:"elements"`
 	}
 
 	data := jsonMap{
 		PageURL:   m.PageURL,
 		PageTitle: m.PageTitle,
+		Scroll:    m.Scroll,
+		Offscreen: m.Offscreen,
 		Elements:  m.Elements,
 	}
 
@@ -0,0 +1,284 @@
+package dom
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/go-rod/rod"
+)
+
+// tableJS extracts HTML tables and ARIA grids as rows of cell text.
+// Cells spanning several rows or columns are repeated into each slot they
+// cover so columns stay aligned. Leading rows made only of header cells
+// (or rows inside thead) become the headers; multiple header rows are
+// joined per column with " / ".
+// The scope argument is {selector, x, y, hasPoint}, as in markdownJS.
+// A point scopes to the table around the element at that position.
+// IMPORTANT: Must use arrow function syntax for rod.Eval()
+const tableJS = `(scope, maxTables, maxRows) => {
+    const tableSelector = 'table, [role="grid"], [role="table"], [role="treegrid"]';
+    let root = null;
+    if (scope.hasPoint) {
+        const hit = document.elementFromPoint(scope.x, scope.y);
+        if (hit) {
+            root = hit.closest(tableSelector) ||
+                hit.closest('form, section, article, [role="region"], [role="dialog"], main') || hit.parentElement || hit;
+        }
+    }
+    if (!root && scope.selector) {
+        root = document.querySelector(scope.selector);
+        if (!root) throw new Error('no element matches selector: ' + scope.selector);
+    }
+    if (!root && scope.hasPoint) throw new Error('no element at scoped position');
+    if (!root) root = document.body;
+
+    const isHidden = (el) => {
+        if (el.checkVisibility) return !el.checkVisibility();
+        const style = window.getComputedStyle(el);
+        return style.display === 'none' || style.visibility === 'hidden';
+    };
+
+    const cellText = (cell) => {
+        let text = (cell.innerText || cell.textContent || '').replace(/\s+/g, ' ').trim();
+        if (!text) {
+            const field = cell.querySelector('input:not([type="hidden"]), select, textarea');
+            if (field) text = (field.value || '').trim();
+        }
+        if (!text) {
+            const img = cell.querySelector('img[alt]');
+            if (img) text = img.alt.trim();
+        }
+        return text;
+    };
+
+    // Normalizes rows of {text, header, colSpan, rowSpan} cells into a grid
+    const layout = (rows) => {
+        const grid = [];
+        const headerFlags = [];
+        rows.forEach((row, r) => {
+            grid[r] = grid[r] || [];
+            let c = 0;
+            for (const cell of row.cells) {
+                while (grid[r][c] !== undefined) c++;
+                const colSpan = Math.min(Math.max(cell.colSpan || 1, 1), 50);
+                const rowSpan = Math.min(Math.max(cell.rowSpan || 1, 1), rows.length - r);
+                for (let dr = 0; dr < rowSpan; dr++) {
+                    grid[r + dr] = grid[r + dr] || [];
+                    for (let dc = 0; dc < colSpan; dc++) {
+                        grid[r + dr][c + dc] = cell.text;
+                    }
+                }
+                c += colSpan;
+            }
+            headerFlags[r] = row.inHead || (row.cells.length > 0 && row.cells.every(cell => cell.header));
+        });
+
+        const width = Math.max(0, ...grid.map(r => r.length));
+        const filled = grid.map(r => {
+            const out = [];
+            for (let c = 0; c < width; c++) out.push(r[c] === undefined ? '' : r[c]);
+            return out;
+        });
+
+        let headerCount = 0;
+        while (headerCount < filled.length - 1 && headerFlags[headerCount]) headerCount++;
+
+        let headers = null;
+        if (headerCount > 0) {
+            headers = [];
+            for (let c = 0; c < width; c++) {
+                const parts = [];
+                for (let r = 0; r < headerCount; r++) {
+                    const t = filled[r][c];
+                    if (t && parts[parts.length - 1] !== t) parts.push(t);
+                }
+                headers.push(parts.join(' / '));
+            }
+        }
+        const body = filled.slice(headerCount).filter(r => r.some(t => t !== ''));
+        return { headers, rows: body };
+    };
+
+    const htmlRows = (table) => Array.from(table.rows).map(tr => ({
+        inHead: tr.parentElement && tr.parentElement.tagName === 'THEAD',
+        cells: Array.from(tr.cells).filter(td => !isHidden(td)).map(td => ({
+            text: cellText(td),
+            header: td.tagName === 'TH',
+            colSpan: td.colSpan,
+            rowSpan: td.rowSpan || 1,
+        })),
+    }));
+
+    const ariaRows = (grid) => Array.from(grid.querySelectorAll('[role="row"]'))
+        .filter(row => row.closest(tableSelector) === grid)
+        .map(row => {
+            const cells = Array.from(row.querySelectorAll(
+                '[role="cell"], [role="gridcell"], [role="columnheader"], [role="rowheader"]'))
+                .filter(cell => cell.closest('[role="row"]') === row && !isHidden(cell));
+            return {
+                inHead: false,
+                cells: cells.map(cell => ({
+                    text: cellText(cell),
+                    header: cell.getAttribute('role') === 'columnheader',
+                    colSpan: parseInt(cell.getAttribute('aria-colspan') || '1', 10),
+                    rowSpan: parseInt(cell.getAttribute('aria-rowspan') || '1', 10),
+                })),
+            };
+        });
+
+    const candidates = root.matches(tableSelector)
+        ? [root]
+        : Array.from(root.querySelectorAll(tableSelector));
+
+    const tables = [];
+    for (const el of candidates) {
+        if (tables.length >= maxTables) break;
+        const role = el.getAttribute('role');
+        if (role === 'presentation' || role === 'none' || isHidden(el)) continue;
+        // Tables wrapping other tables are layout, not data
+        if (el !== root && el.querySelector(tableSelector)) continue;
+
+        const rows = el.tagName === 'TABLE' && !role ? htmlRows(el) : ariaRows(el);
+        const { headers, rows: body } = layout(rows);
+        if (body.length === 0) continue;
+
+        let caption = '';
+        if (el.tagName === 'TABLE' && el.caption) caption = el.caption.innerText.replace(/\s+/g, ' ').trim();
+        if (!caption) caption = (el.getAttribute('aria-label') || '').trim();
+        if (!caption && el.getAttribute('aria-labelledby')) {
+            const label = document.getElementById(el.getAttribute('aria-labelledby'));
+            if (label) caption = label.innerText.replace(/\s+/g, ' ').trim();
+        }
+
+        tables.push({
+            caption,
+            headers: headers || [],
+            rows: body.slice(0, maxRows),
+            total_rows: body.length,
+        });
+    }
+    return JSON.stringify(tables);
+}`
+
+const (
+	// maxExtractedTables caps the number of tables returned per extraction.
+	maxExtractedTables = 20
+
+	// maxExtractedRows caps the rows kept per table.
+	maxExtractedRows = 5000
+)
+
+// Table is a table extracted from the page.
+type Table struct {
+	// Caption is the table caption or accessible name, if any.
+	Caption string `json:"caption,omitempty"`
+
+	// Headers holds one header per column. Empty when the table has no header row.
+	Headers []string `json:"headers,omitempty"`
+
+	// Rows holds the body rows. Every row has one cell per column.
+	Rows [][]string `json:"rows"`
+
+	// TotalRows is the number of body rows on the page, which may exceed
+	// len(Rows) for very large tables.
+	TotalRows int `json:"total_rows"`
+}
+
+// Records returns the rows as maps keyed by header.
+// Returns nil if the table has no headers.
+func (t Table) Records() []map[string]string {
+	if len(t.Headers) == 0 {
+		return nil
+	}
+	records := make([]map[string]string, 0, len(t.Rows))
+	for _, row := range t.Rows {
+		record := make(map[string]string, len(t.Headers))
+		for i, h := range t.Headers {
+			key := h
+			if key == "" {
+				key = fmt.Sprintf("column_%d", i+1)
+			}
+			if i < len(row) {
+				record[key] = row[i]
+			}
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// Truncate returns a copy of the table with at most maxRows rows.
+func (t Table) Truncate(maxRows int) Table {
+	if maxRows <= 0 || len(t.Rows) <= maxRows {
+		return t
+	}
+	t.Rows = t.Rows[:maxRows]
+	return t
+}
+
+// ToMarkdown renders the table as a Markdown table. Every line has as many
+// cells as the widest row or header, so short rows are padded.
+func (t Table) ToMarkdown() string {
+	var sb strings.Builder
+
+	if t.Caption != "" {
+		sb.WriteString(t.Caption)
+		sb.WriteString("\n\n")
+	}
+	width := len(t.Headers)
+	for _, row := range t.Rows {
+		width = max(width, len(row))
+	}
+	if width == 0 {
+		return strings.TrimRight(sb.String(), "\n")
+	}
+
+	line := func(cells []string) {
+		sb.WriteString("|")
+		for i := range width {
+			cell := ""
+			if i < len(cells) {
+				cell = markdownCell.Replace(cells[i])
+			}
+			sb.WriteString(" ")
+			sb.WriteString(cell)
+			sb.WriteString(" |")
+		}
+		sb.WriteString("\n")
+	}
+
+	line(t.Headers)
+	sb.WriteString("|" + strings.Repeat(" --- |", width) + "\n")
+	for _, row := range t.Rows {
+		line(row)
+	}
+	return strings.TrimSuffix(sb.String(), "\n")
+}
+
+// markdownCell escapes pipes and flattens line breaks in a table cell.
+var markdownCell = strings.NewReplacer("|", "\\|", "\r\n", " ", "\n", " ")
+
+// ExtractTables extracts the tables on the page (or within a scope).
+func ExtractTables(ctx context.Context, page *rod.Page, scope ExtractScope) ([]Table, error) {
+	if ctx != nil {
+		page = page.Context(ctx)
+	}
+	arg := map[string]any{
+		"selector": scope.Selector,
+		"x":        scope.X,
+		"y":        scope.Y,
+		"hasPoint": scope.HasPoint,
+	}
+	result, err := page.Eval(tableJS, arg, maxExtractedTables, maxExtractedRows)
+	if err != nil {
+		return nil, fmt.Errorf("table extraction failed: %w", err)
+	}
+
+	var tables []Table
+	if err := json.Unmarshal([]byte(result.Value.String()), &tables); err != nil {
+		return nil, fmt.Errorf("failed to parse tables: %w", err)
+	}
+	return tables, nil
+}
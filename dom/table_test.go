@@ -0,0 +1,128 @@
+package dom
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestTableRecords(t *testing.T) {
+	tests := []struct {
+		name  string
+		table Table
+		want  []map[string]string
+	}{
+		{
+			name:  "no headers",
+			table: Table{Rows: [][]string{{"a", "b"}}},
+			want:  nil,
+		},
+		{
+			name:  "keyed by header",
+			table: Table{Headers: []string{"Name", "Price"}, Rows: [][]string{{"Hub", "$20"}, {"Cable", "$5"}}},
+			want:  []map[string]string{{"Name": "Hub", "Price": "$20"}, {"Name": "Cable", "Price": "$5"}},
+		},
+		{
+			name:  "empty headers become column_N",
+			table: Table{Headers: []string{"", "Price", ""}, Rows: [][]string{{"1", "$20", "x"}}},
+			want:  []map[string]string{{"column_1": "1", "Price": "$20", "column_3": "x"}},
+		},
+		{
+			name:  "short row",
+			table: Table{Headers: []string{"Name", "Price"}, Rows: [][]string{{"Hub"}}},
+			want:  []map[string]string{{"Name": "Hub"}},
+		},
+		{
+			name:  "no rows",
+			table: Table{Headers: []string{"Name"}},
+			want:  []map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.table.Records(); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Records() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTableTruncate(t *testing.T) {
+	table := Table{Headers: []string{"n"}, Rows: [][]string{{"1"}, {"2"}, {"3"}}, TotalRows: 3}
+
+	tests := []struct {
+		maxRows int
+		want    int
+	}{
+		{maxRows: 0, want: 3},
+		{maxRows: -1, want: 3},
+		{maxRows: 2, want: 2},
+		{maxRows: 3, want: 3},
+		{maxRows: 10, want: 3},
+	}
+
+	for _, tt := range tests {
+		got := table.Truncate(tt.maxRows)
+		if len(got.Rows) != tt.want {
+			t.Errorf("Truncate(%d) kept %d rows, want %d", tt.maxRows, len(got.Rows), tt.want)
+		}
+		if got.TotalRows != 3 || len(got.Headers) != 1 {
+			t.Errorf("Truncate(%d) changed the table: %+v", tt.maxRows, got)
+		}
+	}
+	if len(table.Rows) != 3 {
+		t.Errorf("Truncate modified the original table: %d rows", len(table.Rows))
+	}
+}
+
+func TestTableToMarkdown(t *testing.T) {
+	tests := []struct {
+		name  string
+		table Table
+		want  string
+	}{
+		{
+			name:  "empty",
+			table: Table{},
+			want:  "",
+		},
+		{
+			name:  "caption only",
+			table: Table{Caption: "Orders"},
+			want:  "Orders",
+		},
+		{
+			name:  "headers and rows",
+			table: Table{Caption: "Orders", Headers: []string{"ID", "Total"}, Rows: [][]string{{"1", "$20"}}},
+			want:  "Orders\n\n| ID | Total |\n| --- | --- |\n| 1 | $20 |",
+		},
+		{
+			name:  "no headers",
+			table: Table{Rows: [][]string{{"a", "b"}}},
+			want:  "|  |  |\n| --- | --- |\n| a | b |",
+		},
+		{
+			name:  "pipes escaped",
+			table: Table{Headers: []string{"a|b"}, Rows: [][]string{{"x | y"}}},
+			want:  "| a\\|b |\n| --- |\n| x \\| y |",
+		},
+		{
+			name:  "line breaks flattened",
+			table: Table{Headers: []string{"Address"}, Rows: [][]string{{"1 Main St\nSpringfield"}}},
+			want:  "| Address |\n| --- |\n| 1 Main St Springfield |",
+		},
+		{
+			name:  "width from widest row",
+			table: Table{Headers: []string{"A"}, Rows: [][]string{{"1"}, {"2", "3", "4"}}},
+			want:  "| A |  |  |\n| --- | --- | --- |\n| 1 |  |  |\n| 2 | 3 | 4 |",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.table.ToMarkdown(); got != tt.want {
+				t.Errorf("ToMarkdown() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
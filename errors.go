@@ -1,6 +1,11 @@
 package bua
 
-import "errors"
+import (
+	"errors"
+
+	"github.com/anxuanzi/bua/agent"
+	"github.com/anxuanzi/bua/browser"
+)
 
 // Common errors returned by the bua package.
 var (
@@ -16,21 +21,71 @@ var (
 	// ErrMaxStepsReached is returned when the agent exceeds MaxSteps.
 	ErrMaxStepsReached = errors.New("bua: maximum steps reached without completing task")
 
-	// ErrBrowserClosed is returned when the browser is unexpectedly closed.
-	ErrBrowserClosed = errors.New("bua: browser was closed")
+	// ErrBrowserClosed is returned when the browser crashes or disconnects
+	// and Config.RelaunchBrowser is not set or could not recover it.
+	ErrBrowserClosed = browser.ErrBrowserClosed
 
-	// ErrElementNotFound is returned when an element index is invalid.
-	ErrElementNotFound = errors.New("bua: element not found")
+	// ErrElementNotFound is returned when an element index is not in the
+	// current page state.
+	ErrElementNotFound = browser.ErrElementNotFound
 
-	// ErrElementNotVisible is returned when an element is not visible.
-	ErrElementNotVisible = errors.New("bua: element is not visible")
+	// ErrElementNotVisible is returned when a pointer action targets an
+	// element outside the viewport.
+	ErrElementNotVisible = browser.ErrElementNotVisible
 
 	// ErrNavigationFailed is returned when page navigation fails.
-	ErrNavigationFailed = errors.New("bua: navigation failed")
+	ErrNavigationFailed = browser.ErrNavigationFailed
+
+	// ErrURLBlocked is returned when a URL is rejected by Config.URLPolicy.
+	ErrURLBlocked = browser.ErrURLBlocked
+
+	// ErrUnknownSecret is returned when typed text has a placeholder for a
+	// secret that is not in Config.Secrets.
+	ErrUnknownSecret = browser.ErrUnknownSecret
 
 	// ErrTimeout is returned when an operation times out.
-	ErrTimeout = errors.New("bua: operation timed out")
+	ErrTimeout = browser.ErrTimeout
+
+	// ErrInvalidArgument is returned when a browser operation is called
+	// with an argument it can't act on.
+	ErrInvalidArgument = browser.ErrInvalidArgument
+
+	// ErrTabNotFound is returned when a tab ID does not match an open tab.
+	ErrTabNotFound = browser.ErrTabNotFound
+
+	// ErrCheckpointNotFound is returned by Resume when no checkpoint exists for the run ID.
+	ErrCheckpointNotFound = agent.ErrCheckpointNotFound
+
+	// ErrCacheMiss is returned by ActionCache.Get when nothing is cached for a key.
+	ErrCacheMiss = agent.ErrCacheMiss
 
 	// ErrHumanTakeoverTimeout is returned when human intervention times out.
 	ErrHumanTakeoverTimeout = errors.New("bua: human takeover timed out")
 )
+
+// ElementError reports an action that failed on an element. It wraps
+// ErrElementNotFound, ErrElementNotVisible or the underlying failure.
+type ElementError = browser.ElementError
+
+// NavigationError reports a failed navigation with the URL, HTTP status and
+// Chrome network error. It wraps ErrNavigationFailed.
+type NavigationError = browser.NavigationError
+
+// PolicyError reports a URL rejected by Config.URLPolicy and why. It wraps
+// ErrURLBlocked.
+type PolicyError = browser.PolicyError
+
+// Error codes reported in Step.ErrorCode and in failed tool results.
+const (
+	ErrCodeElementNotFound   = agent.ErrCodeElementNotFound
+	ErrCodeElementNotVisible = agent.ErrCodeElementNotVisible
+	ErrCodeNavigationFailed  = agent.ErrCodeNavigationFailed
+	ErrCodeURLBlocked        = agent.ErrCodeURLBlocked
+	ErrCodeTimeout           = agent.ErrCodeTimeout
+	ErrCodeBrowserClosed     = agent.ErrCodeBrowserClosed
+	ErrCodeNoPageState       = agent.ErrCodeNoPageState
+	ErrCodeInvalidArgument   = agent.ErrCodeInvalidArgument
+	ErrCodePermissionDenied  = agent.ErrCodePermissionDenied
+	ErrCodeCanceled          = agent.ErrCodeCanceled
+	ErrCodeActionFailed      = agent.ErrCodeActionFailed
+)
@@ -42,7 +42,7 @@ func main() {
 
 	// Navigate to a page with interactive elements
 	fmt.Println("Navigating to example.com...")
-	if err := b.Navigate(ctx, "https://example.com"); err != nil {
+	if _, err := b.Navigate(ctx, "https://example.com"); err != nil {
 		log.Fatalf("Failed to navigate: %v", err)
 	}
 
@@ -96,7 +96,7 @@ func main() {
 
 	// Try a more complex page
 	fmt.Println("\nNavigating to DuckDuckGo for more elements...")
-	if err := b.Navigate(ctx, "https://duckduckgo.com"); err != nil {
+	if _, err := b.Navigate(ctx, "https://duckduckgo.com"); err != nil {
 		log.Fatalf("Failed to navigate to DuckDuckGo: %v", err)
 	}
 
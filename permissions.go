@@ -0,0 +1,30 @@
+package bua
+
+import "github.com/anxuanzi/bua/agent"
+
+// ToolPermissions restricts the tools the model may use and validates their
+// arguments. Tools that are not permitted are not offered to the model at
+// all; done is always permitted. See Config.ToolPermissions.
+type ToolPermissions = agent.ToolPermissions
+
+// ToolProfile is a predefined set of tools, see ToolPermissions.Profile.
+type ToolProfile = agent.ToolProfile
+
+// Tool profiles, from most to least capable.
+const (
+	// ToolProfileFull allows every tool, including evaluate_js.
+	ToolProfileFull = agent.ToolProfileFull
+
+	// ToolProfileFormFilling allows browsing, typing and key presses, but
+	// not running scripts.
+	ToolProfileFormFilling = agent.ToolProfileFormFilling
+
+	// ToolProfileReadOnly allows navigating, clicking, scrolling, reading
+	// and tabs, but not typing, key presses or scripts.
+	ToolProfileReadOnly = agent.ToolProfileReadOnly
+)
+
+// ToolValidator checks the arguments of a tool call, as decoded from JSON,
+// before it runs. A non-nil error rejects the call with error code
+// permission_denied and is shown to the model.
+type ToolValidator = agent.ToolValidator
@@ -0,0 +1,17 @@
+package bua
+
+import "github.com/anxuanzi/bua/agent"
+
+// Plan is the planner's checklist for a task. See Config.Planner.
+type Plan = agent.Plan
+
+// PlanItem is one sub-goal of a Plan.
+type PlanItem = agent.PlanItem
+
+// Plan item statuses.
+const (
+	PlanPending    = agent.PlanPending
+	PlanInProgress = agent.PlanInProgress
+	PlanDone       = agent.PlanDone
+	PlanFailed     = agent.PlanFailed
+)
@@ -0,0 +1,12 @@
+package bua
+
+import "github.com/anxuanzi/bua/browser"
+
+// URLPolicy restricts which pages the agent may load, by domain and URL
+// scheme. It is checked when navigating and opening tabs, and for every
+// page load in a tab, so links, form submissions and redirects that lead
+// elsewhere are blocked too. See Config.URLPolicy.
+//
+// Domain patterns match a host and its subdomains, so "example.com" matches
+// "shop.example.com"; "*.example.com" matches only the subdomains.
+type URLPolicy = browser.URLPolicy
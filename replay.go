@@ -0,0 +1,53 @@
+package bua
+
+import (
+	"context"
+
+	"github.com/anxuanzi/bua/agent"
+	"github.com/anxuanzi/bua/dom"
+)
+
+// Locator identifies an element by selector, role, text, position and
+// backend node ID so it can be found again on a later visit.
+type Locator = dom.Locator
+
+// Script is a recorded run that can be replayed without the LLM.
+type Script = agent.Script
+
+// ScriptStep is a single recorded action in a Script.
+type ScriptStep = agent.ScriptStep
+
+// ReplayResult is the outcome of replaying a Script.
+type ReplayResult = agent.ReplayResult
+
+// ReplayStep records how a single script step was replayed.
+type ReplayStep = agent.ReplayStep
+
+// Script returns a replayable recording of the run's successful actions.
+// Save it with Script.Save and replay it with Agent.Replay, or export it
+// as a test with Script.ExportGoTest or Script.ExportPlaywright.
+func (r *Result) Script() *Script {
+	return r.script
+}
+
+// LoadScript reads a script saved with Script.Save.
+func LoadScript(path string) (*Script, error) {
+	return agent.LoadScript(path)
+}
+
+// Replay executes a recorded script directly against the browser, without
+// model calls. Steps whose target element cannot be located are handed to
+// the LLM agent, so a changed page costs one agent call instead of a full run.
+func (a *Agent) Replay(ctx context.Context, script *Script) (*ReplayResult, error) {
+	a.mu.RLock()
+	started := a.started
+	b := a.browser
+	browserAgent := a.agent
+	a.mu.RUnlock()
+
+	if !started {
+		return nil, ErrNotStarted
+	}
+
+	return agent.NewReplayer(b, browserAgent, a.config.Debug).Replay(ctx, script)
+}
@@ -4,6 +4,10 @@ import "time"
 
 // Result represents the outcome of a task execution.
 type Result struct {
+	// RunID identifies the run. Pass it to Agent.Resume to continue an
+	// interrupted run when a CheckpointStore is configured.
+	RunID string
+
 	// Success indicates whether the task completed successfully.
 	Success bool
 
@@ -25,6 +29,29 @@ type Result struct {
 
 	// ScreenshotPaths contains paths to saved screenshots.
 	ScreenshotPaths []string
+
+	// CacheHits is the number of steps replayed from Config.ActionCache
+	// and verified without a model call.
+	CacheHits int
+
+	// CacheMisses is the number of turns the cache had no usable action
+	// for, including cached actions whose outcome could not be verified.
+	CacheMisses int
+
+	// Plan is the planner's final checklist when Config.Planner is set.
+	Plan *Plan
+
+	// Retries is the number of model calls retried after transient errors,
+	// including the planner's calls when Config.Planner is set.
+	Retries int
+
+	// Partial is set when Config.MaxDuration stopped the run, or the agent
+	// gave up because time was running out. Data then holds what was
+	// gathered so far, if anything.
+	Partial bool
+
+	// script is the replayable recording of the run.
+	script *Script
 }
 
 // Step represents a single action in the execution sequence.
@@ -53,15 +80,44 @@ type Step struct {
 	// URL is the page URL at this step.
 	URL string
 
+	// Locator identifies the target element for element actions, so the
+	// step can be replayed after element indices change.
+	Locator *Locator
+
 	// Title is the page title at this step.
 	Title string
 
 	// ScreenshotPath is the path to the screenshot for this step.
 	ScreenshotPath string
 
-	// Duration is how long this step took.
+	// Duration is how long this step took: the sum of ModelDuration,
+	// ToolDuration and ScreenshotDuration.
 	Duration time.Duration
 
-	// Error contains any error that occurred during this step.
+	// ModelDuration is the model's response time. A turn's model call is
+	// counted on its first step only.
+	ModelDuration time.Duration
+
+	// ToolDuration is how long the action itself ran.
+	ToolDuration time.Duration
+
+	// ScreenshotDuration is time spent capturing screenshots for this step.
+	ScreenshotDuration time.Duration
+
+	// Error contains the tool's error message if the action failed.
 	Error string
+
+	// ErrorCode classifies Error, e.g. ErrCodeElementNotFound.
+	ErrorCode string
+
+	// Cached is true if the action was replayed from the action cache.
+	Cached bool
+
+	// PlanItem is the checklist item the agent was working on, when
+	// Config.Planner is set.
+	PlanItem string
+
+	// Plan is the updated checklist, set on the first step after the
+	// planner created or revised it.
+	Plan *Plan
 }
@@ -0,0 +1,9 @@
+package bua
+
+import "github.com/anxuanzi/bua/agent"
+
+// RetryPolicy controls how failed model calls are retried with exponential
+// backoff and jitter. Rate limits (429), server errors (5xx), timeouts and
+// dropped connections are retried; other errors fail the run immediately.
+// A retry delay requested by the API is honored. See Config.Retry.
+type RetryPolicy = agent.RetryPolicy
@@ -128,6 +128,9 @@ func Capture(ctx context.Context, page *rod.Page, opts Options) ([]byte, error)
 	if opts.StabilityTimeout == 0 {
 		opts.StabilityTimeout = 500 * time.Millisecond
 	}
+	if ctx != nil {
+		page = page.Context(ctx)
+	}
 
 	// Check for blank page if configured
 	if opts.SkipBlankPages {
@@ -215,8 +218,16 @@ func isBlankPage(page *rod.Page) bool {
 	return false
 }
 
+// readyTimeout bounds waitForPageReady, so a page that never settles is
+// captured as is.
+const readyTimeout = 5 * time.Second
+
 // waitForPageReady waits for the page to be ready for screenshot.
 func waitForPageReady(ctx context.Context, page *rod.Page, opts Options) error {
+	waitCtx, cancel := context.WithTimeout(page.GetContext(), readyTimeout)
+	defer cancel()
+	page = page.Context(waitCtx)
+
 	// Wait for page load event
 	if opts.WaitForLoad {
 		if err := page.WaitLoad(); err != nil {
@@ -0,0 +1,82 @@
+package bua
+
+import (
+	"context"
+	"sync"
+
+	"github.com/anxuanzi/bua/agent"
+)
+
+// Session is a multi-turn conversation with the agent on the same browser.
+// Each Run is a new task, but the model keeps the conversation, history and
+// memory from earlier tasks, so follow-ups like "now add the second result
+// to the cart too" work. Runs within a session are serialized.
+type Session struct {
+	agent *Agent
+	conv  *agent.Conversation
+	mu    sync.Mutex
+}
+
+// NewSession starts a new conversation. The agent must be started.
+func (a *Agent) NewSession() (*Session, error) {
+	a.mu.RLock()
+	started := a.started
+	a.mu.RUnlock()
+
+	if !started {
+		return nil, ErrNotStarted
+	}
+
+	return &Session{
+		agent: a,
+		conv:  agent.NewConversation(),
+	}, nil
+}
+
+// Run executes the next task in the conversation.
+func (s *Session) Run(ctx context.Context, task string) (*Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.agent.mu.RLock()
+	started := s.agent.started
+	browserAgent := s.agent.agent
+	s.agent.mu.RUnlock()
+
+	if !started {
+		return nil, ErrNotStarted
+	}
+
+	agentResult, err := browserAgent.RunConversation(ctx, s.conv, task)
+	if err != nil {
+		return nil, err
+	}
+
+	return convertResult(agentResult), nil
+}
+
+// Reset clears the conversation so the next Run starts without any context
+// from earlier tasks. The browser state (tabs, cookies) is left as is.
+func (s *Session) Reset(ctx context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.agent.mu.RLock()
+	browserAgent := s.agent.agent
+	s.agent.mu.RUnlock()
+
+	if browserAgent == nil {
+		s.conv = agent.NewConversation()
+		return nil
+	}
+
+	return browserAgent.ResetConversation(ctx, s.conv)
+}
+
+// ID returns the conversation's session ID, or "" before the first Run.
+func (s *Session) ID() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.conv.SessionID()
+}
@@ -0,0 +1,18 @@
+package bua
+
+import "github.com/anxuanzi/bua/agent"
+
+// CustomTool is a user-defined ADK tool offered to the model alongside the
+// browser tools, e.g. a CRM lookup, fetching an OTP or posting to an
+// internal API. It is listed in the system prompt under its Category and
+// its calls are recorded as steps. See Config.CustomTools.
+type CustomTool = agent.CustomTool
+
+// ToolEnv is passed to CustomTool.New. It holds the Browser of the run and
+// returns the ElementMap of the page state the model last saw.
+type ToolEnv = agent.ToolEnv
+
+// AgentBrain is the reasoning the model attaches to a tool call. Add it to
+// a custom tool's arguments as Reasoning AgentBrain `json:"reasoning,omitempty"`
+// to record the reasoning on the step.
+type AgentBrain = agent.AgentBrain